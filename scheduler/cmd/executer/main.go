@@ -6,7 +6,6 @@ import (
 	"io"
 	"os"
 	"os/exec"
-	"strings"
 	"sync"
 	"time"
 
@@ -35,35 +34,19 @@ type StateLeaf struct {
 
 
 
-// File looks something like:
-// address 0x58311aaf5ebf42095ee0d620b97697a0b4c2f11c
-// address 0x96fc1d3b4b39982a82776bcc88e32dc934d7b6fa
-// storage 0x58311aaf5ebf42095ee0d620b97697a0b4c2f11c 0x0000000000000000000000000000000000000000000000000000000000000000
-func parseStateLeaves(data string) []StateLeaf {
+// Returns the state leaves in an access list. An account leaf is its address,
+// and a storage leaf is the contract address followed by the slot.
+func accessListLeaves(list *messages.AccessList) []StateLeaf {
 	leaves := []StateLeaf{}
 
-	for _, line := range(strings.Split(data, "\n")) {
-		buf := []byte{}
-
-		if line == "" {
-			continue
-		}
-
-		parts := strings.Split(line, " ")
-		if parts[0] == "address" {
-			account := hexutil.MustDecode(parts[1])
-			buf = append(buf, account...)
-		} else if parts[0] == "storage" {
-			contract := hexutil.MustDecode(parts[1])
-			index := hexutil.MustDecode(parts[2])
-			buf = append(buf, contract...)
-			buf = append(buf, index...)
-		}
-
-		if len(buf) == 0 {
-			panic("no data for state leaf")
-		}
+	for _, account := range list.GetAccounts() {
+		leaves = append(leaves, StateLeaf{account.Address})
+	}
 
+	for _, slot := range list.GetStorage() {
+		buf := []byte{}
+		buf = append(buf, slot.Address...)
+		buf = append(buf, slot.Slot...)
 		leaves = append(leaves, StateLeaf{buf})
 	}
 	
@@ -130,7 +113,7 @@ func main() {
 		// For every tx, we insert it into the graph, pointing it at the 
 		// txs it depends on.
 		for _, tx := range res.Txs {
-			reads := parseStateLeaves(string(tx.StateReads))
+			reads := accessListLeaves(tx.StateReads)
 
 			node := &ExecutionNode{
 				tx: tx,
@@ -2,7 +2,13 @@ module github.com/liamzebedee/goliath/scheduler-executer
 
 go 1.18
 
-// replace github.com/liamzebedee/goliath-blockchain/sequencer/mvp => ../sequencer/mvp
+replace github.com/liamzebedee/goliath-blockchain/sequencer/mvp => ../sequencer/mvp
+
+require (
+	github.com/ethereum/go-ethereum v1.10.18
+	github.com/golang/protobuf v1.5.2
+	github.com/liamzebedee/goliath-blockchain/sequencer/mvp v0.0.0-20220623034338-ec5ff6da4e05
+)
 
 require (
 	// github.com/liamzebedee/goliath/mvp/sequencer v0.0.0
@@ -16,20 +22,17 @@ require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/deckarep/golang-set v1.8.0 // indirect
 	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.0.1 // indirect
-	github.com/ethereum/go-ethereum v1.10.18 // indirect
 	github.com/go-kit/kit v0.9.0 // indirect
 	github.com/go-logfmt/logfmt v0.5.0 // indirect
 	github.com/go-ole/go-ole v1.2.6 // indirect
 	github.com/go-stack/stack v1.8.1 // indirect
 	github.com/gogo/protobuf v1.3.2 // indirect
-	github.com/golang/protobuf v1.5.2 // indirect
 	github.com/golang/snappy v0.0.4 // indirect
 	github.com/google/uuid v1.3.0 // indirect
 	github.com/gorilla/websocket v1.5.0 // indirect
 	github.com/gtank/merlin v0.1.1-0.20191105220539-8318aed1a79f // indirect
 	github.com/gtank/ristretto255 v0.1.2 // indirect
 	github.com/jmhodges/levigo v1.0.0 // indirect
-	github.com/liamzebedee/goliath-blockchain/sequencer/mvp v0.0.0-20220623034338-ec5ff6da4e05 // indirect
 	github.com/libp2p/go-buffer-pool v0.0.2 // indirect
 	github.com/mattn/go-colorable v0.1.12 // indirect
 	github.com/mattn/go-isatty v0.0.14 // indirect
@@ -12,6 +12,7 @@ import (
 	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/utils"
 	"github.com/libp2p/go-libp2p-core/host"
 	_ "github.com/mattn/go-sqlite3"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 func generateMockSequenceTx(signer utils.Signer, nonce int) (*messages.SequenceTx) {
@@ -67,7 +68,7 @@ func waitForPeeringsConnected(host host.Host, num int) (chan bool) {
 func simulate(numReplicas, numSequenceTxs int, waitDuration time.Duration) {
 	// Create primary node.
 	// file::memory:?cache=shared
-	// primary := sequencer.NewSequencerNode("file:swag?cache=shared", "49000", "49001", sequencer.PrimaryMode, "0x08011240e6d9a1faa2fbf1e669169b8813e4439c5d304f82bccdf6a8da30d7e1679edd6e9ca03937ad7b1c86347c24db827cfd0da2743e4946d7437ed6e1571560cad484", "", "3fd7f88cb790c6a8b54d4e1aaebba6775f427bb8fa2276e933b7c3440f164caa")
+	// primary := sequencer.NewSequencerNode("file:swag?cache=shared", "49000", "", "49001", sequencer.PrimaryMode, "0x08011240e6d9a1faa2fbf1e669169b8813e4439c5d304f82bccdf6a8da30d7e1679edd6e9ca03937ad7b1c86347c24db827cfd0da2743e4946d7437ed6e1571560cad484", "", "3fd7f88cb790c6a8b54d4e1aaebba6775f427bb8fa2276e933b7c3440f164caa", sequencer.DefaultReadyMaxLag)
 
 	// Create 5 replicas.
 	replicas := make([]*sequencer.SequencerNode, numReplicas)
@@ -79,7 +80,7 @@ func simulate(numReplicas, numSequenceTxs int, waitDuration time.Duration) {
 		// etc.
 		rpcPort := 49100 + i*100
 		p2pPort := 49101 + i*100
-		replicas[i] = sequencer.NewSequencerNode(":memory:", fmt.Sprint(rpcPort), fmt.Sprint(p2pPort), sequencer.ReplicaMode, "", "/ip4/127.0.0.1/tcp/49001/p2p/12D3KooWLMmULYCrke9PiATTDTmE4pMDCtxiffWTTM3mhTXgfw2K", "")
+		replicas[i] = sequencer.NewSequencerNode(":memory:", fmt.Sprint(rpcPort), "", fmt.Sprint(p2pPort), sequencer.ReplicaMode, "", "/ip4/127.0.0.1/tcp/49001/p2p/12D3KooWLMmULYCrke9PiATTDTmE4pMDCtxiffWTTM3mhTXgfw2K", "", sequencer.DefaultReadyMaxLag)
 	}
 
 	// Start them up.
@@ -151,12 +152,15 @@ func simulate(numReplicas, numSequenceTxs int, waitDuration time.Duration) {
 	<-wait
 
 	for i, rep := range(replicas) {
+		metrics := rep.Seq.Metrics
 		fmt.Printf(
-			"seq #%3d: lastBlock=%5d waitingOn=%5d totalBlocks=%5d n_peers=%3d\n", 
+			"seq #%3d: lastBlock=%5.0f waitingOn=%5d totalBlocks=%5.0f outOfOrder=%5.0f lag=%5.0f n_peers=%3d\n", 
 			i, 
-			rep.Seq.LastBlock.Height, 
+			testutil.ToFloat64(metrics.ChainHeight), 
 			rep.Seq.WaitedBlocks(10000000),
-			rep.Seq.TotalSeen, 
+			testutil.ToFloat64(metrics.BlocksReceived), 
+			testutil.ToFloat64(metrics.OutOfOrderBlocks), 
+			testutil.ToFloat64(metrics.ReplicaLag), 
 			len(rep.P2P.Host.Network().Peers()),
 		)
 	}
@@ -0,0 +1,164 @@
+package commands
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/google/subcommands"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/archive"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/config"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Number of blocks to read from the database at a time.
+const exportBatchSize = 1000
+
+type ExportCmd struct {
+	home *string
+	dbPath *string
+	from *int64
+	to *int64
+	out *string
+}
+
+func (*ExportCmd) Name() string     { return "export" }
+func (*ExportCmd) Synopsis() string { return "exports signed blocks to an archive file." }
+func (*ExportCmd) Usage() string {
+  return `export [-home <dir>|-dbpath <path>] [-from <height>] [-to <height>] [-out <file>]:
+  Writes the node's signed blocks to a portable archive, for backups and
+  bootstrapping replicas with import. Writes to stdout unless -out is given.
+`
+}
+
+func (cmd *ExportCmd) SetFlags(f *flag.FlagSet) {
+	cmd.home = f.String("home", "", "node home directory")
+	cmd.dbPath = f.String("dbpath", "", "path to the database, instead of the one in -home")
+	cmd.from = f.Int64("from", 1, "height of the first block to export")
+	cmd.to = f.Int64("to", 0, "height of the last block to export (default the tip)")
+	cmd.out = f.String("out", "", "file to write the archive to")
+}
+
+func (cmd *ExportCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	err := cmd.export()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return subcommands.ExitFailure
+	}
+	return subcommands.ExitSuccess
+}
+
+func (cmd *ExportCmd) export() (error) {
+	seq, err := openSequencerCore(*cmd.home, *cmd.dbPath, true)
+	if err != nil {
+		return err
+	}
+	defer seq.Close()
+
+	from, to := *cmd.from, *cmd.to
+	if to == 0 {
+		to = seq.LastBlock.Height
+	}
+	if from < 1 || to < from || seq.LastBlock.Height < to {
+		return fmt.Errorf("invalid range %d-%d, the database has blocks 1-%d", from, to, seq.LastBlock.Height)
+	}
+
+	var out io.Writer = os.Stdout
+	if *cmd.out != "" {
+		file, err := os.Create(*cmd.out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+
+	w, err := archive.NewWriter(out)
+	if err != nil {
+		return err
+	}
+
+	for start := from; start <= to; start += exportBatchSize {
+		end := start + exportBatchSize - 1
+		if to < end {
+			end = to
+		}
+
+		blocks, err := seq.GetBlocks(uint64(start), uint64(end))
+		if err != nil {
+			return err
+		}
+		for _, block := range blocks {
+			err = w.WriteBlock(block)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	err = w.Flush()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Exported blocks %d-%d.\n", from, to)
+	return nil
+}
+
+// Opens the sequencer database from the home directory (or `dbPath`), configured
+// with the genesis operator so blocks can be verified.
+func openSequencerCore(home string, dbPath string, readOnly bool) (*sequencer.SequencerCore, error) {
+	var (
+		cfg *config.Config
+		genesis *config.Genesis
+	)
+	if home != "" {
+		var err error
+		cfg, err = config.Load(home)
+		if err != nil {
+			return nil, err
+		}
+		genesis, err = config.LoadHomeGenesis(home)
+		if err != nil {
+			return nil, err
+		}
+		if dbPath == "" {
+			dbPath = cfg.Path(cfg.DBPath)
+		}
+	}
+	if dbPath == "" {
+		return nil, fmt.Errorf("no database, use -home or -dbpath")
+	}
+
+	dsn := getDatabasePathWithOptions(dbPath)
+	if readOnly {
+		_, err := os.Stat(dbPath)
+		if err != nil {
+			return nil, err
+		}
+		dsn += "&mode=ro"
+	}
+
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	seq := sequencer.NewSequencerCore(db, "")
+	if cfg != nil {
+		err = useStorageEngine(seq, cfg, readOnly)
+		if err != nil {
+			seq.Close()
+			return nil, err
+		}
+	}
+	if genesis != nil {
+		setGenesisOperators(seq, genesis)
+	}
+	return seq, nil
+}
@@ -0,0 +1,125 @@
+package commands
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/google/subcommands"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/archive"
+)
+
+type ImportCmd struct {
+	home *string
+	dbPath *string
+}
+
+func (*ImportCmd) Name() string     { return "import" }
+func (*ImportCmd) Synopsis() string { return "imports signed blocks from an archive file." }
+func (*ImportCmd) Usage() string {
+  return `import [-home <dir>|-dbpath <path>] <archive>:
+  Verifies and ingests blocks from an archive written by export, or a gzipped
+  archive (.gz) from a pruned node's cold storage. Reads from stdin if the
+  archive is "-".
+
+  Every block's signature is verified against the genesis operator, and its
+  prevhash against the previous block. Blocks already in the database are
+  skipped, and the rest must continue from its tip - for a fresh database, the
+  archive must start at height 1.
+`
+}
+
+func (cmd *ImportCmd) SetFlags(f *flag.FlagSet) {
+	cmd.home = f.String("home", "", "node home directory")
+	cmd.dbPath = f.String("dbpath", "", "path to the database, instead of the one in -home")
+}
+
+func (cmd *ImportCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	if f.NArg() != 1 {
+		f.Usage()
+		return subcommands.ExitUsageError
+	}
+
+	err := cmd.importArchive(f.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return subcommands.ExitFailure
+	}
+	return subcommands.ExitSuccess
+}
+
+func (cmd *ImportCmd) importArchive(path string) (error) {
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		in = file
+
+		if strings.HasSuffix(path, ".gz") {
+			gz, err := gzip.NewReader(file)
+			if err != nil {
+				return err
+			}
+			defer gz.Close()
+			in = gz
+		}
+	}
+
+	r, err := archive.NewReader(in)
+	if err != nil {
+		return err
+	}
+
+	seq, err := openSequencerCore(*cmd.home, *cmd.dbPath, false)
+	if err != nil {
+		return err
+	}
+	defer seq.Close()
+
+	tip := seq.LastBlock
+	from := tip.Height + 1
+	for {
+		block, err := r.ReadBlock()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		// Skip blocks we already have, checking the archive is for the same chain.
+		if block.Height < tip.Height {
+			continue
+		}
+		if block.Height == tip.Height {
+			if !bytes.Equal(block.SigHash(), tip.SigHash()) {
+				return fmt.Errorf("archive block at height %d doesn't match the database, it's for a different chain", block.Height)
+			}
+			continue
+		}
+
+		err = seq.ImportBlock(block)
+		if err != nil {
+			return err
+		}
+
+		if block.Height % 10000 == 0 {
+			fmt.Fprintf(os.Stderr, "Imported block %d.\n", block.Height)
+		}
+	}
+
+	if seq.LastBlock.Height < from {
+		fmt.Fprintln(os.Stderr, "No blocks imported.")
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, "Imported blocks %d-%d.\n", from, seq.LastBlock.Height)
+	return nil
+}
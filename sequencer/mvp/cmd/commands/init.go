@@ -4,66 +4,252 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	ethCrypto "github.com/ethereum/go-ethereum/crypto"
 	"github.com/google/subcommands"
 	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/config"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/keys"
 	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/utils"
 	"github.com/libp2p/go-libp2p"
-	libp2pCrypto "github.com/libp2p/go-libp2p-core/crypto"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
 type InitCmd struct {
+	home *string
+	mode *string
+	chainID *string
+	rpcport *string
+	grpcport *string
 	p2pport *string
+	peers *string
+	genesis *string
+	committee *string
+	threshold *int
+	committeeSigners *string
+	passwordFile *string
+	force *bool
 }
 
 func (*InitCmd) Name() string     { return "init" }
-func (*InitCmd) Synopsis() string { return "initializes configuration for a sequencer node." }
+func (*InitCmd) Synopsis() string { return "initializes a home directory for a sequencer node." }
 func (*InitCmd) Usage() string {
-  return `init:
-  Generates an initial configuration for setting up a sequencer primary/replica.
+  return `init [-home <dir>] [-mode primary|replica] [flags]:
+  Generates a home directory for a sequencer primary/replica, containing
+  a config file, keys and a genesis file.
+
+  A primary generates the genesis, which lists its operator pubkey and multiaddr.
+  Replicas join the network using the primary's genesis file (-genesis).
+
+  With -committee, the operator is a k-of-n committee instead of a single key.
+  Each member runs a signer (see signer), and the primary collects their
+  signatures for every block, from -committeesigners.
+
+  Keys are encrypted with a passphrase, read from -passwordfile or prompted for.
+  If -passwordfile is given, the config refers to it, so start can run unattended.
 `
 }
 
 func (cmd *InitCmd) SetFlags(f *flag.FlagSet) {
+	cmd.home = f.String("home", config.DefaultHome(), "node home directory to create")
+	cmd.mode = f.String("mode", "primary", "mode to operate in (primary, replica)")
+	cmd.chainID = f.String("chainid", "goliath-dev", "chain ID, for a new genesis")
+	cmd.rpcport = f.String("rpcport", "24444", "RPC port to listen on")
+	cmd.grpcport = f.String("grpcport", "24446", "gRPC port to listen on, or empty to disable gRPC")
 	cmd.p2pport = f.String("p2pport", "24445", "P2P port to listen on")
+	cmd.peers = f.String("peers", "", "extra bootstrap peers, comma-separated")
+	cmd.genesis = f.String("genesis", "", "path to an existing genesis file (required for replicas)")
+	cmd.committee = f.String("committee", "", "pubkeys of the operator committee members, comma-separated, instead of generating an operator key")
+	cmd.threshold = f.Int("threshold", 0, "number of committee members which must sign each block (default a simple majority)")
+	cmd.committeeSigners = f.String("committeesigners", "", "addresses of the committee members' remote signers, comma-separated")
+	cmd.passwordFile = f.String("passwordfile", "", "file containing the passphrase to encrypt keys with, instead of prompting")
+	cmd.force = f.Bool("force", false, "overwrite an existing home directory")
 }
 
 func (cmd *InitCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
-	fmt.Println("Initializing a sequencer primary...\n")
-	
+	err := cmd.init()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return subcommands.ExitFailure
+	}
+	return subcommands.ExitSuccess
+}
+
+func (cmd *InitCmd) init() (error) {
+	home := *cmd.home
+
+	if *cmd.mode != "primary" && *cmd.mode != "replica" {
+		return fmt.Errorf("unknown sequencer mode: %s", *cmd.mode)
+	}
+	if *cmd.mode == "replica" && *cmd.genesis == "" {
+		return fmt.Errorf("replicas need the primary's genesis file, pass it with -genesis")
+	}
+
+	_, err := os.Stat(filepath.Join(home, config.ConfigFileName))
+	if err == nil && !*cmd.force {
+		return fmt.Errorf("%s is already initialized, use -force to overwrite it", home)
+	}
+
+	fmt.Printf("Initializing a sequencer %s in %s...\n\n", *cmd.mode, home)
+
+	passphrase, err := keys.ReadPassphrase(*cmd.passwordFile, "Passphrase to encrypt keys: ", true)
+	if err != nil {
+		return err
+	}
+
+	cfg := config.DefaultHomeConfig(home)
+	cfg.Mode = *cmd.mode
+	cfg.RPC.Port = *cmd.rpcport
+	cfg.RPC.GRPCPort = *cmd.grpcport
+	cfg.P2P.Port = *cmd.p2pport
+	if *cmd.passwordFile != "" {
+		cfg.Keys.PasswordFile, err = filepath.Abs(*cmd.passwordFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, dir := range []string{home, filepath.Dir(filepath.Join(home, cfg.DBPath))} {
+		err = os.MkdirAll(dir, 0700)
+		if err != nil {
+			return err
+		}
+	}
+
 	// - p2p private key
-	// - sequencer private key for signing
-	// - output sequencer pubkey for following
-	// - output sequencer multiaddress
+	// - sequencer private key for signing (primary only)
+	// - genesis, with the sequencer pubkey for following and multiaddr for bootstrapping
+	multiaddr, err := cmd.initP2P(cfg.Path(cfg.Keys.P2P), passphrase)
+	if err != nil {
+		return err
+	}
+
+	var genesis *config.Genesis
+	if cfg.Mode == "primary" && *cmd.committee != "" {
+		cfg.Keys.Operator = ""
+		for _, addr := range strings.Split(*cmd.committeeSigners, ",") {
+			if addr != "" {
+				cfg.Keys.CommitteeSigners = append(cfg.Keys.CommitteeSigners, addr)
+			}
+		}
+
+		committee, err := cmd.initCommittee()
+		if err != nil {
+			return err
+		}
 
-	cmd.initCore()
-	cmd.initP2P()
+		genesis = &config.Genesis{
+			ChainID: *cmd.chainID,
+			OperatorCommittee: committee,
+			BootstrapPeers: []string{multiaddr},
+		}
+		err = genesis.Validate()
+		if err != nil {
+			return err
+		}
+	} else if cfg.Mode == "primary" {
+		pubkey, err := cmd.initCore(cfg.Path(cfg.Keys.Operator), passphrase)
+		if err != nil {
+			return err
+		}
 
-	return 0
+		genesis = &config.Genesis{
+			ChainID: *cmd.chainID,
+			OperatorPubkey: pubkey,
+			BootstrapPeers: []string{multiaddr},
+		}
+	} else {
+		cfg.Keys.Operator = ""
+
+		genesis, err = config.LoadGenesis(*cmd.genesis)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, peer := range strings.Split(*cmd.peers, ",") {
+		if peer != "" {
+			genesis.BootstrapPeers = append(genesis.BootstrapPeers, peer)
+		}
+	}
+
+	err = cfg.Write(home)
+	if err != nil {
+		return fmt.Errorf("couldn't write config: %s", err)
+	}
+	err = genesis.Write(home)
+	if err != nil {
+		return fmt.Errorf("couldn't write genesis: %s", err)
+	}
+
+	fmt.Printf("Chain ID: %s\n", genesis.ChainID)
+	if committee := genesis.Committee(); committee != nil {
+		fmt.Printf("Core operator: %s\n", committee)
+	} else {
+		fmt.Printf("Core operator public key: %s\n", hexutil.Encode(genesis.OperatorPubkey))
+	}
+	fmt.Printf("P2P multiaddr: %s\n", multiaddr)
+	fmt.Println()
+	fmt.Printf("Wrote %s and %s.\n", filepath.Join(home, config.ConfigFileName), filepath.Join(home, config.GenesisFileName))
+	fmt.Printf("Start the node with: sequencer start -home %s\n", home)
+
+	return nil
 }
 
-func (cmd *InitCmd) initCore() {
-	// Core.
+// Generates the operator key, returning its pubkey.
+func (cmd *InitCmd) initCore(keyPath string, passphrase string) ([]byte, error) {
 	privateKey, err := ethCrypto.GenerateKey()
 	if err != nil {
-		panic(fmt.Errorf("error generating private key:", err))
+		return nil, fmt.Errorf("error generating private key: %s", err)
 	}
 	signer := utils.NewEthereumECDSASignerFromKey(privateKey)
 
-	fmt.Printf("Core operator private key: %s\n", hexutil.Encode(ethCrypto.FromECDSA(privateKey)))
-	fmt.Printf("Core operator public key: %s\n", hexutil.Encode(ethCrypto.FromECDSAPub(signer.GetPubkey())))
+	keyJSON, err := keys.EncryptOperatorKey(privateKey, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't encrypt operator key: %s", err)
+	}
+	err = keys.WriteKeyFile(keyPath, keyJSON)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't write operator key: %s", err)
+	}
+
+	return ethCrypto.FromECDSAPub(signer.GetPubkey()), nil
+}
+
+// Parses the operator committee from the flags.
+func (cmd *InitCmd) initCommittee() (*config.GenesisCommittee, error) {
+	committee := &config.GenesisCommittee{
+		Threshold: *cmd.threshold,
+	}
+	for _, pubkey := range strings.Split(*cmd.committee, ",") {
+		buf, err := hexutil.Decode(strings.TrimSpace(pubkey))
+		if err != nil {
+			return nil, fmt.Errorf("invalid committee pubkey %s: %s", pubkey, err)
+		}
+		committee.Pubkeys = append(committee.Pubkeys, buf)
+	}
+	if committee.Threshold == 0 {
+		committee.Threshold = len(committee.Pubkeys) / 2 + 1
+	}
+	return committee, nil
 }
 
-func (cmd *InitCmd) initP2P() {
+// Generates the P2P key, returning the node's multiaddr.
+func (cmd *InitCmd) initP2P(keyPath string, passphrase string) (string, error) {
 	privateKey := sequencer.P2PGeneratePrivateKey()
 
-	rawPrivateKey, err := libp2pCrypto.MarshalPrivateKey(privateKey)
+	keyJSON, err := keys.EncryptP2PKey(privateKey, passphrase)
 	if err != nil {
-		panic(fmt.Errorf("error getting private key raw data: %s", err))
+		return "", fmt.Errorf("couldn't encrypt P2P key: %s", err)
+	}
+	err = keys.WriteKeyFile(keyPath, keyJSON)
+	if err != nil {
+		return "", fmt.Errorf("couldn't write P2P key: %s", err)
 	}
 
 	p2pAddr := fmt.Sprintf("/ip4/0.0.0.0/tcp/%s", *cmd.p2pport)
@@ -72,9 +258,9 @@ func (cmd *InitCmd) initP2P() {
 		libp2p.Identity(privateKey),
 	)
 	if err != nil {
-		panic(err)
+		return "", err
 	}
+	defer host.Close()
 
-	fmt.Printf("P2P multiaddr: %s/p2p/%s\n", host.Addrs()[0], host.ID())
-	fmt.Printf("P2P private key: %s\n", hexutil.Encode(rawPrivateKey))
-}
\ No newline at end of file
+	return fmt.Sprintf("%s/p2p/%s", host.Addrs()[0], host.ID()), nil
+}
@@ -0,0 +1,287 @@
+package commands
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethCrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/subcommands"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/config"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/keys"
+	libp2pCrypto "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+type KeysCmd struct {
+	home *string
+	keyType *string
+	passwordFile *string
+	force *bool
+}
+
+func (*KeysCmd) Name() string     { return "keys" }
+func (*KeysCmd) Synopsis() string { return "manages the node's encrypted key files." }
+func (*KeysCmd) Usage() string {
+  return `keys <new|import|export|list> [-home <dir>] [-type operator|p2p] [flags]:
+  Manages the encrypted key files in a node's home directory.
+
+  new             generates a new key.
+  import <file>   encrypts a plaintext hex key read from <file>, or - for stdin.
+  export          decrypts a key, printing it as plaintext hex.
+  list            lists the key files.
+
+  Plaintext keys use the same format as the OPERATOR_PRIVATE_KEY and PRIVATE_KEY
+  environment variables.
+`
+}
+
+func (cmd *KeysCmd) SetFlags(f *flag.FlagSet) {
+	cmd.home = f.String("home", config.DefaultHome(), "node home directory")
+	cmd.keyType = f.String("type", keys.OperatorKey, "key type (operator, p2p)")
+	cmd.passwordFile = f.String("passwordfile", "", "file containing the key passphrase, instead of prompting")
+	cmd.force = f.Bool("force", false, "overwrite an existing key file")
+}
+
+func (cmd *KeysCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	if f.NArg() == 0 {
+		f.Usage()
+		return subcommands.ExitUsageError
+	}
+
+	// Flags can also come after the action, eg. `keys list -home <dir>`.
+	action := f.Arg(0)
+	err := f.Parse(f.Args()[1:])
+	if err != nil {
+		return subcommands.ExitUsageError
+	}
+
+	switch action {
+	case "new":
+		err = cmd.new()
+	case "import":
+		if f.NArg() < 1 {
+			err = fmt.Errorf("import needs a file to read the key from, or - for stdin")
+			break
+		}
+		err = cmd.importKey(f.Arg(0))
+	case "export":
+		err = cmd.export()
+	case "list":
+		err = cmd.list()
+	default:
+		err = fmt.Errorf("unknown keys command: %s", action)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return subcommands.ExitFailure
+	}
+	return subcommands.ExitSuccess
+}
+
+// The path of the key file for -type, from the home directory's config.
+func (cmd *KeysCmd) keyPath() (string, error) {
+	cfg, err := config.Load(*cmd.home)
+	if err != nil {
+		cfg = config.DefaultHomeConfig(*cmd.home)
+	}
+	defaults := config.DefaultHomeConfig(*cmd.home)
+
+	switch *cmd.keyType {
+	case keys.OperatorKey:
+		if cfg.Keys.Operator == "" {
+			return defaults.Path(defaults.Keys.Operator), nil
+		}
+		return cfg.Path(cfg.Keys.Operator), nil
+	case keys.P2PKey:
+		if cfg.Keys.P2P == "" {
+			return defaults.Path(defaults.Keys.P2P), nil
+		}
+		return cfg.Path(cfg.Keys.P2P), nil
+	}
+	return "", fmt.Errorf("unknown key type: %s", *cmd.keyType)
+}
+
+func (cmd *KeysCmd) new() (error) {
+	switch *cmd.keyType {
+	case keys.OperatorKey:
+		key, err := ethCrypto.GenerateKey()
+		if err != nil {
+			return fmt.Errorf("error generating private key: %s", err)
+		}
+		return cmd.writeOperatorKey(key)
+	case keys.P2PKey:
+		return cmd.writeP2PKey(sequencer.P2PGeneratePrivateKey())
+	}
+	return fmt.Errorf("unknown key type: %s", *cmd.keyType)
+}
+
+func (cmd *KeysCmd) importKey(file string) (error) {
+	var data []byte
+	var err error
+	if file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return fmt.Errorf("couldn't read key: %s", err)
+	}
+	raw := strings.TrimSpace(string(data))
+
+	switch *cmd.keyType {
+	case keys.OperatorKey:
+		key, err := ethCrypto.HexToECDSA(strings.TrimPrefix(raw, "0x"))
+		if err != nil {
+			return fmt.Errorf("invalid operator key: %s", err)
+		}
+		return cmd.writeOperatorKey(key)
+	case keys.P2PKey:
+		keyBytes, err := hexutil.Decode(raw)
+		if err != nil {
+			return fmt.Errorf("invalid p2p key: %s", err)
+		}
+		key, err := libp2pCrypto.UnmarshalPrivateKey(keyBytes)
+		if err != nil {
+			return fmt.Errorf("invalid p2p key: %s", err)
+		}
+		return cmd.writeP2PKey(key)
+	}
+	return fmt.Errorf("unknown key type: %s", *cmd.keyType)
+}
+
+func (cmd *KeysCmd) export() (error) {
+	path, err := cmd.keyPath()
+	if err != nil {
+		return err
+	}
+
+	passphrase, err := keys.ReadPassphrase(*cmd.passwordFile, "Passphrase: ", false)
+	if err != nil {
+		return err
+	}
+
+	switch *cmd.keyType {
+	case keys.OperatorKey:
+		key, err := keys.LoadOperatorKey(path, passphrase)
+		if err != nil {
+			return err
+		}
+		fmt.Println(hex.EncodeToString(ethCrypto.FromECDSA(key)))
+	case keys.P2PKey:
+		key, err := keys.LoadP2PKey(path, passphrase)
+		if err != nil {
+			return err
+		}
+		raw, err := libp2pCrypto.MarshalPrivateKey(key)
+		if err != nil {
+			return err
+		}
+		fmt.Println(hexutil.Encode(raw))
+	}
+	return nil
+}
+
+func (cmd *KeysCmd) list() (error) {
+	dir := filepath.Join(*cmd.home, "keys")
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		fmt.Printf("No key files in %s\n", dir)
+		return nil
+	}
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		info, err := keys.Inspect(data)
+		if err != nil {
+			fmt.Printf("%-8s  %s  (%s)\n", "?", path, err)
+			continue
+		}
+		fmt.Printf("%-8s  %s  %s\n", info.Type, info.ID, path)
+	}
+	return nil
+}
+
+func (cmd *KeysCmd) checkOverwrite(path string) (error) {
+	_, err := os.Stat(path)
+	if err == nil && !*cmd.force {
+		return fmt.Errorf("%s already exists, use -force to overwrite it", path)
+	}
+	return nil
+}
+
+func (cmd *KeysCmd) writeOperatorKey(key *ecdsa.PrivateKey) (error) {
+	path, err := cmd.keyPath()
+	if err != nil {
+		return err
+	}
+	err = cmd.checkOverwrite(path)
+	if err != nil {
+		return err
+	}
+
+	passphrase, err := keys.ReadPassphrase(*cmd.passwordFile, "Passphrase to encrypt key: ", true)
+	if err != nil {
+		return err
+	}
+	keyJSON, err := keys.EncryptOperatorKey(key, passphrase)
+	if err != nil {
+		return err
+	}
+	err = keys.WriteKeyFile(path, keyJSON)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Core operator public key: %s\n", hexutil.Encode(ethCrypto.FromECDSAPub(&key.PublicKey)))
+	fmt.Printf("Core operator address: %s\n", ethCrypto.PubkeyToAddress(key.PublicKey))
+	fmt.Printf("Wrote %s\n", path)
+	return nil
+}
+
+func (cmd *KeysCmd) writeP2PKey(key libp2pCrypto.PrivKey) (error) {
+	path, err := cmd.keyPath()
+	if err != nil {
+		return err
+	}
+	err = cmd.checkOverwrite(path)
+	if err != nil {
+		return err
+	}
+
+	passphrase, err := keys.ReadPassphrase(*cmd.passwordFile, "Passphrase to encrypt key: ", true)
+	if err != nil {
+		return err
+	}
+	keyJSON, err := keys.EncryptP2PKey(key, passphrase)
+	if err != nil {
+		return err
+	}
+	err = keys.WriteKeyFile(path, keyJSON)
+	if err != nil {
+		return err
+	}
+
+	id, err := peer.IDFromPrivateKey(key)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("P2P peer ID: %s\n", id)
+	fmt.Printf("Wrote %s\n", path)
+	return nil
+}
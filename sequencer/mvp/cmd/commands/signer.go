@@ -0,0 +1,114 @@
+package commands
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/google/subcommands"
+	"github.com/ipfs/go-log/v2"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/config"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/keys"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/remotesigner"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/utils"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+type SignerCmd struct {
+	home *string
+	listen *string
+	passwordFile *string
+	slashingDB *string
+}
+
+func (*SignerCmd) Name() string     { return "signer" }
+func (*SignerCmd) Synopsis() string { return "runs a remote signer for the operator key." }
+func (*SignerCmd) Usage() string {
+  return `signer [-home <dir>] [-listen unix://<path>|<host:port>] [flags]:
+  Runs a signing daemon for the operator key, so it doesn't need to live on the
+  sequencing host. Start the primary with -remotesigner pointing at it.
+
+  The signer keeps a slashing protection database, and refuses to sign two
+  different blocks at the same height.
+`
+}
+
+func (cmd *SignerCmd) SetFlags(f *flag.FlagSet) {
+	cmd.home = f.String("home", config.DefaultHome(), "home directory containing the operator key")
+	cmd.listen = f.String("listen", "", "address to listen on, unix://<path> or <host:port> (default unix://<home>/signer.sock)")
+	cmd.passwordFile = f.String("passwordfile", "", "file containing the key passphrase, instead of prompting")
+	cmd.slashingDB = f.String("slashingdb", "", "path to the slashing protection database (default <home>/data/slashing.sqlite)")
+}
+
+func (cmd *SignerCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	err := cmd.run()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return subcommands.ExitFailure
+	}
+	return subcommands.ExitSuccess
+}
+
+func (cmd *SignerCmd) run() (error) {
+	home := *cmd.home
+
+	cfg, err := config.Load(home)
+	if err != nil {
+		cfg = config.DefaultHomeConfig(home)
+	}
+	if cfg.Keys.Operator == "" {
+		return fmt.Errorf("no operator key configured in %s", home)
+	}
+
+	err = sequencer.SetupLogging(cfg.Log.Format, cfg.Log.Level, cfg.Log.Levels)
+	if err != nil {
+		return err
+	}
+	err = log.SetLogLevel("signer", cfg.Log.Level)
+	if err != nil {
+		return err
+	}
+
+	passwordFile := *cmd.passwordFile
+	if passwordFile == "" {
+		passwordFile = cfg.Path(cfg.Keys.PasswordFile)
+	}
+	passphrase, err := keys.ReadPassphrase(passwordFile, "Passphrase to unlock operator key: ", false)
+	if err != nil {
+		return err
+	}
+	key, err := keys.LoadOperatorKey(cfg.Path(cfg.Keys.Operator), passphrase)
+	if err != nil {
+		return err
+	}
+
+	dbPath := *cmd.slashingDB
+	if dbPath == "" {
+		dbPath = filepath.Join(home, "data", "slashing.sqlite")
+	}
+	err = os.MkdirAll(filepath.Dir(dbPath), 0700)
+	if err != nil {
+		return err
+	}
+	db, err := sql.Open("sqlite3", getDatabasePathWithOptions(dbPath))
+	if err != nil {
+		return fmt.Errorf("couldn't open slashing protection database: %s", err)
+	}
+	protection, err := remotesigner.NewSlashingProtection(db)
+	if err != nil {
+		return err
+	}
+
+	listen := *cmd.listen
+	if listen == "" {
+		listen = "unix://" + filepath.Join(home, "signer.sock")
+	}
+
+	server := remotesigner.NewServer(utils.NewEthereumECDSASignerFromKey(key), protection)
+	return server.ListenAndServe(listen)
+}
@@ -1,83 +1,355 @@
 package commands
 
 import (
+	"bytes"
 	"context"
 	"crypto/ecdsa"
+	"encoding/hex"
 	"flag"
 	"fmt"
 	"log"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
+	"time"
 
 	"os"
 
 	"github.com/google/subcommands"
 	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/config"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/keys"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/remotesigner"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/segmentlog"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/utils"
+	libp2pCrypto "github.com/libp2p/go-libp2p-core/crypto"
 
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto"
 	_ "github.com/mattn/go-sqlite3"
 )
 
 type StartCmd struct {
+  home *string
   rpcport *string
+  grpcport *string
   p2pport *string
   mode_flag *string
   peers *string
+  dht *bool
+  mdns *bool
+  dissemination *string
+  relayFanout *int
+  minPeers *int
+  connectTimeout *int64
   dbPath *string
+  passwordFile *string
+  remoteSigner *string
+  committeeSigners *string
+  readyMaxLag *int64
+  adminPort *string
+  wsOrigins *string
+  checkpointInterval *int64
+  trustedCheckpoint *string
+  storageMode *string
+  keepBlocks *int64
+  coldStorage *string
+  storageEngine *string
+  logFormat *string
+  logLevel *string
+  logLevels *string
 }
 
 func (*StartCmd) Name() string     { return "start" }
 func (*StartCmd) Synopsis() string { return "starts the sequencer node." }
 func (*StartCmd) Usage() string {
-  return `start:
+  return `start [-home <dir>] [flags]:
   Starts a sequencer node.
+
+  Configuration is loaded from the home directory created by init. Flags override
+  values in the config file. The PRIVATE_KEY and OPERATOR_PRIVATE_KEY environment
+  variables override the keys in the home directory.
+
+  Key files are unlocked using -passwordfile, or by prompting for the passphrase.
+  With -remotesigner, blocks are signed by a signer daemon (see signer) instead
+  of the operator key file. If the genesis operator is a committee, the primary
+  collects signatures from the members' signers, listed in -committeesigners.
+
+  The primary signs a checkpoint every -checkpointinterval blocks. A new replica
+  started with -trustedcheckpoint <height>:<tip hash> syncs from that checkpoint
+  instead of from genesis, and backfills the older blocks in the background.
+
+  Nodes find each other through a DHT joined through the bootstrap peers, and
+  over mDNS on local networks. Disable them with -dht=false and -mdns=false.
+
+  Peers are scored on the blocks they deliver and how they answer sync requests,
+  and banned for an hour if they send invalid blocks. Known-good peers are saved
+  to peers_file in the home directory, and reconnected to on boot. With -adminport,
+  peers can be managed with admin_peers, admin_addPeer and admin_banPeer, which
+  are served on 127.0.0.1 only.
+
+  Blocks are gossipped by default. With -dissemination relaytree, they're streamed
+  down a tree rooted at the primary instead, where each node relays to at most
+  -relayfanout children. Every node in the network should use the same setting.
+
+  A replica fails to start if it isn't connected to -minpeers peers within
+  -connecttimeout seconds. Once running, it redials disconnected bootstrap peers
+  with backoff, and logs a heartbeat with its peer count.
+
+  With -storagemode pruned, the node only keeps the bodies of the last
+  -keepblocks blocks, and the headers of older blocks. Pruned blocks are
+  exported to gzipped archives in -coldstorage before they're deleted, if set.
+
+  With -storageengine segments, blocks are stored in an append-only log of
+  segment files instead of the database, which is faster to append to. The
+  engine can't be changed once the node has blocks.
 `
 }
 
 func (cmd *StartCmd) SetFlags(f *flag.FlagSet) {
 	// Arguments parsing.
+	cmd.home = f.String("home", "", "node home directory, created with init")
 	cmd.rpcport = f.String("rpcport", "24444", "RPC port to listen on")
+	cmd.grpcport = f.String("grpcport", "24446", "gRPC port to listen on, or empty to disable gRPC")
 	cmd.p2pport = f.String("p2pport", "24445", "P2P port to listen on")
 	cmd.mode_flag = f.String("mode", "primary", "mode to operate in")
 	cmd.peers = f.String("peers", "", "peers to join the pubsub network on")
+	cmd.dht = f.Bool("dht", true, "discover peers through the DHT")
+	cmd.mdns = f.Bool("mdns", true, "discover peers on the local network with mDNS")
+	cmd.dissemination = f.String("dissemination", "gossip", "how blocks are disseminated (gossip, relaytree)")
+	cmd.relayFanout = f.Int("relayfanout", 0, "max children in the relay tree, or 0 for the default (3 for the primary, 8 for replicas)")
+	cmd.minPeers = f.Int("minpeers", sequencer.DefaultMinPeers, "min number of peers a replica must connect to")
+	cmd.connectTimeout = f.Int64("connecttimeout", int64(sequencer.DefaultConnectTimeout / time.Second), "seconds a replica waits for -minpeers peers before failing to start, or 0 to wait forever")
 	cmd.dbPath = f.String("dbpath", DB_PATH, "path to the database")
+	cmd.passwordFile = f.String("passwordfile", "", "file containing the passphrase for the key files, instead of prompting")
+	cmd.remoteSigner = f.String("remotesigner", "", "address of a remote signer for the operator key, unix://<path> or http://<host:port>")
+	cmd.committeeSigners = f.String("committeesigners", "", "addresses of the operator committee's remote signers, comma-separated")
+	cmd.readyMaxLag = f.Int64("readymaxlag", sequencer.DefaultReadyMaxLag, "max number of blocks a replica can be behind the tip and still report ready on /readyz")
+	cmd.adminPort = f.String("adminport", "", "port to serve the admin_ RPC methods on, on 127.0.0.1 only, or empty to disable them")
+	cmd.wsOrigins = f.String("wsorigins", "", "web page origins allowed to connect over WebSocket, comma-separated, or * for any (default localhost only)")
+	cmd.checkpointInterval = f.Int64("checkpointinterval", sequencer.DefaultCheckpointInterval, "number of blocks between checkpoints signed by the primary, or 0 to disable")
+	cmd.trustedCheckpoint = f.String("trustedcheckpoint", "", "checkpoint to fast-sync a new replica from, as <height>:<tip hash>")
+	cmd.storageMode = f.String("storagemode", "archive", "storage mode (archive, pruned)")
+	cmd.keepBlocks = f.Int64("keepblocks", sequencer.DefaultKeepBlocks, "number of recent blocks a pruned node keeps the bodies of")
+	cmd.coldStorage = f.String("coldstorage", "", "directory to export pruned blocks to, or empty to delete them")
+	cmd.storageEngine = f.String("storageengine", "sqlite", "block storage engine (sqlite, segments)")
+	cmd.logFormat = f.String("logformat", "text", "log format (text, json)")
+	cmd.logLevel = f.String("loglevel", "info", "log level (debug, info, warn, error)")
+	cmd.logLevels = f.String("loglevels", "", "per-subsystem log levels, eg. p2p=debug,db=warn")
+}
+
+// Loads the config from the home directory, if any, and applies flags on top.
+func (cmd *StartCmd) loadConfig(f *flag.FlagSet) (*config.Config, *config.Genesis, error) {
+	cfg := config.DefaultConfig()
+	var genesis *config.Genesis
+
+	if *cmd.home != "" {
+		var err error
+		cfg, err = config.Load(*cmd.home)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		genesis, err = config.LoadHomeGenesis(*cmd.home)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Flags override the config file.
+	f.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "rpcport":
+			cfg.RPC.Port = *cmd.rpcport
+		case "grpcport":
+			cfg.RPC.GRPCPort = *cmd.grpcport
+		case "p2pport":
+			cfg.P2P.Port = *cmd.p2pport
+		case "mode":
+			cfg.Mode = *cmd.mode_flag
+		case "peers":
+			cfg.P2P.Peers = strings.Split(*cmd.peers, ",")
+		case "dht":
+			cfg.P2P.DHT = *cmd.dht
+		case "mdns":
+			cfg.P2P.MDNS = *cmd.mdns
+		case "dissemination":
+			cfg.P2P.Dissemination = *cmd.dissemination
+		case "relayfanout":
+			cfg.P2P.RelayFanout = *cmd.relayFanout
+		case "minpeers":
+			cfg.P2P.MinPeers = *cmd.minPeers
+		case "connecttimeout":
+			cfg.P2P.ConnectTimeout = *cmd.connectTimeout
+		case "dbpath":
+			// Relative to the working directory, not the home directory.
+			cfg.DBPath = *cmd.dbPath
+			if cfg.DBPath != "" {
+				cfg.DBPath, _ = filepath.Abs(cfg.DBPath)
+			}
+		case "passwordfile":
+			cfg.Keys.PasswordFile, _ = filepath.Abs(*cmd.passwordFile)
+		case "remotesigner":
+			cfg.Keys.RemoteSigner = *cmd.remoteSigner
+		case "committeesigners":
+			cfg.Keys.CommitteeSigners = strings.Split(*cmd.committeeSigners, ",")
+		case "readymaxlag":
+			cfg.RPC.ReadyMaxLag = *cmd.readyMaxLag
+		case "adminport":
+			cfg.RPC.AdminPort = *cmd.adminPort
+		case "wsorigins":
+			cfg.RPC.WSOrigins = strings.Split(*cmd.wsOrigins, ",")
+		case "checkpointinterval":
+			cfg.Sync.CheckpointInterval = *cmd.checkpointInterval
+		case "trustedcheckpoint":
+			cfg.Sync.TrustedCheckpoint = *cmd.trustedCheckpoint
+		case "storagemode":
+			cfg.Storage.Mode = *cmd.storageMode
+		case "keepblocks":
+			cfg.Storage.KeepBlocks = *cmd.keepBlocks
+		case "coldstorage":
+			// Relative to the working directory, not the home directory.
+			cfg.Storage.ColdStorage = *cmd.coldStorage
+			if cfg.Storage.ColdStorage != "" {
+				cfg.Storage.ColdStorage, _ = filepath.Abs(cfg.Storage.ColdStorage)
+			}
+		case "storageengine":
+			cfg.Storage.Engine = *cmd.storageEngine
+		case "logformat":
+			cfg.Log.Format = *cmd.logFormat
+		case "loglevel":
+			cfg.Log.Level = *cmd.logLevel
+		case "loglevels":
+			cfg.Log.Levels = *cmd.logLevels
+		}
+	})
+
+	return cfg, genesis, nil
 }
 
 func (cmd *StartCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
-	// privateKey := parsePrivateKey()
-	privateKey := os.Getenv("PRIVATE_KEY")
-	operatorPrivateKey := os.Getenv("OPERATOR_PRIVATE_KEY")
+	cfg, genesis, err := cmd.loadConfig(f)
+	if err != nil {
+		panic(err)
+	}
+
+	err = sequencer.SetupLogging(cfg.Log.Format, cfg.Log.Level, cfg.Log.Levels)
+	if err != nil {
+		panic(err)
+	}
 
 	var mode sequencer.SequencerMode
-	switch *cmd.mode_flag {
+	switch cfg.Mode {
 	case "primary":
 		mode = sequencer.PrimaryMode
 	case "replica":
 		mode = sequencer.ReplicaMode
 	default:
-		panic(fmt.Errorf("unknown sequencer mode: %s", *cmd.mode_flag))
+		panic(fmt.Errorf("unknown sequencer mode: %s", cfg.Mode))
+	}
+
+	// Keys from the environment take precedence over the home directory.
+	operatorPrivateKey := os.Getenv("OPERATOR_PRIVATE_KEY")
+	operatorSigner, err := remoteOperatorSigner(cfg, genesis, mode)
+	if err != nil {
+		panic(err)
+	}
+	if operatorSigner != nil {
+		// The operator key lives with the remote signer.
+		cfg.Keys.Operator = ""
+		operatorPrivateKey = ""
+	}
+	privateKey, operatorPrivateKey, err := unlockKeys(cfg, os.Getenv("PRIVATE_KEY"), operatorPrivateKey)
+	if err != nil {
+		panic(err)
+	}
+
+	var trustedCheckpoint *sequencer.TrustedCheckpoint
+	if cfg.Sync.TrustedCheckpoint != "" {
+		trustedCheckpoint, err = sequencer.ParseTrustedCheckpoint(cfg.Sync.TrustedCheckpoint)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	storageMode, err := sequencer.ParseStorageMode(cfg.Storage.Mode)
+	if err != nil {
+		panic(err)
 	}
 
 	if privateKey == "" && mode == sequencer.PrimaryMode {
-		panic("PRIVATE_KEY environment variable is empty!")
+		panic("no P2P key, set the PRIVATE_KEY environment variable or use -home")
 	}
-	if operatorPrivateKey == "" && mode == sequencer.PrimaryMode {
-		panic("OPERATOR_PRIVATE_KEY environment variable is empty!")
+	if operatorPrivateKey == "" && operatorSigner == nil && mode == sequencer.PrimaryMode {
+		panic("no operator key, set the OPERATOR_PRIVATE_KEY environment variable, use -home or -remotesigner")
+	}
+
+	// Bootstrap from the genesis peers, and any configured peers.
+	peers := cfg.P2P.Peers
+	if genesis != nil {
+		peers = append(genesis.BootstrapPeers, peers...)
 	}
 
 	fmt.Println("Goliath Sequencer")
-	fmt.Println("Mode:", *cmd.mode_flag)
+	fmt.Println("Mode:", cfg.Mode)
+	if genesis != nil {
+		fmt.Println("Chain:", genesis.ChainID)
+	}
 
 	// Sequencer node.
 	node := sequencer.NewSequencerNode(
-		getDatabasePathWithOptions(*cmd.dbPath),
-		*cmd.rpcport,
-		*cmd.p2pport,
+		getDatabasePathWithOptions(cfg.Path(cfg.DBPath)),
+		cfg.RPC.Port,
+		cfg.RPC.GRPCPort,
+		cfg.P2P.Port,
 		mode,
 		privateKey,
-		*cmd.peers,
+		strings.Join(peers, ","),
 		operatorPrivateKey,
+		cfg.RPC.ReadyMaxLag,
 	)
+	err = useStorageEngine(node.Seq, cfg, false)
+	if err != nil {
+		panic(err)
+	}
+	if genesis != nil {
+		setGenesisOperators(node.Seq, genesis)
+		node.Handshaker.SetGenesis(genesis.ChainID, genesis.Hash())
+	}
+	if operatorSigner != nil {
+		node.Seq.SetSigner(operatorSigner)
+	}
+	err = node.P2P.SetDiscovery(cfg.P2P.DHT, cfg.P2P.MDNS)
+	if err != nil {
+		panic(err)
+	}
+	if cfg.P2P.PeersFile != "" {
+		err = node.Peers.SetPeersFile(cfg.Path(cfg.P2P.PeersFile))
+		if err != nil {
+			panic(err)
+		}
+	}
+	if cfg.RPC.AdminPort != "" {
+		node.RPC.ServeAdmin(cfg.RPC.AdminPort, node.Peers)
+	}
+	node.RPC.SetWSOrigins(cfg.RPC.WSOrigins)
+	node.Watchdog.SetMinPeers(cfg.P2P.MinPeers, time.Duration(cfg.P2P.ConnectTimeout) * time.Second)
+	switch cfg.P2P.Dissemination {
+	case "gossip":
+	case "relaytree":
+		node.UseRelayTree(cfg.P2P.RelayFanout)
+	default:
+		panic(fmt.Errorf("unknown dissemination: %s", cfg.P2P.Dissemination))
+	}
+	node.Seq.SetCheckpointInterval(cfg.Sync.CheckpointInterval)
+	if trustedCheckpoint != nil {
+		node.Syncer.SetTrustedCheckpoint(trustedCheckpoint)
+	}
+	err = node.Seq.SetStorageMode(storageMode, cfg.Storage.KeepBlocks, cfg.Path(cfg.Storage.ColdStorage))
+	if err != nil {
+		panic(err)
+	}
 
 	// Handle shutdowns.
 	ch := make(chan os.Signal, 1)
@@ -93,11 +365,117 @@ func (cmd *StartCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{
 	})()
 
 	// Start the node.
-	node.Start()
-	
-	return 0
+	err = node.Start()
+	fmt.Fprintln(os.Stderr, "error:", err)
+	node.Close()
+	return subcommands.ExitFailure
+}
+
+
+// Switches the sequencer to the configured block storage engine. Must be called
+// before the genesis operators are configured.
+func useStorageEngine(seq *sequencer.SequencerCore, cfg *config.Config, readOnly bool) (error) {
+	engine, err := sequencer.ParseStorageEngine(cfg.Storage.Engine)
+	if err != nil || engine != sequencer.SegmentsEngine {
+		return err
+	}
+
+	opts := segmentlog.DefaultOptions()
+	opts.SegmentSize = cfg.Storage.SegmentSize
+	opts.SyncInterval = time.Duration(cfg.Storage.SyncIntervalMs) * time.Millisecond
+	opts.ReadOnly = readOnly
+	return seq.UseSegmentStore(cfg.Path(cfg.Storage.SegmentsDir), opts)
+}
+
+// Configures the genesis operator and any handovers, which blocks are verified against.
+func setGenesisOperators(seq *sequencer.SequencerCore, genesis *config.Genesis) {
+	if genesis.OperatorCommittee != nil {
+		seq.SetGenesisCommittee(genesis.Committee())
+	} else {
+		seq.SetGenesisOperator(genesis.OperatorPubkey)
+	}
+
+	for _, handover := range genesis.OperatorHandovers {
+		seq.AddOperatorHandover(handover.AfterBlock, handover.OperatorPubkey, handover.Committee())
+	}
 }
 
+// Connects to the remote signer for the operator key, or the committee's signers if the
+// genesis operator is a committee. Returns nil if the operator key is held locally.
+func remoteOperatorSigner(cfg *config.Config, genesis *config.Genesis, mode sequencer.SequencerMode) (utils.Signer, error) {
+	if genesis != nil && genesis.OperatorCommittee != nil {
+		if mode != sequencer.PrimaryMode {
+			return nil, nil
+		}
+		if len(cfg.Keys.CommitteeSigners) == 0 {
+			return nil, fmt.Errorf("the operator is a committee, configure its signers with -committeesigners")
+		}
+
+		members := []*remotesigner.RemoteSigner{}
+		for _, addr := range cfg.Keys.CommitteeSigners {
+			member, err := remotesigner.NewRemoteSigner(addr)
+			if err != nil {
+				return nil, fmt.Errorf("committee signer %s: %s", addr, err)
+			}
+			members = append(members, member)
+		}
+		signer, err := remotesigner.NewCommitteeSigner(genesis.Committee(), members)
+		if err != nil {
+			return nil, err
+		}
+		return signer, nil
+	}
+
+	if cfg.Keys.RemoteSigner == "" {
+		return nil, nil
+	}
+
+	signer, err := remotesigner.NewRemoteSigner(cfg.Keys.RemoteSigner)
+	if err != nil {
+		return nil, err
+	}
+	if genesis != nil && !bytes.Equal(crypto.FromECDSAPub(signer.GetPubkey()), genesis.OperatorPubkey) {
+		return nil, fmt.Errorf("remote signer key %s is not the genesis operator %s", signer, genesis.OperatorPubkey)
+	}
+	return signer, nil
+}
+
+// Decrypts the key files in the home directory, for any keys not already given.
+// Returns the keys hex-encoded, in the same format as the environment variables.
+func unlockKeys(cfg *config.Config, p2pKey string, operatorKey string) (string, string, error) {
+	unlockP2P := p2pKey == "" && cfg.Keys.P2P != ""
+	unlockOperator := operatorKey == "" && cfg.Keys.Operator != ""
+	if !unlockP2P && !unlockOperator {
+		return p2pKey, operatorKey, nil
+	}
+
+	passphrase, err := keys.ReadPassphrase(cfg.Path(cfg.Keys.PasswordFile), "Passphrase to unlock keys: ", false)
+	if err != nil {
+		return "", "", err
+	}
+
+	if unlockP2P {
+		key, err := keys.LoadP2PKey(cfg.Path(cfg.Keys.P2P), passphrase)
+		if err != nil {
+			return "", "", err
+		}
+		raw, err := libp2pCrypto.MarshalPrivateKey(key)
+		if err != nil {
+			return "", "", err
+		}
+		p2pKey = hexutil.Encode(raw)
+	}
+
+	if unlockOperator {
+		key, err := keys.LoadOperatorKey(cfg.Path(cfg.Keys.Operator), passphrase)
+		if err != nil {
+			return "", "", err
+		}
+		operatorKey = hex.EncodeToString(crypto.FromECDSA(key))
+	}
+
+	return p2pKey, operatorKey, nil
+}
 
 const DB_PATH = "db.sqlite"
 
@@ -15,6 +15,11 @@ func main() {
   subcommands.Register(subcommands.CommandsCommand(), "")
   subcommands.Register(&commands.StartCmd{}, "")
   subcommands.Register(&commands.InitCmd{}, "")
+  subcommands.Register(&commands.KeysCmd{}, "")
+  subcommands.Register(&commands.SignerCmd{}, "")
+  subcommands.Register(&commands.ExportCmd{}, "")
+  subcommands.Register(&commands.ImportCmd{}, "")
+  subcommands.Register(&commands.VerifyCmd{}, "")
 
   flag.Parse()
   ctx := context.Background()
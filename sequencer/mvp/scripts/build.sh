@@ -4,7 +4,7 @@ set -ex
 echo Generating protobufs...
 
 # docker run -v $PWD:/defs namely/protoc-all -f defs/*.proto -l go
-protoc --go_out=. --go_opt=paths=source_relative sequencer/messages/defs.proto
+protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative sequencer/messages/defs.proto
 
 cd cmd/sequencer
 go build
@@ -0,0 +1,111 @@
+package accumulator
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// An append-only accumulator over block hashes - a Merkle Mountain Range.
+//
+// It's a list of perfect Merkle trees ("peaks"), one for each set bit in the number
+// of leaves, like a binary counter. Appending a leaf merges equal-sized trees. The
+// root hashes the peaks and the size together, committing to every leaf in order.
+//
+// Only the peaks are kept, so it's O(log n) to store and extend.
+
+var (
+	leafPrefix = []byte{0}
+	nodePrefix = []byte{1}
+)
+
+type Accumulator struct {
+	size int64
+	// peaks[i] is the root of a tree of 2^i leaves, or nil.
+	peaks [][]byte
+}
+
+func New() (*Accumulator) {
+	return &Accumulator{}
+}
+
+// Restores an accumulator of `size` leaves from its peaks, largest first (as returned by Peaks).
+func FromPeaks(size int64, peaks [][]byte) (*Accumulator, error) {
+	if size < 0 || bits.OnesCount64(uint64(size)) != len(peaks) {
+		return nil, fmt.Errorf("accumulator of size %d can't have %d peaks", size, len(peaks))
+	}
+
+	a := &Accumulator{
+		size: size,
+		peaks: make([][]byte, bits.Len64(uint64(size))),
+	}
+	j := 0
+	for i := len(a.peaks) - 1; 0 <= i; i-- {
+		if size & (1 << i) != 0 {
+			if len(peaks[j]) != 32 {
+				return nil, fmt.Errorf("invalid accumulator peak")
+			}
+			a.peaks[i] = peaks[j]
+			j++
+		}
+	}
+	return a, nil
+}
+
+// Appends a block hash.
+func (a *Accumulator) Append(leaf []byte) {
+	node := crypto.Keccak256(leafPrefix, leaf)
+	for i := 0; ; i++ {
+		if i == len(a.peaks) {
+			a.peaks = append(a.peaks, nil)
+		}
+		if a.peaks[i] == nil {
+			a.peaks[i] = node
+			break
+		}
+		node = crypto.Keccak256(nodePrefix, a.peaks[i], node)
+		a.peaks[i] = nil
+	}
+	a.size++
+}
+
+// The number of leaves.
+func (a *Accumulator) Size() (int64) {
+	return a.size
+}
+
+// The peaks, largest first.
+func (a *Accumulator) Peaks() ([][]byte) {
+	peaks := [][]byte{}
+	for i := len(a.peaks) - 1; 0 <= i; i-- {
+		if a.peaks[i] != nil {
+			peaks = append(peaks, a.peaks[i])
+		}
+	}
+	return peaks
+}
+
+func (a *Accumulator) Root() ([]byte) {
+	var size [8]byte
+	binary.BigEndian.PutUint64(size[:], uint64(a.size))
+
+	// Bag the peaks, from smallest to largest.
+	root := []byte{}
+	for _, peak := range a.peaks {
+		if peak != nil {
+			root = crypto.Keccak256(nodePrefix, peak, root)
+		}
+	}
+	return crypto.Keccak256(size[:], root)
+}
+
+func (a *Accumulator) Copy() (*Accumulator) {
+	peaks := make([][]byte, len(a.peaks))
+	copy(peaks, a.peaks)
+	return &Accumulator{
+		size: a.size,
+		peaks: peaks,
+	}
+}
@@ -0,0 +1,50 @@
+package accumulator
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccumulator(t *testing.T) {
+	a := New()
+	roots := [][]byte{a.Root()}
+	for i := 0; i < 100; i++ {
+		a.Append(crypto.Keccak256([]byte{byte(i)}))
+		roots = append(roots, a.Root())
+
+		// Restoring from the peaks gives the same accumulator.
+		restored, err := FromPeaks(a.Size(), a.Peaks())
+		assert.Nil(t, err)
+		assert.Equal(t, a.Root(), restored.Root())
+	}
+	assert.Equal(t, int64(100), a.Size())
+	assert.Equal(t, 3, len(a.Peaks())) // 100 = 0b1100100
+
+	// Every root is different.
+	seen := map[string]bool{}
+	for _, root := range roots {
+		assert.False(t, seen[string(root)])
+		seen[string(root)] = true
+	}
+
+	// Extending a restored accumulator matches extending the original.
+	restored, _ := FromPeaks(a.Size(), a.Peaks())
+	copied := a.Copy()
+	a.Append([]byte("next"))
+	restored.Append([]byte("next"))
+	assert.Equal(t, a.Root(), restored.Root())
+	assert.NotEqual(t, a.Root(), copied.Root())
+
+	// The order of leaves matters.
+	b, c := New(), New()
+	b.Append([]byte("1"))
+	b.Append([]byte("2"))
+	c.Append([]byte("2"))
+	c.Append([]byte("1"))
+	assert.NotEqual(t, b.Root(), c.Root())
+
+	_, err := FromPeaks(3, [][]byte{make([]byte, 32)})
+	assert.NotNil(t, err)
+}
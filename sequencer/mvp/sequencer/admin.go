@@ -0,0 +1,54 @@
+package sequencer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+const adminAddPeerTimeout = 10 * time.Second
+
+// The admin_ RPC methods, for managing the node's peers.
+type AdminService struct {
+	peers *PeerManager
+}
+
+// Lists connected and scored peers.
+func (s *AdminService) Peers() ([]PeerInfo) {
+	rpcLog.Debug("rpc: admin peers")
+	return s.peers.Peers()
+}
+
+// Connects to the peer at `addr`, a multiaddr ending in /p2p/<peer id>. Lifts any ban on it.
+func (s *AdminService) AddPeer(ctx context.Context, addr string) (bool, error) {
+	rpcLog.Infow("rpc: admin add peer", "addr", addr)
+	ctx, cancel := context.WithTimeout(ctx, adminAddPeerTimeout)
+	defer cancel()
+
+	err := s.peers.AddPeer(ctx, addr)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Disconnects the peer and bans it for `seconds`, or an hour if it's 0.
+func (s *AdminService) BanPeer(id string, seconds int64) (bool, error) {
+	rpcLog.Infow("rpc: admin ban peer", "peer", id, "seconds", seconds)
+	pid, err := peer.Decode(id)
+	if err != nil {
+		return false, fmt.Errorf("invalid peer ID %q: %s", id, err)
+	}
+	if seconds < 0 {
+		return false, fmt.Errorf("invalid ban duration %d", seconds)
+	}
+
+	d := peerBanDuration
+	if 0 < seconds {
+		d = time.Duration(seconds) * time.Second
+	}
+	s.peers.Ban(pid, d, fmt.Errorf("banned by admin"))
+	return true, nil
+}
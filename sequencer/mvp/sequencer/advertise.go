@@ -0,0 +1,186 @@
+package sequencer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/messages"
+	"github.com/libp2p/go-libp2p-core/event"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/peerstore"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	maddr "github.com/multiformats/go-multiaddr"
+)
+
+// Primary advertisements.
+//
+// The primary gossips its addresses, signed by the operator, every advertiseInterval
+// and whenever they change. Replicas replace the primary's addresses in their
+// peerstore, and reconnect to it if they've lost their connection. So if the primary
+// moves, replicas follow it without being reconfigured.
+
+const (
+	advertiseInterval = 30 * time.Second
+	// Older advertisements are ignored, so they can't be replayed after the primary moves.
+	maxAdvertisementAge = 10 * time.Minute
+)
+
+// Signs an advertisement with the operator key.
+func (s *SequencerCore) SignAdvertisement(ad *messages.SequencerPrimaryAdvertisement) (*messages.SequencerPrimaryAdvertisement, error) {
+	if s.signer == nil {
+		return nil, fmt.Errorf("no operator key")
+	}
+	return ad.SignWith(s.signer)
+}
+
+// Verifies an advertisement was signed by the operator.
+// Safe to call from any goroutine.
+func (s *SequencerCore) VerifyAdvertisement(ad *messages.SequencerPrimaryAdvertisement) (error) {
+	return s.verifyGossipDigest(ad.SigHash(), ad.Sig, ad.Sigs)
+}
+
+// Gossips a signed advertisement of our addresses periodically, and whenever they
+// change. Used by the primary.
+func (n *P2PNode) AdvertisePrimary(sign func(ad *messages.SequencerPrimaryAdvertisement) (*messages.SequencerPrimaryAdvertisement, error)) {
+	addrsUpdated, err := n.Host.EventBus().Subscribe(new(event.EvtLocalAddressesUpdated))
+	if err != nil {
+		p2pLog.Errorw("error subscribing to address updates", "err", err)
+		return
+	}
+	defer addrsUpdated.Close()
+
+	ticker := time.NewTicker(advertiseInterval)
+	defer ticker.Stop()
+
+	for {
+		err := n.advertise(sign)
+		if err != nil {
+			p2pLog.Warnw("error advertising primary", "err", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-addrsUpdated.Out():
+		case <-n.ctx.Done():
+			return
+		}
+	}
+}
+
+func (n *P2PNode) advertise(sign func(ad *messages.SequencerPrimaryAdvertisement) (*messages.SequencerPrimaryAdvertisement, error)) (error) {
+	ad := &messages.SequencerPrimaryAdvertisement{
+		PeerId: []byte(n.Host.ID()),
+		Timestamp: time.Now().UnixMilli(),
+	}
+	for _, addr := range n.Host.Addrs() {
+		ad.Multiaddress = append(ad.Multiaddress, addr.Bytes())
+	}
+
+	ad, err := sign(ad)
+	if err != nil {
+		return err
+	}
+	buf, err := proto.Marshal(ad)
+	if err != nil {
+		return err
+	}
+
+	p2pLog.Debugw("pubsub - advertise primary", "addrs", n.Host.Addrs())
+	return n.primaryAds.Publish(n.ctx, buf)
+}
+
+// Validates advertisements with `verify` before they're delivered or relayed.
+// Must be called before the node starts.
+func (n *P2PNode) ValidateAdvertisements(verify func(ad *messages.SequencerPrimaryAdvertisement) (error)) (error) {
+	validator := func(ctx context.Context, from peer.ID, msg *pubsub.Message) (pubsub.ValidationResult) {
+		ad := &messages.SequencerPrimaryAdvertisement{}
+		err := proto.Unmarshal(msg.Data, ad)
+		if err != nil {
+			p2pLog.Debugw("rejected advertisement", "peer", from, "err", err)
+			return pubsub.ValidationReject
+		}
+
+		// Only the primary can advertise itself.
+		if peer.ID(ad.PeerId) != msg.GetFrom() {
+			p2pLog.Debugw("rejected advertisement", "peer", from, "err", "advertisement wasn't published by the advertised peer")
+			return pubsub.ValidationReject
+		}
+
+		age := time.Since(time.UnixMilli(ad.Timestamp))
+		if maxAdvertisementAge < age || age < -maxAdvertisementAge {
+			return pubsub.ValidationIgnore
+		}
+
+		err = verify(ad)
+		if err != nil {
+			p2pLog.Debugw("rejected advertisement", "peer", from, "err", err)
+			return pubsub.ValidationReject
+		}
+
+		msg.ValidatorData = ad
+		return pubsub.ValidationAccept
+	}
+
+	return n.ps.RegisterTopicValidator(topicName(PUBSUB_TOPIC_PRIMARY), validator)
+}
+
+// Follows the primary's advertisements, updating its addresses and reconnecting to
+// it if we're disconnected. Used by replicas.
+func (n *P2PNode) FollowPrimary() {
+	sub, err := n.primaryAds.Subscribe()
+	if err != nil {
+		p2pLog.Errorw("error subscribing to primary advertisements", "err", err)
+		return
+	}
+
+	latest := int64(0)
+	for {
+		msg, err := sub.Next(n.ctx)
+		if err != nil {
+			return
+		}
+
+		ad, ok := msg.ValidatorData.(*messages.SequencerPrimaryAdvertisement)
+		if !ok || ad.Timestamp <= latest {
+			continue
+		}
+		latest = ad.Timestamp
+		n.handleAdvertisement(ad)
+	}
+}
+
+func (n *P2PNode) handleAdvertisement(ad *messages.SequencerPrimaryAdvertisement) {
+	primary := peer.AddrInfo{
+		ID: peer.ID(ad.PeerId),
+	}
+	for _, buf := range ad.Multiaddress {
+		addr, err := maddr.NewMultiaddrBytes(buf)
+		if err != nil {
+			p2pLog.Debugw("invalid address in advertisement", "err", err)
+			continue
+		}
+		primary.Addrs = append(primary.Addrs, addr)
+	}
+	p2pLog.Debugw("pubsub - primary advertisement", "peer", primary.ID.Pretty(), "addrs", primary.Addrs)
+
+	// Forget the old addresses, in case the primary moved.
+	ps := n.Host.Peerstore()
+	ps.ClearAddrs(primary.ID)
+	ps.AddAddrs(primary.ID, primary.Addrs, peerstore.AddressTTL)
+	n.Host.ConnManager().Protect(primary.ID, "primary")
+
+	if n.Host.Network().Connectedness(primary.ID) == network.Connected {
+		return
+	}
+
+	p2pLog.Infow("reconnecting to primary", "peer", primary.ID.Pretty(), "addrs", primary.Addrs)
+	ctx, cancel := context.WithTimeout(n.ctx, 10 * time.Second)
+	defer cancel()
+	err := n.Host.Connect(ctx, primary)
+	if err != nil {
+		p2pLog.Warnw("error connecting to primary", "peer", primary.ID.Pretty(), "err", err)
+	}
+}
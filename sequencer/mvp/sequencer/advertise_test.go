@@ -0,0 +1,122 @@
+package sequencer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/messages"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peerstore"
+	ma "github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/assert"
+)
+
+func testAdvertisement(node *P2PNode, timestamp time.Time) (*messages.SequencerPrimaryAdvertisement) {
+	ad := &messages.SequencerPrimaryAdvertisement{
+		PeerId: []byte(node.Host.ID()),
+		Timestamp: timestamp.UnixMilli(),
+	}
+	for _, addr := range node.Host.Addrs() {
+		ad.Multiaddress = append(ad.Multiaddress, addr.Bytes())
+	}
+	return ad
+}
+
+func publishAdvertisement(t *testing.T, node *P2PNode, ad *messages.SequencerPrimaryAdvertisement) {
+	buf, err := proto.Marshal(ad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, node.primaryAds.Publish(context.Background(), buf))
+}
+
+func TestVerifyAdvertisement(t *testing.T) {
+	primary, err := getMockSequencer(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replica := newTestReplica(t)
+	node := newTestP2PNode(t)
+
+	ad, err := primary.SignAdvertisement(testAdvertisement(node, time.Now()))
+	assert.NoError(t, err)
+	assert.NoError(t, replica.VerifyAdvertisement(ad))
+
+	// Replicas can't advertise.
+	_, err = replica.SignAdvertisement(testAdvertisement(node, time.Now()))
+	assert.Error(t, err)
+
+	moved := proto.Clone(ad).(*messages.SequencerPrimaryAdvertisement)
+	moved.Multiaddress = moved.Multiaddress[:0]
+	assert.Error(t, replica.VerifyAdvertisement(moved))
+	forged, err := testAdvertisement(node, time.Now()).SignWith(randomTestSigner(t))
+	assert.NoError(t, err)
+	assert.Error(t, replica.VerifyAdvertisement(forged))
+}
+
+func TestFollowPrimary(t *testing.T) {
+	primary, err := getMockSequencer(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replica := newTestReplica(t)
+	primaryNode, replicaNode, otherNode := newTestP2PNode(t), newTestP2PNode(t), newTestP2PNode(t)
+	assert.NoError(t, replicaNode.ValidateAdvertisements(replica.VerifyAdvertisement))
+
+	sub, err := replicaNode.primaryAds.Subscribe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go replicaNode.FollowPrimary()
+	connectTestNodes(t, replicaNode, primaryNode)
+	connectTestNodes(t, otherNode, replicaNode)
+	for _, node := range []*P2PNode{primaryNode, otherNode} {
+		node := node
+		assert.Eventually(t, func() (bool) {
+			return 0 < len(node.primaryAds.ListPeers())
+		}, 5 * time.Second, 10 * time.Millisecond)
+	}
+
+	// A stale address, which the advertisement replaces.
+	stale, _ := ma.NewMultiaddr("/ip4/127.0.0.1/tcp/1")
+	replicaNode.Host.Peerstore().AddAddr(primaryNode.Host.ID(), stale, peerstore.PermanentAddrTTL)
+
+	// Advertisements which are old, or for another peer, aren't delivered.
+	old, err := primary.SignAdvertisement(testAdvertisement(primaryNode, time.Now().Add(-2 * maxAdvertisementAge)))
+	assert.NoError(t, err)
+	publishAdvertisement(t, primaryNode, old)
+	other, err := primary.SignAdvertisement(testAdvertisement(primaryNode, time.Now()))
+	assert.NoError(t, err)
+	publishAdvertisement(t, otherNode, other)
+
+	ad, err := primary.SignAdvertisement(testAdvertisement(primaryNode, time.Now().Add(time.Millisecond)))
+	assert.NoError(t, err)
+	publishAdvertisement(t, primaryNode, ad)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5 * time.Second)
+	defer cancel()
+	msg, err := sub.Next(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, primaryNode.Host.ID(), msg.GetFrom())
+	assert.Equal(t, ad.Timestamp, msg.ValidatorData.(*messages.SequencerPrimaryAdvertisement).Timestamp)
+
+	assert.Eventually(t, func() (bool) {
+		addrs := replicaNode.Host.Peerstore().Addrs(primaryNode.Host.ID())
+		for _, addr := range addrs {
+			if addr.Equal(stale) {
+				return false
+			}
+		}
+		return len(addrs) == len(ad.Multiaddress)
+	}, 5 * time.Second, 10 * time.Millisecond)
+	assert.True(t, replicaNode.Host.ConnManager().IsProtected(primaryNode.Host.ID(), "primary"))
+
+	// Replicas reconnect to the primary when it advertises.
+	replicaNode.Host.Network().ClosePeer(primaryNode.Host.ID())
+	replicaNode.handleAdvertisement(ad)
+	assert.Equal(t, network.Connected, replicaNode.Host.Network().Connectedness(primaryNode.Host.ID()))
+}
@@ -0,0 +1,99 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/messages"
+	"google.golang.org/protobuf/proto"
+)
+
+// Block archives are a portable stream of signed blocks, for backups and bootstrapping replicas.
+//
+// The format is a magic header, followed by each block as a uvarint length and the
+// protobuf-encoded Block. Blocks are in height order.
+
+var magic = []byte("goliath-blocks/1\n")
+
+// Blocks larger than this are assumed to be a corrupt archive.
+const maxBlockSize = 64 << 20
+
+type Writer struct {
+	w *bufio.Writer
+}
+
+// Writes the archive header to `w`, returning a Writer for the blocks.
+func NewWriter(w io.Writer) (*Writer, error) {
+	bw := bufio.NewWriter(w)
+	_, err := bw.Write(magic)
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{w: bw}, nil
+}
+
+func (a *Writer) WriteBlock(block *messages.Block) (error) {
+	buf, err := proto.Marshal(block)
+	if err != nil {
+		return err
+	}
+
+	var lenBuf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(lenBuf[:], uint64(len(buf)))
+	_, err = a.w.Write(lenBuf[:n])
+	if err != nil {
+		return err
+	}
+	_, err = a.w.Write(buf)
+	return err
+}
+
+// Flushes buffered blocks to the underlying writer.
+func (a *Writer) Flush() (error) {
+	return a.w.Flush()
+}
+
+type Reader struct {
+	r *bufio.Reader
+}
+
+// Reads the archive header from `r`, returning a Reader for the blocks.
+func NewReader(r io.Reader) (*Reader, error) {
+	br := bufio.NewReader(r)
+	header := make([]byte, len(magic))
+	_, err := io.ReadFull(br, header)
+	if err != nil || !bytes.Equal(header, magic) {
+		return nil, fmt.Errorf("not a block archive")
+	}
+	return &Reader{r: br}, nil
+}
+
+// Reads the next block. Returns io.EOF at the end of the archive.
+func (a *Reader) ReadBlock() (*messages.Block, error) {
+	size, err := binary.ReadUvarint(a.r)
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading archive: %s", err)
+	}
+	if maxBlockSize < size {
+		return nil, fmt.Errorf("error reading archive: block of %d bytes is too large", size)
+	}
+
+	buf := make([]byte, size)
+	_, err = io.ReadFull(a.r, buf)
+	if err != nil {
+		return nil, fmt.Errorf("error reading archive: truncated block: %s", err)
+	}
+
+	block := &messages.Block{}
+	err = proto.Unmarshal(buf, block)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding archive block: %s", err)
+	}
+	return block, nil
+}
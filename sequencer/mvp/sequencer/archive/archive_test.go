@@ -0,0 +1,54 @@
+package archive
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/messages"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestArchiveRoundTrip(t *testing.T) {
+	blocks := []*messages.Block{}
+	for i := int64(1); i <= 3; i++ {
+		blocks = append(blocks, &messages.Block{
+			Height: i,
+			PrevBlockHash: []byte{byte(i - 1)},
+			Body: &messages.SequenceTx{Data: []byte("hello")},
+			Sig: []byte{byte(i)},
+		})
+	}
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf)
+	assert.Nil(t, err)
+	for _, block := range blocks {
+		assert.Nil(t, w.WriteBlock(block))
+	}
+	assert.Nil(t, w.Flush())
+	data := buf.Bytes()
+
+	r, err := NewReader(bytes.NewReader(data))
+	assert.Nil(t, err)
+	for _, block := range blocks {
+		read, err := r.ReadBlock()
+		assert.Nil(t, err)
+		assert.True(t, proto.Equal(block, read))
+	}
+	_, err = r.ReadBlock()
+	assert.Equal(t, io.EOF, err)
+
+	// Truncated archives are an error, not EOF.
+	r, err = NewReader(bytes.NewReader(data[:len(data) - 1]))
+	assert.Nil(t, err)
+	r.ReadBlock()
+	r.ReadBlock()
+	_, err = r.ReadBlock()
+	assert.NotNil(t, err)
+	assert.NotEqual(t, io.EOF, err)
+
+	_, err = NewReader(bytes.NewReader([]byte("not an archive")))
+	assert.NotNil(t, err)
+}
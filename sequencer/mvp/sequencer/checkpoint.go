@@ -0,0 +1,270 @@
+package sequencer
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"math"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/golang/protobuf/proto"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/accumulator"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/messages"
+)
+
+// Checkpoints.
+//
+// Every `checkpointInterval` blocks, the primary signs a checkpoint which commits to
+// the chain so far - its height, the tip hash, and the root of an accumulator over
+// the hashes of every block. New replicas can sync from a trusted checkpoint instead
+// of from genesis, and backfill the history before it later (see sync.go).
+
+const DefaultCheckpointInterval = 1000
+
+// Sets how often the primary emits a checkpoint, in blocks. 0 disables checkpoints.
+// Must be called before the node starts.
+func (s *SequencerCore) SetCheckpointInterval(interval int64) {
+	s.checkpointInterval = interval
+}
+
+// Signs and stores a checkpoint for the chain tip.
+func (s *SequencerCore) createCheckpoint() (error) {
+	cp := &messages.Checkpoint{
+		Height: s.LastBlock.Height,
+		TipHash: s.LastBlock.SigHash(),
+		AccumulatorRoot: s.acc.Root(),
+		AccumulatorPeaks: s.acc.Peaks(),
+	}
+
+	cp, err := cp.SignWith(s.signer)
+	if err != nil {
+		return fmt.Errorf("error signing checkpoint: %s", err)
+	}
+
+	err = s.storeCheckpoint(cp)
+	if err != nil {
+		return err
+	}
+
+	coreLog.Infow("created checkpoint", "height", cp.Height, "hash", cp.PrettyHash(), "root", hexutil.Encode(cp.AccumulatorRoot))
+	return nil
+}
+
+func (s *SequencerCore) storeCheckpoint(cp *messages.Checkpoint) (error) {
+	buf, err := proto.Marshal(cp)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec("INSERT OR REPLACE INTO checkpoints values (?, ?)", cp.Height, buf)
+	if err != nil {
+		return fmt.Errorf("error writing checkpoint to db: %s", err)
+	}
+	return nil
+}
+
+// Returns the checkpoint at `height`, or the latest checkpoint if `height` is 0.
+// Returns nil if there's no such checkpoint.
+func (s *SequencerCore) GetCheckpoint(height int64) (*messages.Checkpoint, error) {
+	row := s.db.QueryRow("SELECT checkpoint FROM checkpoints WHERE height = ?", height)
+	if height == 0 {
+		row = s.db.QueryRow("SELECT checkpoint FROM checkpoints ORDER BY height DESC LIMIT 1")
+	}
+
+	var buf []byte
+	err := row.Scan(&buf)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching from db: %s", err)
+	}
+
+	cp := &messages.Checkpoint{}
+	err = proto.Unmarshal(buf, cp)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding db checkpoint: %s", err)
+	}
+	return cp, nil
+}
+
+// Returns the accumulator over blocks 1 to `height`. It's restored from the latest
+// checkpoint at or below `height`, and extended with the blocks after it.
+func (s *SequencerCore) accumulatorAt(height int64) (*accumulator.Accumulator, error) {
+	acc := accumulator.New()
+
+	var buf []byte
+	err := s.db.QueryRow("SELECT checkpoint FROM checkpoints WHERE height <= ? ORDER BY height DESC LIMIT 1", height).Scan(&buf)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("error fetching from db: %s", err)
+	}
+	if err == nil {
+		cp := &messages.Checkpoint{}
+		err = proto.Unmarshal(buf, cp)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding db checkpoint: %s", err)
+		}
+		acc, err = accumulator.FromPeaks(cp.Height, cp.AccumulatorPeaks)
+		if err != nil {
+			return nil, fmt.Errorf("checkpoint %d: %s", cp.Height, err)
+		}
+	}
+
+	err = s.replayAccumulator(acc, height)
+	if err != nil {
+		return nil, err
+	}
+	return acc, nil
+}
+
+// Appends the hashes of the blocks after the accumulator, up to `height`.
+func (s *SequencerCore) replayAccumulator(acc *accumulator.Accumulator, height int64) (error) {
+	err := s.scanHashes(acc.Size() + 1, height, func(num int64, hash []byte) (error) {
+		if num != acc.Size() + 1 {
+			return errStopScan
+		}
+		acc.Append(hash)
+		return nil
+	})
+	if err != nil && err != errStopScan {
+		return err
+	}
+
+	if acc.Size() != height {
+		return fmt.Errorf("block %d is missing from the database", acc.Size() + 1)
+	}
+	return nil
+}
+
+var errStopScan = fmt.Errorf("stop scan")
+
+// Calls `fn` with the hash of each block between `from` and `to` in height order,
+// including pruned blocks.
+func (s *SequencerCore) scanHashes(from, to int64, fn func(height int64, hash []byte) (error)) (error) {
+	res, err := s.db.Query("SELECT num, hash FROM headers WHERE num >= ? AND num <= ? ORDER BY num", from, to)
+	if err != nil {
+		return fmt.Errorf("error fetching from db: %s", err)
+	}
+	defer res.Close()
+
+	var (
+		prunedNum int64
+		prunedHash []byte
+		havePruned bool
+	)
+	nextPruned := func() (error) {
+		havePruned = res.Next()
+		if !havePruned {
+			return res.Err()
+		}
+		return res.Scan(&prunedNum, &prunedHash)
+	}
+	// Merges in the pruned blocks before `height`.
+	prunedBefore := func(height int64) (error) {
+		for havePruned && prunedNum < height {
+			err := fn(prunedNum, prunedHash)
+			if err != nil {
+				return err
+			}
+			err = nextPruned()
+			if err != nil {
+				return err
+			}
+		}
+		// A block which was being pruned when we crashed.
+		if havePruned && prunedNum == height {
+			return nextPruned()
+		}
+		return nil
+	}
+
+	err = nextPruned()
+	if err != nil {
+		return fmt.Errorf("error fetching from db: %s", err)
+	}
+	err = s.store.Hashes(from, to, func(height int64, hash []byte) (error) {
+		err := prunedBefore(height)
+		if err != nil {
+			return err
+		}
+		return fn(height, hash)
+	})
+	if err != nil {
+		return err
+	}
+	return prunedBefore(math.MaxInt64)
+}
+
+// Verifies the checkpoint is for `tip`, and is signed by the operator which signed
+// `tip`. Returns the index of that operator.
+func (s *SequencerCore) verifyCheckpoint(cp *messages.Checkpoint, tip *messages.Block) (int, error) {
+	if cp.Height != tip.Height || !bytes.Equal(cp.TipHash, tip.SigHash()) {
+		return 0, fmt.Errorf("checkpoint is for block %s at height %d, not block %s at height %d", cp.PrettyHash(), cp.Height, tip.PrettyHash(), tip.Height)
+	}
+
+	acc, err := accumulator.FromPeaks(cp.Height, cp.AccumulatorPeaks)
+	if err != nil {
+		return 0, err
+	}
+	if !bytes.Equal(acc.Root(), cp.AccumulatorRoot) {
+		return 0, fmt.Errorf("checkpoint accumulator peaks don't match its root")
+	}
+
+	operator, err := s.blockOperator(tip)
+	if err != nil {
+		return 0, err
+	}
+	err = verifyOperatorDigest(s.operatorChangeHistory[operator], cp.SigHash(), cp.Sig, cp.Sigs)
+	if err != nil {
+		return 0, fmt.Errorf("checkpoint signature: %s", err)
+	}
+
+	return operator, nil
+}
+
+// Returns the index of the operator which signed the block, searching from the latest
+// handover back. Used when syncing from a checkpoint, where the chain before it isn't known.
+func (s *SequencerCore) blockOperator(block *messages.Block) (int, error) {
+	for i := len(s.operatorChangeHistory) - 1; 0 <= i; i-- {
+		if verifyOperatorSig(s.operatorChangeHistory[i], block) == nil {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("block %d isn't signed by any operator", block.Height)
+}
+
+// Stores a checkpoint from a peer, after checking it against our chain.
+func (s *SequencerCore) addCheckpoint(cp *messages.Checkpoint) (error) {
+	if s.LastBlock.Height < cp.Height {
+		return fmt.Errorf("checkpoint %d is past the tip %d", cp.Height, s.LastBlock.Height)
+	}
+
+	blocks, err := s.GetBlocks(uint64(cp.Height), uint64(cp.Height))
+	if err != nil {
+		return err
+	}
+	if len(blocks) == 0 {
+		return fmt.Errorf("block %d is missing from the database", cp.Height)
+	}
+
+	_, err = s.verifyCheckpoint(cp, blocks[0])
+	if err != nil {
+		return err
+	}
+
+	acc, err := s.accumulatorAt(cp.Height)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(acc.Root(), cp.AccumulatorRoot) {
+		return fmt.Errorf("checkpoint accumulator root %s doesn't match our chain %s", hexutil.Encode(cp.AccumulatorRoot), hexutil.Encode(acc.Root()))
+	}
+
+	err = s.storeCheckpoint(cp)
+	if err != nil {
+		return err
+	}
+
+	coreLog.Infow("added checkpoint", "height", cp.Height, "hash", cp.PrettyHash())
+	return nil
+}
@@ -0,0 +1,219 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pelletier/go-toml"
+)
+
+// A node's home directory:
+//
+//   <home>/
+//     config.toml       - node configuration
+//     genesis.json      - chain parameters, shared by all nodes in the network
+//     keys/operator.json - encrypted operator key, for signing blocks (primary only)
+//     keys/p2p.json      - encrypted libp2p key, which determines the node's peer ID
+//     data/             - database, and known peers
+const (
+	ConfigFileName = "config.toml"
+	GenesisFileName = "genesis.json"
+)
+
+type Config struct {
+	// "primary" or "replica".
+	Mode string `toml:"mode"`
+
+	// Path to the database. Relative paths are resolved against the home directory.
+	// Empty for an in-memory database.
+	DBPath string `toml:"db_path"`
+
+	RPC RPCConfig `toml:"rpc"`
+	P2P P2PConfig `toml:"p2p"`
+	Log LogConfig `toml:"log"`
+	Keys KeysConfig `toml:"keys"`
+	Sync SyncConfig `toml:"sync"`
+	Storage StorageConfig `toml:"storage"`
+
+	// The home directory this config was loaded from. Not stored.
+	home string
+}
+
+type RPCConfig struct {
+	Port string `toml:"port"`
+	// Empty to disable gRPC.
+	GRPCPort string `toml:"grpc_port"`
+	// Max number of blocks a replica can be behind the tip and still report ready.
+	ReadyMaxLag int64 `toml:"ready_max_lag"`
+	// Port to serve the admin_ methods on, which manage peers. They're only served on
+	// 127.0.0.1. Empty to disable them.
+	AdminPort string `toml:"admin_port"`
+	// Web pages which can connect over WebSocket, eg. "https://example.com", or "*"
+	// for any. Empty to only allow localhost.
+	WSOrigins []string `toml:"ws_origins"`
+}
+
+type P2PConfig struct {
+	Port string `toml:"port"`
+	// Extra peers to bootstrap from, in addition to those in the genesis.
+	Peers []string `toml:"peers"`
+	// Discover peers through the DHT, starting from the bootstrap peers.
+	DHT bool `toml:"dht"`
+	// Discover peers on the local network.
+	MDNS bool `toml:"mdns"`
+	// How blocks are disseminated, "gossip" or "relaytree".
+	Dissemination string `toml:"dissemination"`
+	// Children per node in the relay tree. 0 for the default, 3 for the primary and 8 for replicas.
+	RelayFanout int `toml:"relay_fanout"`
+	// File to save known-good peers to, which are reconnected to on boot. Empty to not save them.
+	PeersFile string `toml:"peers_file"`
+	// Replicas fail to start if they aren't connected to this many peers within
+	// `connect_timeout` seconds. A timeout of 0 waits forever.
+	MinPeers int `toml:"min_peers"`
+	ConnectTimeout int64 `toml:"connect_timeout"`
+}
+
+type LogConfig struct {
+	// "text" or "json".
+	Format string `toml:"format"`
+	Level string `toml:"level"`
+	// Per-subsystem levels, eg. "p2p=debug,db=warn".
+	Levels string `toml:"levels"`
+}
+
+type KeysConfig struct {
+	// Paths to encrypted key files. Relative paths are resolved against the home directory.
+	Operator string `toml:"operator"`
+	P2P string `toml:"p2p"`
+	// File containing the passphrase for the key files. If empty, start prompts for it.
+	PasswordFile string `toml:"password_file"`
+	// Address of a remote signer for the operator key, unix://<path> or http://<host:port>.
+	// When set, the operator key file isn't used.
+	RemoteSigner string `toml:"remote_signer"`
+	// Addresses of the committee members' remote signers, when the genesis operator
+	// is a committee.
+	CommitteeSigners []string `toml:"committee_signers"`
+}
+
+type SyncConfig struct {
+	// The primary signs a checkpoint every this many blocks. 0 disables checkpoints.
+	CheckpointInterval int64 `toml:"checkpoint_interval"`
+	// A checkpoint to fast-sync a new replica from, as <height>:<tip hash>.
+	// Empty to sync from genesis.
+	TrustedCheckpoint string `toml:"trusted_checkpoint"`
+}
+
+type StorageConfig struct {
+	// "archive" to keep every block, or "pruned" to keep only the last `keep_blocks`
+	// block bodies, and the headers of the rest.
+	Mode string `toml:"mode"`
+	KeepBlocks int64 `toml:"keep_blocks"`
+	// Directory to export pruned blocks to, as gzipped archives. Relative paths are
+	// resolved against the home directory. Empty to delete them.
+	ColdStorage string `toml:"cold_storage"`
+	// "sqlite" to store blocks in the database, or "segments" to store them in an
+	// append-only log of segment files in `segments_dir`.
+	Engine string `toml:"engine"`
+	SegmentsDir string `toml:"segments_dir"`
+	// Size of each segment file, in bytes.
+	SegmentSize int64 `toml:"segment_size"`
+	// Appends are fsynced together every this many milliseconds. 0 to fsync every block.
+	SyncIntervalMs int64 `toml:"sync_interval_ms"`
+}
+
+func DefaultConfig() (*Config) {
+	return &Config{
+		Mode: "primary",
+		DBPath: "db.sqlite",
+		RPC: RPCConfig{
+			Port: "24444",
+			GRPCPort: "24446",
+			ReadyMaxLag: 10,
+		},
+		P2P: P2PConfig{
+			Port: "24445",
+			Peers: []string{},
+			DHT: true,
+			MDNS: true,
+			Dissemination: "gossip",
+			MinPeers: 1,
+			ConnectTimeout: 10,
+		},
+		Log: LogConfig{
+			Format: "text",
+			Level: "info",
+		},
+		Sync: SyncConfig{
+			CheckpointInterval: 1000,
+		},
+		Storage: StorageConfig{
+			Mode: "archive",
+			KeepBlocks: 10000,
+			Engine: "sqlite",
+			SegmentsDir: "blocks",
+			SegmentSize: 64 << 20,
+			SyncIntervalMs: 10,
+		},
+	}
+}
+
+// The default config for a new home directory.
+func DefaultHomeConfig(home string) (*Config) {
+	cfg := DefaultConfig()
+	cfg.DBPath = "data/db.sqlite"
+	cfg.Storage.SegmentsDir = "data/blocks"
+	cfg.P2P.PeersFile = "data/peers.json"
+	cfg.Keys = KeysConfig{
+		Operator: "keys/operator.json",
+		P2P: "keys/p2p.json",
+	}
+	cfg.home = home
+	return cfg
+}
+
+// The default home directory, ~/.goliath-sequencer.
+func DefaultHome() (string) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".goliath-sequencer"
+	}
+	return filepath.Join(home, ".goliath-sequencer")
+}
+
+// Loads config.toml from the home directory. Missing fields take their default values.
+func Load(home string) (*Config, error) {
+	path := filepath.Join(home, ConfigFileName)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read config: %s", err)
+	}
+
+	cfg := DefaultConfig()
+	err = toml.Unmarshal(data, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse config %s: %s", path, err)
+	}
+	cfg.home = home
+
+	return cfg, nil
+}
+
+// Writes config.toml to the home directory.
+func (cfg *Config) Write(home string) (error) {
+	file, err := os.OpenFile(filepath.Join(home, ConfigFileName), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return toml.NewEncoder(file).Order(toml.OrderPreserve).Encode(cfg)
+}
+
+// Resolves a path from the config against the home directory.
+func (cfg *Config) Path(path string) (string) {
+	if path == "" || cfg.home == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(cfg.home, path)
+}
@@ -0,0 +1,159 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/messages"
+)
+
+// The genesis file describes the chain. Every node in a network shares the same one -
+// replicas use it to verify blocks were signed by the operator.
+//
+// The operator is either a single key (operator_pubkey), or a k-of-n committee
+// (operator_committee) which co-signs blocks.
+type Genesis struct {
+	ChainID string `json:"chain_id"`
+	// Uncompressed secp256k1 pubkey of the operator which signs blocks.
+	OperatorPubkey hexutil.Bytes `json:"operator_pubkey,omitempty"`
+	OperatorCommittee *GenesisCommittee `json:"operator_committee,omitempty"`
+	// Operator handovers, in chain order.
+	OperatorHandovers []GenesisHandover `json:"operator_handovers,omitempty"`
+	// Multiaddrs of peers to bootstrap from.
+	BootstrapPeers []string `json:"bootstrap_peers"`
+}
+
+type GenesisCommittee struct {
+	// Number of members which must co-sign a block. Must be a majority.
+	Threshold int `json:"threshold"`
+	// Uncompressed secp256k1 pubkeys of the members.
+	Pubkeys []hexutil.Bytes `json:"pubkeys"`
+}
+
+// Hands the chain over to a new operator, which signs the blocks after `after_block`.
+type GenesisHandover struct {
+	// Hash of the last block signed by the previous operator.
+	AfterBlock hexutil.Bytes `json:"after_block"`
+	OperatorPubkey hexutil.Bytes `json:"operator_pubkey,omitempty"`
+	OperatorCommittee *GenesisCommittee `json:"operator_committee,omitempty"`
+}
+
+func (g *Genesis) Validate() (error) {
+	if g.ChainID == "" {
+		return fmt.Errorf("genesis is missing chain_id")
+	}
+
+	err := validateOperator(g.OperatorPubkey, g.OperatorCommittee)
+	if err != nil {
+		return fmt.Errorf("genesis has invalid operator: %s", err)
+	}
+
+	for i, handover := range g.OperatorHandovers {
+		if len(handover.AfterBlock) != 32 {
+			return fmt.Errorf("genesis handover %d has invalid after_block", i)
+		}
+		err := validateOperator(handover.OperatorPubkey, handover.OperatorCommittee)
+		if err != nil {
+			return fmt.Errorf("genesis handover %d has invalid operator: %s", i, err)
+		}
+	}
+
+	return nil
+}
+
+// An operator is either a pubkey or a committee.
+func validateOperator(pubkey hexutil.Bytes, committee *GenesisCommittee) (error) {
+	if committee != nil {
+		if len(pubkey) != 0 {
+			return fmt.Errorf("both operator_pubkey and operator_committee are set")
+		}
+		err := committee.toCommittee().Validate()
+		if err != nil {
+			return fmt.Errorf("invalid operator_committee: %s", err)
+		}
+		return nil
+	}
+
+	_, err := crypto.UnmarshalPubkey(pubkey)
+	if err != nil {
+		return fmt.Errorf("invalid operator_pubkey: %s", err)
+	}
+	return nil
+}
+
+// Hashes the chain's identity - its ID and genesis operator. Bootstrap peers and
+// handovers aren't included, as they're added to over the chain's life.
+func (g *Genesis) Hash() ([]byte) {
+	data, err := json.Marshal(&Genesis{
+		ChainID: g.ChainID,
+		OperatorPubkey: g.OperatorPubkey,
+		OperatorCommittee: g.OperatorCommittee,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return crypto.Keccak256(data)
+}
+
+// Returns the operator committee, or nil if the operator is a single key.
+func (g *Genesis) Committee() (*messages.Committee) {
+	return g.OperatorCommittee.toCommittee()
+}
+
+// Returns the handover's operator committee, or nil if the operator is a single key.
+func (h *GenesisHandover) Committee() (*messages.Committee) {
+	return h.OperatorCommittee.toCommittee()
+}
+
+func (c *GenesisCommittee) toCommittee() (*messages.Committee) {
+	if c == nil {
+		return nil
+	}
+
+	committee := &messages.Committee{
+		Threshold: c.Threshold,
+	}
+	for _, pubkey := range c.Pubkeys {
+		committee.Pubkeys = append(committee.Pubkeys, pubkey)
+	}
+	return committee
+}
+
+func LoadGenesis(path string) (*Genesis, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read genesis: %s", err)
+	}
+
+	genesis := &Genesis{}
+	err = json.Unmarshal(data, genesis)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse genesis %s: %s", path, err)
+	}
+
+	err = genesis.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return genesis, nil
+}
+
+// Loads genesis.json from the home directory.
+func LoadHomeGenesis(home string) (*Genesis, error) {
+	return LoadGenesis(filepath.Join(home, GenesisFileName))
+}
+
+// Writes genesis.json to the home directory.
+func (g *Genesis) Write(home string) (error) {
+	data, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(home, GenesisFileName), append(data, '\n'), 0644)
+}
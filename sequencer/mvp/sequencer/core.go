@@ -5,56 +5,93 @@ import (
 	"fmt"
 
 	"database/sql"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/golang/protobuf/proto"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/accumulator"
 	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/messages"
 	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/utils"
+	"github.com/libp2p/go-libp2p-core/peer"
 	_ "github.com/mattn/go-sqlite3"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto"
 )
 
-// Event listeners.
-
 type onBlockFn func (block *messages.Block)
-type OnBlockEventListener struct {
-	handler onBlockFn
-}
 
 // Sequencer Core.
 type SequencerCore struct {
 	signer utils.Signer
 	db *sql.DB
+	store blockStore
 	
 	sequenceTxs chan *sequenceWork
 	processBlock chan *messages.Block
+	syncWork chan *syncWork
 
 	outOfOrderBlockChan chan *messages.Block
 	unprocessedBlockAtHeight map[int64]*messages.Block
 	LastBlock *messages.Block
 	TotalSeen int
-	blockListeners []*OnBlockEventListener
+	// The highest block height seen from the network. Accessed atomically.
+	highestSeenHeight int64
+	// Tip heights claimed by connected peers. They aren't authenticated, so only
+	// their median counts towards the lag.
+	peerHeightsMu sync.Mutex
+	peerHeights map[peer.ID]int64
+	feed *blockFeed
+
+	Metrics *Metrics
+
+	operatorChangeHistory []operatorChange
+	// Index of the active operator in operatorChangeHistory.
+	operator int
+	// Copy of `operator` for verifying gossip off the loop. Accessed atomically.
+	gossipOperator int32
+
+	// Accumulator over the hashes of blocks 1 to LastBlock.
+	acc *accumulator.Accumulator
+	// The primary emits a checkpoint every `checkpointInterval` blocks, if non-zero.
+	checkpointInterval int64
+
+	storageMode StorageMode
+	// Pruned nodes keep the bodies of the last `keepBlocks` blocks.
+	keepBlocks int64
+	// Directory to export pruned blocks to, if set.
+	coldStorage string
+
+	// Blocks which were proposed to the signer, but not stored (see pending.go).
+	pending []*messages.Block
+	pendingLoaded bool
 }
 
+// An operator handover. The operator signs the blocks after the block with BlockHash.
 type operatorChange struct {
 	BlockHash []byte
 	Pubkey []byte
+	// Set when the operator is a committee, instead of Pubkey.
+	Committee *messages.Committee
+}
+
+func (c operatorChange) String() (string) {
+	if c.Committee != nil {
+		return c.Committee.String()
+	}
+	return hexutil.Encode(c.Pubkey)
 }
-var operatorChangeHistory []operatorChange
+
+// The default operator, used when no genesis is configured.
+var defaultGenesisOperator = hexutil.MustDecode("0x043e0b751273070a517b4c54393deb672e75a6d9dd731bd0b90f11bb178343dc2084ac3c86e289d0902fe40fbb7bb24efd2a342a95220347ed7cedd0dd19d629f5")
 
 
 func NewSequencerCore(db *sql.DB, operatorPrivateKey string) (*SequencerCore) {
-	fmt.Println("migrating database")
+	dbLog.Info("migrating database")
 
-	operatorChangeHistory = []operatorChange{
-		{
-			BlockHash: []byte{0},
-			Pubkey: hexutil.MustDecode("0x043e0b751273070a517b4c54393deb672e75a6d9dd731bd0b90f11bb178343dc2084ac3c86e289d0902fe40fbb7bb24efd2a342a95220347ed7cedd0dd19d629f5"),
-		},
-	}
-	
 	// TODO: handle migation errors
 	// panic: table sequence already exists
 	db.Exec(`
@@ -69,7 +106,22 @@ func NewSequencerCore(db *sql.DB, operatorPrivateKey string) (*SequencerCore) {
 		hash BLOB
 	);
 	`)
-	fmt.Println("migration complete")
+	db.Exec(`
+	CREATE TABLE IF NOT EXISTS checkpoints (
+		height INTEGER PRIMARY KEY,
+		checkpoint BLOB
+	);
+	CREATE TABLE IF NOT EXISTS headers (
+		num INTEGER PRIMARY KEY,
+		header BLOB,
+		hash BLOB
+	);
+	CREATE TABLE IF NOT EXISTS pending_blocks (
+		height INTEGER PRIMARY KEY,
+		block BLOB
+	);
+	`)
+	dbLog.Info("migration complete")
 
 	// if err != nil {
 	// 	panic(err)
@@ -79,23 +131,31 @@ func NewSequencerCore(db *sql.DB, operatorPrivateKey string) (*SequencerCore) {
 		// blockIngestion: make(chan *messages.Block),
 		processBlock: make(chan *messages.Block),
 		sequenceTxs: make(chan *sequenceWork),
+		syncWork: make(chan *syncWork),
 
-		blockListeners: make([]*OnBlockEventListener, 0),
+		feed: newBlockFeed(),
+		Metrics: NewMetrics(),
 		db: db,
+		store: newSQLBlockStore(db),
 		// outOfOrderBlocks: make([]*messages.Block, 100),
 		unprocessedBlockAtHeight: make(map[int64]*messages.Block),
+		peerHeights: make(map[peer.ID]int64),
 	}
+	s.SetGenesisOperator(defaultGenesisOperator)
 
 	// Insert genesis block.
-	s.LastBlock = &messages.Block{
-		Height: 0,
-		PrevBlockHash: []byte{0},
-		Body: nil,
+	s.LastBlock = genesisBlock()
+	s.acc = accumulator.New()
+
+	// Resume from the last block in the database.
+	err := s.restoreTip()
+	if err != nil {
+		dbLog.Errorw("couldn't restore tip from the database", "err", err)
 	}
 
 	if operatorPrivateKey != "" {
 		s.signer = utils.NewEthereumECDSASigner(operatorPrivateKey)
-		fmt.Printf("operator pubkey: %s\n", s.signer.String())
+		coreLog.Infow("operator configured", "pubkey", s.signer.String())
 	}
 
 	go s.loop()
@@ -103,6 +163,15 @@ func NewSequencerCore(db *sql.DB, operatorPrivateKey string) (*SequencerCore) {
 }
 
 
+// The block before the first block in the chain.
+func genesisBlock() (*messages.Block) {
+	return &messages.Block{
+		Height: 0,
+		PrevBlockHash: []byte{0},
+		Body: nil,
+	}
+}
+
 func (s *SequencerCore) loop() {
 	for {
 		// Process blocks serially.
@@ -114,17 +183,90 @@ func (s *SequencerCore) loop() {
 			// Process the block.
 			err := s.doProcessBlock(block)
 			if err != nil {
-				fmt.Println("error while processing block", fmt.Sprint(block.Height), ":", err)
-				continue
+				coreLog.Warnw("error while processing block", "height", block.Height, "hash", block.PrettyHash(), "err", err)
 			}
+
+			s.updateReplicaMetrics()
 		case work := <-s.sequenceTxs:
-			s.doSequenceWork(work)
+			s.sequenceBatch(work)
+		case work := <-s.syncWork:
+			work.done <- work.fn()
 		}
 	}
 }
 
 type sequenceWork struct {
 	msg *messages.SequenceTx
+	received time.Time
+	// Receives the tx's sequence number once its block is committed.
+	done chan sequenceResult
+}
+
+type sequenceResult struct {
+	seqno int64
+	err error
+}
+
+func (s *SequencerCore) updateReplicaMetrics() {
+	s.Metrics.OutOfOrderBlocks.Set(float64(len(s.unprocessedBlockAtHeight)))
+
+	s.Metrics.ReplicaLag.Set(float64(s.Lag()))
+}
+
+// Records a block height seen from the network. Safe to call from any goroutine.
+func (s *SequencerCore) observeHeight(height int64) {
+	for {
+		seen := atomic.LoadInt64(&s.highestSeenHeight)
+		if height <= seen || atomic.CompareAndSwapInt64(&s.highestSeenHeight, seen, height) {
+			return
+		}
+	}
+}
+
+// Records the tip height a peer claims to have. Safe to call from any goroutine.
+func (s *SequencerCore) observePeerHeight(id peer.ID, height int64) {
+	s.peerHeightsMu.Lock()
+	defer s.peerHeightsMu.Unlock()
+	s.peerHeights[id] = height
+}
+
+// Forgets the peer's claimed height, once it's disconnected. Safe to call from any goroutine.
+func (s *SequencerCore) forgetPeerHeight(id peer.ID) {
+	s.peerHeightsMu.Lock()
+	defer s.peerHeightsMu.Unlock()
+	delete(s.peerHeights, id)
+}
+
+// The median of the heights claimed by peers, so a single peer lying about its
+// height can't make us look behind. With an even number, the lower one.
+func (s *SequencerCore) peerHeight() (int64) {
+	s.peerHeightsMu.Lock()
+	heights := make([]int64, 0, len(s.peerHeights))
+	for _, height := range s.peerHeights {
+		heights = append(heights, height)
+	}
+	s.peerHeightsMu.Unlock()
+
+	if len(heights) == 0 {
+		return 0
+	}
+	sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+	return heights[(len(heights) - 1) / 2]
+}
+
+// The number of blocks between the chain tip and the highest block seen from the network,
+// or the height most peers claim to have. Safe to call from any goroutine.
+func (s *SequencerCore) Lag() (int64) {
+	tip, _ := s.feed.current()
+	seen := atomic.LoadInt64(&s.highestSeenHeight)
+	if claimed := s.peerHeight(); seen < claimed {
+		seen = claimed
+	}
+	lag := seen - tip
+	if lag < 0 {
+		return 0
+	}
+	return lag
 }
 
 func (s *SequencerCore) WaitedBlocks(max int64) (int64) {
@@ -157,118 +299,185 @@ func (s *SequencerCore) checkOutOfOrderBlocks() (error) {
 	return nil
 }
 
-func (s *SequencerCore) doSequenceWork(work *sequenceWork) (error) {
-	// Process sequence txs serially.
-	sequenceTx := work.msg
+// Max number of txs sequenced in a batch.
+const maxSequenceBatch = 256
 
-	sequenceBuf, err := proto.Marshal(sequenceTx)
+// Sequences `first`, and any other txs which are queued, as a batch. The batch's blocks
+// are synced to disk together, and only published after that - if a published block
+// was lost in a crash, the primary would sign a different block at the same height
+// when it restarts.
+func (s *SequencerCore) sequenceBatch(first *sequenceWork) {
+	// Blocks which were proposed before, but never stored, go first.
+	err := s.resumePending()
 	if err != nil {
-		return err
+		coreLog.Warnw("error while sequencing tx", "hash", hexutil.Encode(first.msg.SigHash()), "err", err)
+		first.done <- sequenceResult{err: err}
+		return
 	}
 
-	// Insert sequence into storage, generating a sequence number.
-	tx, err := s.db.Begin()
-	if err != nil {
-		return fmt.Errorf("error writing tx to db: %s", err)
+	batch := []*sequenceWork{first}
+	results := []sequenceResult{}
+	blocks := []*messages.Block{}
+
+	for {
+		work := batch[len(batch) - 1]
+		block, err := s.doSequenceWork(work)
+		if err != nil {
+			coreLog.Warnw("error while sequencing tx", "hash", hexutil.Encode(work.msg.SigHash()), "err", err)
+			results = append(results, sequenceResult{err: err})
+		} else {
+			results = append(results, sequenceResult{seqno: block.Height})
+			blocks = append(blocks, block)
+		}
+
+		// Checkpoints are created at the end of a batch, while the accumulator is at
+		// the checkpoint's height.
+		checkpoint := block != nil && s.checkpointInterval != 0 && block.Height % s.checkpointInterval == 0
+		if checkpoint || len(batch) == maxSequenceBatch || 0 < len(s.pending) {
+			break
+		}
+		next := false
+		select {
+		case work := <-s.sequenceTxs:
+			batch = append(batch, work)
+			next = true
+		default:
+		}
+		if !next {
+			break
+		}
 	}
 
-	_, err = tx.Exec(
-		"INSERT INTO sequence values (?, ?, ?)",
-		nil,
-		sequenceBuf,
-		nil,
-	)
+	err = s.publishBlocks(blocks)
 	if err != nil {
-		return fmt.Errorf("error writing tx to db: %s", err)
+		for i := range results {
+			if results[i].err == nil {
+				results[i] = sequenceResult{err: err}
+			}
+		}
 	}
 
-	// Create a block, chain and sign it.
-	block := messages.ConstructBlock(sequenceTx)
-	block.Height = s.LastBlock.Height + 1
-	block.PrevBlockHash = s.LastBlock.SigHash()
-	block = block.Signed(s.signer)
-	
-	// Insert into database.
-	blockBuf, err := proto.Marshal(block)
-	if err != nil {
-		return err
+	for i, work := range batch {
+		if results[i].err == nil {
+			s.Metrics.AppendLatency.Observe(time.Since(work.received).Seconds())
+		}
+		work.done <- results[i]
 	}
+}
 
-	_, err = tx.Exec(
-		"INSERT INTO blocks values (?, ?, ?)",
-		nil,
-		blockBuf,
-		block.SigHash(),
-	)
-	if err != nil {
-		return fmt.Errorf("error writing tx to db: %s", err)
+// Syncs the blocks to disk, then publishes them.
+func (s *SequencerCore) publishBlocks(blocks []*messages.Block) (error) {
+	if len(blocks) == 0 {
+		return nil
 	}
 
-	// Commit the new state.
-	err = tx.Commit()
+	err := s.store.Sync()
 	if err != nil {
-		return err
+		// The blocks may not be on disk, so they're never published. The store fails
+		// every later append too, so the primary stops here.
+		coreLog.Errorw("error syncing blocks, stopping", "err", err)
+		return fmt.Errorf("error syncing block: %s", err)
+	}
+	if s.protectedSigner() {
+		err = s.clearPending(s.LastBlock.Height)
+		if err != nil {
+			coreLog.Warnw("error clearing pending blocks", "err", err)
+		}
 	}
-	
-	s.LastBlock = block
 
-	fmt.Println("chained a block:", block.PrettyString())
+	for _, block := range blocks {
+		s.Metrics.SequencedTxs.Inc()
+		s.Metrics.Blocks.Inc()
+		s.Metrics.ChainHeight.Set(float64(block.Height))
 
-	// Notify the block listeners.
-	for _, list := range s.blockListeners {
-		go list.handler(block)
+		// Notify the block subscribers.
+		s.feed.publish(block)
 	}
 
+	if s.checkpointInterval != 0 && s.LastBlock.Height % s.checkpointInterval == 0 {
+		err = s.createCheckpoint()
+		if err != nil {
+			coreLog.Errorw("error creating checkpoint", "height", s.LastBlock.Height, "err", err)
+		}
+	}
 	return nil
 }
 
-func (s *SequencerCore) ingestBlock(block *messages.Block) (error) {
-	sequenceBuf, err := proto.Marshal(block.Body)
+// Creates the tx's block, and proposes it. It isn't published until it's synced.
+func (s *SequencerCore) doSequenceWork(work *sequenceWork) (*messages.Block, error) {
+	// Process sequence txs serially.
+	sequenceTx := work.msg
+
+	// The tx may have expired while it was queued.
+	height := s.LastBlock.Height + 1
+	err := sequenceTx.CheckExpiry(blockExpiryContext(height, true))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Insert sequence into storage, generating a sequence number.
-	tx, err := s.db.Begin()
+	// Create a block and chain it.
+	block := messages.ConstructBlock(sequenceTx)
+	block.Height = height
+	block.PrevBlockHash = s.LastBlock.SigHash()
+
+	if !s.protectedSigner() {
+		return s.proposeBlock(block)
+	}
+	err = s.savePending(block)
 	if err != nil {
-		return fmt.Errorf("error writing tx to db: %s", err)
+		return nil, err
 	}
-
-	_, err = tx.Exec(
-		"INSERT INTO sequence values (?, ?, ?)",
-		nil,
-		sequenceBuf,
-		nil,
-	)
+	signed, err := s.proposeBlock(block)
 	if err != nil {
-		return fmt.Errorf("error writing tx to db: %s", err)
+		// The signer may have signed it.
+		s.pending = []*messages.Block{block}
+		return nil, fmt.Errorf("%s, the block will be proposed again before the next one", err)
 	}
+	return signed, nil
+}
 
-	// Insert into database.
-	blockBuf, err := proto.Marshal(block)
+// Signs and appends the block.
+func (s *SequencerCore) proposeBlock(unsigned *messages.Block) (*messages.Block, error) {
+	block, err := unsigned.SignWith(s.signer)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("error signing block %d: %s", unsigned.Height, err)
 	}
 
-	_, err = tx.Exec(
-		"INSERT INTO blocks values (?, ?, ?)",
-		nil,
-		blockBuf,
-		block.SigHash(),
-	)
+	// Commit the new state.
+	commitStart := time.Now()
+	err = s.store.Append(block)
 	if err != nil {
-		return fmt.Errorf("error writing tx to db: %s", err)
+		return nil, err
 	}
+	s.Metrics.DBCommitLatency.Observe(time.Since(commitStart).Seconds())
+	
+	s.LastBlock = block
+	s.acc.Append(block.SigHash())
+	s.applyHandover(block)
+
+	coreLog.Debugw("chained a block", "height", block.Height, "hash", block.PrettyHash())
+	return block, nil
+}
 
+func (s *SequencerCore) ingestBlock(block *messages.Block) (error) {
 	// Commit the new state.
-	err = tx.Commit()
+	commitStart := time.Now()
+	err := s.store.Append(block)
 	if err != nil {
 		return err
 	}
+	s.Metrics.DBCommitLatency.Observe(time.Since(commitStart).Seconds())
 
 	s.LastBlock = block
-	fmt.Println("ingested a block:", block.PrettyString())
-	fmt.Printf("new chain height %d\n", block.Height)
+	s.acc.Append(block.SigHash())
+	s.applyHandover(block)
+	coreLog.Debugw("ingested a block", "height", block.Height, "hash", block.PrettyHash())
+
+	s.Metrics.Blocks.Inc()
+	s.Metrics.ChainHeight.Set(float64(block.Height))
+
+	// Notify the block subscribers.
+	s.feed.publish(block)
 
 	return nil
 }
@@ -281,38 +490,14 @@ func (s *SequencerCore) ProcessBlock(block *messages.Block) (error) {
 }
 
 func (s *SequencerCore) doProcessBlock(block *messages.Block) (error) {
-	fmt.Println("process block", fmt.Sprint(block.Height))
-	// fmt.Printf("processing block: %s\n", block.PrettyString())
+	coreLog.Debugw("process block", "height", block.Height)
 
 	// 
 	// 1. Verify block.
 	// 
-	if block.Sig == nil {
-		return fmt.Errorf("missing signature")
-	}
-
-	// Compute the digest which was signed, aka the "sighash".
-	digestHash := block.SigHash()
-
-	// Recover pubkey.
-	pubkey, err := crypto.Ecrecover(digestHash, block.Sig)
+	err := s.verifyBlockSig(block)
 	if err != nil {
-		// TODO
-		fmt.Println("error while recovering pubkey:", err.Error())
-		return fmt.Errorf("invalid signature")
-	}
-
-	// Verify block was signed by the sequencer operator.
-	expectedPubkey := s.GetOperatorPubkey()
-	if !bytes.Equal(pubkey, expectedPubkey) {
-		return fmt.Errorf("invalid signer for block\n     got: %s\nexpected: %s\n", hexutil.Encode(pubkey), hexutil.Encode(expectedPubkey))
-	}
-
-	// Verify signature is valid.
-	// remove recovery id (last byte) from signature.
-	signatureValid := crypto.VerifySignature(pubkey, digestHash, block.Sig[:len(block.Sig)-1])
-	if !signatureValid {
-		return fmt.Errorf("invalid signature")
+		return err
 	}
 	
 	// 
@@ -323,17 +508,40 @@ func (s *SequencerCore) doProcessBlock(block *messages.Block) (error) {
 		return nil
 	}
 	s.TotalSeen += 1
+	s.Metrics.BlocksReceived.Inc()
+	s.observeHeight(block.Height)
 
 	// Block was received out-of-order. We can process it later.
 	if s.LastBlock.Height + 1 < block.Height {
 		// Store it for later.
-		fmt.Println("got block out-of-order:", block.PrettyString())
+		coreLog.Debugw("got block out-of-order", "height", block.Height, "hash", block.PrettyHash(), "tip", s.LastBlock.Height)
 
 		// Maps the out-of-order block to the block height which satisfies it.
 		s.unprocessedBlockAtHeight[block.Height] = block
 		return nil
 	}
 
+	err = s.verifyNextBlock(block)
+	if err != nil {
+		return err
+	}
+
+	// Block was valid.
+	
+	// 
+	// 3. Update sequencer state.
+	// 
+
+	err = s.ingestBlock(block)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Verifies the block follows the last block, and its body is valid.
+func (s *SequencerCore) verifyNextBlock(block *messages.Block) (error) {
 	// Verify hash chain.
 	if !bytes.Equal(block.PrevBlockHash, s.LastBlock.SigHash()) {
 		return fmt.Errorf("block prevhash is not lastblock prevhash")
@@ -344,26 +552,99 @@ func (s *SequencerCore) doProcessBlock(block *messages.Block) (error) {
 		return fmt.Errorf("block body is empty")
 	}
 
-	err = s.verifySequenceMessage(body, false)
+	err := s.verifySequenceMessage(body)
 	if err != nil {
 		return err
 	}
 
-	// Block was valid.
-	
-	// 
-	// 3. Update sequencer state.
-	// 
+	return body.CheckExpiry(blockExpiryContext(block.Height, false))
+}
 
-	err = s.ingestBlock(block)
+// Verifies and ingests the next block in the chain, eg. from an archive.
+// Blocks must be imported in height order.
+// NOTE: This method is NOT threadsafe with the `ProcessBlock` and `Sequence` methods.
+func (s *SequencerCore) ImportBlock(block *messages.Block) (error) {
+	if block.Height != s.LastBlock.Height + 1 {
+		return fmt.Errorf("expected block at height %d, got %d", s.LastBlock.Height + 1, block.Height)
+	}
+
+	err := s.verifyBlockSig(block)
 	if err != nil {
+		return fmt.Errorf("invalid block at height %d: %s", block.Height, err)
+	}
+
+	err = s.verifyNextBlock(block)
+	if err != nil {
+		return fmt.Errorf("invalid block at height %d: %s", block.Height, err)
+	}
+
+	return s.ingestBlock(block)
+}
+
+// Loads the last block from the database, so the node resumes where it left off.
+func (s *SequencerCore) restoreTip() (error) {
+	block, err := s.store.Last()
+	if err != nil || block == nil {
 		return err
 	}
 
+	s.acc, err = s.accumulatorAt(block.Height)
+	if err != nil {
+		return fmt.Errorf("error restoring accumulator: %s", err)
+	}
+
+	s.LastBlock = block
+	atomic.StoreInt64(&s.highestSeenHeight, block.Height)
+	s.Metrics.ChainHeight.Set(float64(block.Height))
+	s.feed.publish(block)
+	dbLog.Infow("restored tip", "height", block.Height, "hash", block.PrettyHash())
+	return nil
+}
+
+// Verifies the block was signed by the active operator.
+func (s *SequencerCore) verifyBlockSig(block *messages.Block) (error) {
+	return verifyOperatorSig(s.operatorChangeHistory[s.operator], block)
+}
+
+// Verifies the block was signed by the operator, or co-signed by the operator committee.
+func verifyOperatorSig(operator operatorChange, block *messages.Block) (error) {
+	return verifyOperatorDigest(operator, block.SigHash(), block.Sig, block.Sigs)
+}
+
+// Verifies `sig` over `digestHash` is from the operator, or `sigs` are from the operator committee.
+func verifyOperatorDigest(operator operatorChange, digestHash []byte, sig []byte, sigs [][]byte) (error) {
+	if operator.Committee != nil {
+		return operator.Committee.Verify(digestHash, sigs)
+	}
+
+	if sig == nil {
+		return fmt.Errorf("missing signature")
+	}
+
+	// Recover pubkey.
+	pubkey, err := crypto.Ecrecover(digestHash, sig)
+	if err != nil {
+		coreLog.Debugw("error while recovering pubkey", "err", err)
+		return fmt.Errorf("invalid signature")
+	}
+
+	// Verify it was signed by the sequencer operator.
+	expectedPubkey := operator.Pubkey
+	if !bytes.Equal(pubkey, expectedPubkey) {
+		return fmt.Errorf("invalid signer\n     got: %s\nexpected: %s\n", hexutil.Encode(pubkey), hexutil.Encode(expectedPubkey))
+	}
+
+	// Verify signature is valid.
+	// remove recovery id (last byte) from signature.
+	signatureValid := crypto.VerifySignature(pubkey, digestHash, sig[:len(sig)-1])
+	if !signatureValid {
+		return fmt.Errorf("invalid signature")
+	}
+
 	return nil
 }
 
-func (s *SequencerCore) verifySequenceMessage(msg *messages.SequenceTx, checkUnixExpiry bool) (error) {
+func (s *SequencerCore) verifySequenceMessage(msg *messages.SequenceTx) (error) {
 	if len(msg.Data) == 0 || msg.Sig == nil {
 		return fmt.Errorf("message is malformed")
 	}
@@ -372,18 +653,25 @@ func (s *SequencerCore) verifySequenceMessage(msg *messages.SequenceTx, checkUni
 		return fmt.Errorf("message is malformed")
 	}
 
-	// TODO: process From and StateReads fields.
+	// TODO: process To field.
 	// if len(msg.To) == 0 || msg.To == nil {
 	// 	return fmt.Errorf("message is malformed")
 	// }
+
+	// Verify access lists.
+	if err := msg.StateReads.Validate(); err != nil {
+		return fmt.Errorf("message is malformed: state reads: %s", err)
+	}
+	if err := msg.StateWrites.Validate(); err != nil {
+		return fmt.Errorf("message is malformed: state writes: %s", err)
+	}
 	
 	// Verify signature.
 	digestHash := msg.SigHash()
 
 	pubkey, err := crypto.Ecrecover(digestHash, msg.Sig)
 	if err != nil {
-		// TODO
-		fmt.Println("error while recovering pubkey:", err.Error())
+		coreLog.Debugw("error while recovering pubkey", "err", err)
 		return fmt.Errorf("invalid signature")
 	}
 
@@ -393,7 +681,7 @@ func (s *SequencerCore) verifySequenceMessage(msg *messages.SequenceTx, checkUni
 	}
 
 	if !bytes.Equal(pubkey, crypto.FromECDSAPub(fromPubkey)) {
-		fmt.Println("message signature is for different pubkey:")
+		coreLog.Debug("message signature is for different pubkey")
 		return fmt.Errorf("invalid signature")
 	}
 
@@ -403,65 +691,77 @@ func (s *SequencerCore) verifySequenceMessage(msg *messages.SequenceTx, checkUni
 		return fmt.Errorf("invalid signature")
 	}
 
-	// Check expiry conditions.
-	for _, expiryCondition := range msg.Expires {
-		if cond := expiryCondition.GetUnix(); cond != nil {
-			if (!checkUnixExpiry) {
-				continue
-			}
+	return nil
+}
 
-			if err != nil {
-				// TODO
-				fmt.Println("error while parsing expiry check time", err.Error())
-				return fmt.Errorf("message is malformed")
-			}
-			
-			if cond.Time < uint64(time.Now().UnixMilli()) {
-				return fmt.Errorf("message expired")
-			}
-		} else {
-			// TODO this won't actually print the Condition id. Do we need this? 
-			return fmt.Errorf("unknown expiry condition '%s'", expiryCondition.GetCondition())
-		}
+// Returns the expiry context for a tx included in the block at `height`.
+// Each block sequences exactly one tx, so its sequence number is the block height.
+// UNIX expiry is only checked by the primary, as it can't be verified deterministically.
+func blockExpiryContext(height int64, checkUnix bool) (messages.ExpiryContext) {
+	return messages.ExpiryContext{
+		Height: height,
+		Sequence: uint64(height),
+		CheckUnix: checkUnix,
+		Now: time.Now(),
 	}
-
-	if err != nil {
-		return err
-	}
-
-	return nil
 }
 
 // Assigns a sequence number for the transaction.
 func (s *SequencerCore) Sequence(msgData string) (int64, error) {
-	if (s.signer == nil) {
-		return 0, fmt.Errorf("sequencer is in replica mode, it will not produce blocks")
-	}
-	
 	// Decode message.
 	msg := &messages.SequenceTx{}
 	
 	msgBuf, err := hexutil.Decode(msgData)
 	if err != nil {
+		s.Metrics.RejectedTxs.WithLabelValues("malformed").Inc()
 		return 0, err
 	}
 
 	err = proto.Unmarshal(msgBuf, msg)
 	if err != nil {
+		s.Metrics.RejectedTxs.WithLabelValues("malformed").Inc()
 		return 0, err
 	}
 
+	return s.SequenceMessage(msg)
+}
+
+// Assigns a sequence number for a decoded transaction.
+func (s *SequencerCore) SequenceMessage(msg *messages.SequenceTx) (int64, error) {
+	seqno, err := s.sequenceMessage(msg)
+	if err != nil {
+		s.Metrics.RejectedTxs.WithLabelValues(rejectReason(err)).Inc()
+	}
+	return seqno, err
+}
+
+func (s *SequencerCore) sequenceMessage(msg *messages.SequenceTx) (int64, error) {
+	if (s.signer == nil) {
+		return 0, fmt.Errorf("sequencer is in replica mode, it will not produce blocks")
+	}
+
 	// Verify message.
-	err = s.verifySequenceMessage(msg, true)
+	err := s.verifySequenceMessage(msg)
+	if err != nil {
+		return 0, err
+	}
+
+	// Reject expired messages early. This is checked again when the block is created.
+	// The core loop writes LastBlock, so read the tip from the feed.
+	tip, _ := s.feed.current()
+	err = msg.CheckExpiry(blockExpiryContext(tip + 1, true))
 	if err != nil {
 		return 0, err
 	}
 	
-	fmt.Printf("sequence hash=%s\n", hexutil.Encode(msg.SigHash()))
+	coreLog.Debugw("sequence", "hash", hexutil.Encode(msg.SigHash()))
 
-	s.sequenceTxs <- &sequenceWork{msg: msg}
+	// Wait for the tx's block to be committed.
+	work := &sequenceWork{msg: msg, received: time.Now(), done: make(chan sequenceResult, 1)}
+	s.sequenceTxs <- work
+	result := <-work.done
 
-	return 0, nil
+	return result.seqno, result.err
 }
 
 
@@ -474,49 +774,39 @@ func (s *SequencerCore) Get(from, to uint64) (*messages.GetTransactions, error)
 		Txs: []*messages.SequenceTx{},
 	}
 
-	res, err := s.db.Query(
-		`SELECT msg FROM sequence WHERE num >= ? AND num <= ?`, 
-		from, 
-		to,
-	)
-	defer res.Close()
-
+	err := s.checkPruned(from, to)
 	if err != nil {
-		return reply, fmt.Errorf("error fetching from db: %s", err)
+		return reply, err
 	}
 
-	// fmt.Println(res.Columns())
-
-	// var hash []byte
-
-	for res.Next() {
-		tx := &messages.SequenceTx{}
-		var (
-			// num int64
-			buf []byte
-		)
-
-		err := res.Scan(&buf)
-		// err := res.Scan(&num, &buf, &hash)
-		if err != nil {
-			return reply, fmt.Errorf("error fetching from db: %s", err)
-		}
+	reply.Txs, err = s.store.Txs(toHeight(from), toHeight(to))
+	if err != nil {
+		return reply, err
+	}
 
-		// fmt.Printf("%d ", len(buf))
-		err = proto.Unmarshal(buf, tx)
-		if err != nil {
-			return reply, fmt.Errorf("error decoding db tx: %s", err)
-		}
+	return reply, nil
+}
 
-		reply.Txs = append(reply.Txs, tx)
+// Returns the blocks between height `from` and `to`.
+// Returns a *PrunedError if any of them have been pruned.
+func (s *SequencerCore) GetBlocks(from, to uint64) ([]*messages.Block, error) {
+	err := s.checkPruned(from, to)
+	if err != nil {
+		return []*messages.Block{}, err
 	}
+	return s.getBlocks(from, to)
+}
 
-	return reply, nil
+func (s *SequencerCore) getBlocks(from, to uint64) ([]*messages.Block, error) {
+	return s.store.Blocks(toHeight(from), toHeight(to))
 }
 
-// Returns the blocks between index `from` and `to`.
-func (s *SequencerCore) GetBlocks(from, to uint64) (int, error) {
-	return 1, nil
+// Heights in requests are unsigned.
+func toHeight(height uint64) (int64) {
+	if math.MaxInt64 < height {
+		return math.MaxInt64
+	}
+	return int64(height)
 }
 
 type SequencerInfo struct {
@@ -532,38 +822,140 @@ func (s *SequencerCore) Info() (*messages.GetSequencerInfo, error) {
 		Count: 0,
 	}
 	
-	res, err := s.db.Query("SELECT COUNT(*) FROM sequence")
+	count, err := s.store.Count()
+	if err != nil {
+		return reply, err
+	}
+
+	// Pruned txs are counted by their headers.
+	var pruned int64
+	err = s.db.QueryRow("SELECT COUNT(*) FROM headers").Scan(&pruned)
 	if err != nil {
 		return reply, fmt.Errorf("error fetching from db: %s", err)
 	}
 
+	reply.Count = uint64(count + pruned)
+	return reply, nil
+}
 
-	for res.Next() {
-		err := res.Scan(&reply.Count)
-		if err != nil {
-			return reply, fmt.Errorf("error fetching from db: %s", err)
+// Calls `onBlock` for every new block, in height order.
+func (s *SequencerCore) OnNewBlock(onBlock onBlockFn) () {
+	tip, _ := s.feed.current()
+	sub := s.SubscribeBlocks(tip + 1)
+
+	go func() {
+		for {
+			select {
+			case block := <-sub.Blocks():
+				onBlock(block)
+			case err, ok := <-sub.Err():
+				if ok {
+					coreLog.Errorw("error in block listener", "err", err)
+				}
+				return
+			}
 		}
+	}()
+}
+
+// Sets the signer for the operator key, eg. a remote signer.
+// Must be called before the node starts.
+func (s *SequencerCore) SetSigner(signer utils.Signer) {
+	s.signer = signer
+	coreLog.Infow("operator configured", "pubkey", s.signer.String())
+}
+
+// Sets the operator at genesis, whose signature blocks are verified against.
+// Must be called before the node starts.
+func (s *SequencerCore) SetGenesisOperator(pubkey []byte) {
+	s.operatorChangeHistory = []operatorChange{
+		{
+			BlockHash: []byte{0},
+			Pubkey: pubkey,
+		},
 	}
+	s.setOperator(0)
+}
 
-	if err != nil {
-		return reply, fmt.Errorf("error fetching from db: %s", err)
+// Sets a k-of-n committee as the operator at genesis. Blocks are verified against
+// the committee's signatures, instead of a single operator's.
+// Must be called before the node starts.
+func (s *SequencerCore) SetGenesisCommittee(committee *messages.Committee) {
+	s.operatorChangeHistory = []operatorChange{
+		{
+			BlockHash: []byte{0},
+			Committee: committee,
+		},
 	}
+	s.setOperator(0)
+}
 
-	return reply, nil
+// Hands the chain over to a new operator (a pubkey or a committee), which signs the
+// blocks after the block with `blockHash`. Handovers must be added in chain order,
+// after the genesis operator is set.
+// Must be called before the node starts.
+func (s *SequencerCore) AddOperatorHandover(blockHash []byte, pubkey []byte, committee *messages.Committee) {
+	s.operatorChangeHistory = append(s.operatorChangeHistory, operatorChange{
+		BlockHash: blockHash,
+		Pubkey: pubkey,
+		Committee: committee,
+	})
+
+	// The handover may already be in the database.
+	if s.operator == len(s.operatorChangeHistory) - 2 && s.hasBlock(blockHash) {
+		s.setOperator(s.operator + 1)
+	}
 }
 
-func (s *SequencerCore) OnNewBlock(onBlock onBlockFn) () {
-	list := &OnBlockEventListener{
-		handler: onBlock,
+// Advances to the next operator, if `block` is the handover block.
+func (s *SequencerCore) applyHandover(block *messages.Block) {
+	if len(s.operatorChangeHistory) <= s.operator + 1 {
+		return
+	}
+
+	next := s.operatorChangeHistory[s.operator + 1]
+	if bytes.Equal(next.BlockHash, block.SigHash()) {
+		s.setOperator(s.operator + 1)
+		coreLog.Infow("operator handover", "height", block.Height, "operator", next)
 	}
-	s.blockListeners = append(s.blockListeners, list)
+}
+
+func (s *SequencerCore) setOperator(operator int) {
+	s.operator = operator
+	atomic.StoreInt32(&s.gossipOperator, int32(operator))
 }
 
 func (s *SequencerCore) GetOperatorPubkey() ([]byte) {
 	// TODO load from Ethereum.
-	return operatorChangeHistory[0].Pubkey
+	return s.operatorChangeHistory[s.operator].Pubkey
+}
+
+// Returns the operator committee, or nil if the operator is a single key.
+func (s *SequencerCore) GetOperatorCommittee() (*messages.Committee) {
+	return s.operatorChangeHistory[s.operator].Committee
 }
 
+// Returns true if the block with `hash` is stored, or was pruned.
+func (s *SequencerCore) hasBlock(hash []byte) (bool) {
+	var num int64
+	err := s.db.QueryRow("SELECT num FROM headers WHERE hash = ?", hash).Scan(&num)
+	if err == nil {
+		return true
+	}
+
+	found, err := s.store.HasHash(hash)
+	if err != nil {
+		dbLog.Errorw("error looking up block", "hash", hexutil.Encode(hash), "err", err)
+	}
+	return found
+}
+
+// Closes the block store, syncing any blocks which haven't been synced yet.
 func (s *SequencerCore) Close() {
+	// On the loop, so no block is appended while it closes.
+	err := s.runOnLoop(s.store.Close)
+	if err != nil {
+		dbLog.Errorw("error closing block store", "err", err)
+	}
 	s.db.Close()
 }
\ No newline at end of file
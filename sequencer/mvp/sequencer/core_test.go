@@ -8,26 +8,30 @@ import (
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer"
 	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/messages"
 	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/utils"
 	"github.com/stretchr/testify/assert"
 )
 
-func getMockSequencer() (*sequencer.SequencerCore, error) {
-	db, err := sql.Open("sqlite3", "file::memory:?cache=shared")
+// The default operator key.
+const testOperatorKey = "3fd7f88cb790c6a8b54d4e1aaebba6775f427bb8fa2276e933b7c3440f164caa"
+
+func getMockSequencer(t *testing.T) (*SequencerCore, error) {
+	// Each test gets its own database.
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s-%d?mode=memory&cache=shared", t.Name(), time.Now().UnixNano()))
 	// db, err := sql.Open("sqlite3", "data.sqlite")
 	if err != nil {
 		return nil, err
 	}
 
-	seq := sequencer.NewSequencerCore(db)
+	seq := NewSequencerCore(db, testOperatorKey)
+	t.Cleanup(seq.Close)
 	return seq, nil
 }
 
 
 func TestSequence(t *testing.T) {
-    seq, err := getMockSequencer()
+    seq, err := getMockSequencer(t)
 	if err != nil {
 		t.Error(err)
 	}
@@ -37,7 +41,7 @@ func TestSequence(t *testing.T) {
 	// 
 	
 	// 1. Message is malformed.
-	seqno, err := seq.Sequence("0x")
+	_, err = seq.Sequence("0x")
 	assert.EqualError(t, err, "message is malformed")
 
 	// 2. Invalid signature.
@@ -49,7 +53,7 @@ func TestSequence(t *testing.T) {
 	msg := messages.ConstructSequenceMessage(txData, 5 * time.Second)
 	msg.Sig = hexutil.MustDecode("0x1234")
 	msg.From = hexutil.MustDecode("0x0266724a07b5fc7937b0a5ef42d9d25b496958426e2d36c69e44e7e33c0b1f835e")
-	seqno, err = seq.Sequence(msg.ToHex())
+	_, err = seq.Sequence(msg.ToHex())
 	assert.EqualError(t, err, "invalid signature")
 
 	// // 2b. Signature for a different message.
@@ -61,7 +65,7 @@ func TestSequence(t *testing.T) {
 	}
 	msg.Sig = badSig
 	msg.SetFrom(signer.GetPubkey())
-	seqno, err = seq.Sequence(msg.ToHex())
+	_, err = seq.Sequence(msg.ToHex())
 	assert.EqualError(t, err, "invalid signature")
 
 	// // 3. Message is expired.
@@ -75,24 +79,27 @@ func TestSequence(t *testing.T) {
 	fmt.Println(msg.ToHex())
 	msg.SetFrom(signer.GetPubkey())
 	msg = msg.Signed(signer)
-	seqno, err = seq.Sequence(msg.ToHex())
+	_, err = seq.Sequence(msg.ToHex())
 	assert.EqualError(t, err, "message expired")
 
 	// Happy path!
 	msg = messages.ConstructSequenceMessage(txData, 1 * time.Second)
 	msg.SetFrom(signer.GetPubkey())
 	msg = msg.Signed(signer)
-	seqno, err = seq.Sequence(msg.ToHex())
-	
-	if err != nil {
-		t.Error(err)
-	}
-
-	assert.True(t, seqno == 1, "First tx sequenced should have sequence number of 1, got %d", seqno)
+	seqno, err := seq.Sequence(msg.ToHex())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), seqno, "first tx should have sequence number 1")
+
+	// The tx is sequenced into the first block.
+	sub := seq.SubscribeBlocks(1)
+	defer sub.Unsubscribe()
+	block := nextBlock(t, sub)
+	assert.Equal(t, int64(1), block.Height)
+	assert.Equal(t, msg.SigHash(), block.Body.SigHash())
 }
 
 // func TestGet(t *testing.T) {
-//     seq, err := getMockSequencer()
+//     seq, err := getMockSequencer(t)
 // 	if err != nil {
 // 		t.Error(err)
 // 	}
@@ -101,7 +108,7 @@ func TestSequence(t *testing.T) {
 // }
 
 func TestInfo(t *testing.T) {
-    seq, err := getMockSequencer()
+    seq, err := getMockSequencer(t)
 	if err != nil {
 		t.Error(err)
 	}
@@ -110,20 +117,31 @@ func TestInfo(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	
-	assert.Equal(t, info.Total, 0, "Total should be 0")
-	assert.Equal(t, info.LastSequenceTime, 0, "LastSequenceTime should be 0")
+	assert.Equal(t, uint64(0), info.Count, "Count should be 0")
 
+	sequenceTestBlocks(t, seq, 1)
 
-	start := time.Now().UnixMilli()
-	seq.Sequence("")
-	end := time.Now().UnixMilli()
-	
-	assert.Equal(t, info.Total, 1, "Total should be 1")
-	assert.True(
-		t,
-		start <= info.LastSequenceTime && info.LastSequenceTime < end,
-		"LastSequenceTime should be a recent timestamp.\nstart=%d\nLastSequenceTime=%d\nend=%d\n",
-		start, info.LastSequenceTime, end,
-	)
-}
\ No newline at end of file
+	info, err = seq.Info()
+	if err != nil {
+		t.Error(err)
+	}
+	assert.Equal(t, uint64(1), info.Count, "Count should be 1")
+}
+
+func TestLagPeerHeights(t *testing.T) {
+	seq := newTestReplica(t)
+	a, b, liar := randomPeerID(t), randomPeerID(t), randomPeerID(t)
+	assert.Equal(t, int64(0), seq.Lag())
+
+	// One peer can't move the median on its own.
+	seq.observePeerHeight(a, 5)
+	seq.observePeerHeight(b, 7)
+	seq.observePeerHeight(liar, 1 << 62)
+	assert.Equal(t, int64(7), seq.Lag())
+	seq.forgetPeerHeight(liar)
+	assert.Equal(t, int64(5), seq.Lag())
+
+	// Heights from verified blocks count in full.
+	seq.observeHeight(9)
+	assert.Equal(t, int64(9), seq.Lag())
+}
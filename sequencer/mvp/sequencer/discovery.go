@@ -0,0 +1,134 @@
+package sequencer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	dht "github.com/libp2p/go-libp2p-kad-dht"
+	"github.com/libp2p/go-libp2p/p2p/discovery/mdns"
+	drouting "github.com/libp2p/go-libp2p/p2p/discovery/routing"
+	dutil "github.com/libp2p/go-libp2p/p2p/discovery/util"
+)
+
+// Peer discovery.
+//
+// Nodes find each other through a Kademlia DHT, joined through the bootstrap peers.
+// Every node advertises itself under DHT_RENDEZVOUS_MAGIC, and periodically looks up
+// the other nodes advertising it, connecting to any it isn't connected to. So a replica
+// only needs one bootstrap peer to find the rest of the network.
+//
+// On local networks, nodes also find each other over mDNS, without any bootstrap peers.
+
+const (
+	// Keeps our DHT separate from the public IPFS DHT.
+	dhtProtocolPrefix = "/goliath"
+	discoveryInterval = 30 * time.Second
+	// Stop connecting to discovered peers once we have this many.
+	discoveryMaxPeers = 32
+)
+
+// Enables discovering peers over the DHT and mDNS.
+// Must be called before the node starts.
+func (n *P2PNode) SetDiscovery(useDHT bool, useMDNS bool) (error) {
+	if useDHT {
+		kdht, err := dht.New(
+			n.ctx,
+			n.Host,
+			// Every node is reachable, so they all answer queries.
+			dht.Mode(dht.ModeServer),
+			dht.ProtocolPrefix(dhtProtocolPrefix),
+			dht.BootstrapPeers(n.bootstrapPeers...),
+		)
+		if err != nil {
+			return fmt.Errorf("couldn't create DHT: %s", err)
+		}
+		n.dht = kdht
+	}
+
+	if useMDNS {
+		n.mdns = mdns.NewMdnsService(n.Host, DHT_RENDEZVOUS_MAGIC, n)
+	}
+	return nil
+}
+
+func (n *P2PNode) startDiscovery() {
+	if n.dht != nil {
+		err := n.dht.Bootstrap(n.ctx)
+		if err != nil {
+			p2pLog.Warnw("error bootstrapping DHT", "err", err)
+		}
+		go n.runDHTDiscovery()
+	}
+
+	if n.mdns != nil {
+		err := n.mdns.Start()
+		if err != nil {
+			p2pLog.Warnw("error starting mDNS discovery", "err", err)
+		}
+	}
+}
+
+func (n *P2PNode) runDHTDiscovery() {
+	disc := drouting.NewRoutingDiscovery(n.dht)
+	// Re-advertises before the advertisement expires.
+	dutil.Advertise(n.ctx, disc, DHT_RENDEZVOUS_MAGIC)
+
+	for {
+		if len(n.Host.Network().Peers()) < discoveryMaxPeers {
+			n.findPeers(disc)
+		}
+
+		select {
+		case <-time.After(discoveryInterval):
+		case <-n.ctx.Done():
+			return
+		}
+	}
+}
+
+func (n *P2PNode) findPeers(disc *drouting.RoutingDiscovery) {
+	ctx, cancel := context.WithTimeout(n.ctx, discoveryInterval)
+	defer cancel()
+
+	peers, err := dutil.FindPeers(ctx, disc, DHT_RENDEZVOUS_MAGIC)
+	if err != nil {
+		p2pLog.Debugw("error finding peers", "err", err)
+		return
+	}
+	for _, peerinfo := range peers {
+		n.HandlePeerFound(peerinfo)
+	}
+}
+
+// Connects to a discovered peer, if we aren't already connected to it.
+func (n *P2PNode) HandlePeerFound(peerinfo peer.AddrInfo) {
+	if peerinfo.ID == n.Host.ID() || len(peerinfo.Addrs) == 0 {
+		return
+	}
+	if n.Host.Network().Connectedness(peerinfo.ID) == network.Connected {
+		return
+	}
+
+	p2pLog.Debugw("connecting to discovered peer", "peer", peerinfo.ID.Pretty())
+	ctx, cancel := context.WithTimeout(n.ctx, 10 * time.Second)
+	defer cancel()
+
+	err := n.Host.Connect(ctx, peerinfo)
+	if err != nil {
+		p2pLog.Debugw("error connecting to discovered peer", "peer", peerinfo.ID.Pretty(), "err", err)
+		return
+	}
+	p2pLog.Infow("connected to discovered peer", "peer", peerinfo.ID.Pretty())
+}
+
+func (n *P2PNode) closeDiscovery() {
+	if n.mdns != nil {
+		n.mdns.Close()
+	}
+	if n.dht != nil {
+		n.dht.Close()
+	}
+}
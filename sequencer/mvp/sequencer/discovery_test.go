@@ -0,0 +1,82 @@
+package sequencer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	drouting "github.com/libp2p/go-libp2p/p2p/discovery/routing"
+	dutil "github.com/libp2p/go-libp2p/p2p/discovery/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestDHTNode(t *testing.T, bootstrap *P2PNode) (*P2PNode) {
+	addrs := AddrList{}
+	if bootstrap != nil {
+		var err error
+		addrs, err = peer.AddrInfoToP2pAddrs(&peer.AddrInfo{ID: bootstrap.Host.ID(), Addrs: bootstrap.Host.Addrs()})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	node, err := NewP2PNode("/ip4/127.0.0.1/tcp/0", nil, addrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		node.Close()
+	})
+	assert.NoError(t, node.SetDiscovery(true, false))
+	node.connectBootstrapPeers()
+	return node
+}
+
+func TestDHTDiscovery(t *testing.T) {
+	// Two replicas which only know the bootstrap node find each other.
+	boot := newTestDHTNode(t, nil)
+	a := newTestDHTNode(t, boot)
+	b := newTestDHTNode(t, boot)
+	assert.Equal(t, network.Connected, a.Host.Network().Connectedness(boot.Host.ID()))
+	assert.NotEqual(t, network.Connected, a.Host.Network().Connectedness(b.Host.ID()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10 * time.Second)
+	defer cancel()
+	for _, node := range []*P2PNode{boot, a, b} {
+		assert.NoError(t, node.dht.Bootstrap(ctx))
+	}
+
+	// Advertising fails until the DHT has peers in its routing table.
+	assert.Eventually(t, func() (bool) {
+		_, err := drouting.NewRoutingDiscovery(b.dht).Advertise(ctx, DHT_RENDEZVOUS_MAGIC)
+		return err == nil
+	}, 10 * time.Second, 50 * time.Millisecond)
+
+	disc := drouting.NewRoutingDiscovery(a.dht)
+	assert.Eventually(t, func() (bool) {
+		a.findPeers(disc)
+		return a.Host.Network().Connectedness(b.Host.ID()) == network.Connected
+	}, 10 * time.Second, 50 * time.Millisecond)
+
+	// The advertisement is found by name.
+	peers, err := dutil.FindPeers(ctx, disc, DHT_RENDEZVOUS_MAGIC)
+	assert.NoError(t, err)
+	found := false
+	for _, info := range peers {
+		found = found || info.ID == b.Host.ID()
+	}
+	assert.True(t, found)
+}
+
+func TestHandlePeerFound(t *testing.T) {
+	a, b := newTestP2PNode(t), newTestP2PNode(t)
+
+	// Peers without addresses, and ourselves, are ignored.
+	a.HandlePeerFound(peer.AddrInfo{ID: b.Host.ID()})
+	a.HandlePeerFound(peer.AddrInfo{ID: a.Host.ID(), Addrs: a.Host.Addrs()})
+	assert.Empty(t, a.Host.Network().Peers())
+
+	a.HandlePeerFound(peer.AddrInfo{ID: b.Host.ID(), Addrs: b.Host.Addrs()})
+	assert.Equal(t, network.Connected, a.Host.Network().Connectedness(b.Host.ID()))
+}
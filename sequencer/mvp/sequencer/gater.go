@@ -0,0 +1,71 @@
+package sequencer
+
+import (
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/control"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// Refuses connections to and from blocked peers, until the block expires.
+// Otherwise discovery, gossip and the DHT redial a peer as soon as we disconnect it.
+type peerGater struct {
+	mu sync.Mutex
+	blocked map[peer.ID]time.Time
+}
+
+func newPeerGater() (*peerGater) {
+	return &peerGater{
+		blocked: make(map[peer.ID]time.Time),
+	}
+}
+
+// Blocks the peer for `d`.
+func (g *peerGater) Block(id peer.ID, d time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.blocked[id] = time.Now().Add(d)
+}
+
+func (g *peerGater) Unblock(id peer.ID) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.blocked, id)
+}
+
+func (g *peerGater) Blocked(id peer.ID) (bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	until, ok := g.blocked[id]
+	if !ok {
+		return false
+	}
+	if time.Now().After(until) {
+		delete(g.blocked, id)
+		return false
+	}
+	return true
+}
+
+func (g *peerGater) InterceptPeerDial(id peer.ID) (bool) {
+	return !g.Blocked(id)
+}
+
+func (g *peerGater) InterceptAddrDial(id peer.ID, _ ma.Multiaddr) (bool) {
+	return !g.Blocked(id)
+}
+
+func (g *peerGater) InterceptAccept(_ network.ConnMultiaddrs) (bool) {
+	return true
+}
+
+func (g *peerGater) InterceptSecured(_ network.Direction, id peer.ID, _ network.ConnMultiaddrs) (bool) {
+	return !g.Blocked(id)
+}
+
+func (g *peerGater) InterceptUpgraded(_ network.Conn) (bool, control.DisconnectReason) {
+	return true, 0
+}
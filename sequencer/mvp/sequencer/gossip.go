@@ -0,0 +1,180 @@
+package sequencer
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/messages"
+	"github.com/libp2p/go-libp2p-core/peer"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+)
+
+// Gossip validation.
+//
+// Blocks are validated before they're delivered to us or relayed to our peers, so
+// invalid blocks stop at the first honest node. Validation only checks the block is
+// well-formed and signed by the operator - the rest is checked when it's processed.
+//
+// Peers are scored by GossipSub. Peers which deliver new blocks first gain score, and
+// peers which relay invalid blocks lose it quickly. Peers with a low score stop
+// receiving gossip from us, and then are ignored entirely (graylisted).
+
+// The largest block we gossip.
+const maxGossipBlockSize = DefaultMaxMessageSize
+
+// Returned for blocks at or below our tip, or from an earlier operator.
+var errStaleBlock = fmt.Errorf("block is stale")
+
+// Verifies a gossipped block before it's relayed. The block must be signed by the
+// active operator, or an operator after a handover we haven't processed yet.
+// Returns errStaleBlock if we're already past it. Safe to call from any goroutine.
+func (s *SequencerCore) VerifyGossipBlock(block *messages.Block) (error) {
+	if block.Body == nil {
+		return fmt.Errorf("block body is empty")
+	}
+	tip, _ := s.feed.current()
+	if block.Height <= tip {
+		return errStaleBlock
+	}
+
+	digestHash := block.SigHash()
+	err := s.verifyGossipDigest(digestHash, block.Sig, block.Sigs)
+	if err == nil {
+		return nil
+	}
+	// Signed before a handover we've processed, and the peer hasn't.
+	operator := atomic.LoadInt32(&s.gossipOperator)
+	for _, op := range s.operatorChangeHistory[:operator] {
+		if verifyOperatorDigest(op, digestHash, block.Sig, block.Sigs) == nil {
+			return errStaleBlock
+		}
+	}
+	return err
+}
+
+// Verifies `sig` or `sigs` over `digestHash` are from the active operator, or an
+// operator after it.
+func (s *SequencerCore) verifyGossipDigest(digestHash []byte, sig []byte, sigs [][]byte) (error) {
+	var err error
+	operator := atomic.LoadInt32(&s.gossipOperator)
+	for _, op := range s.operatorChangeHistory[operator:] {
+		err = verifyOperatorDigest(op, digestHash, sig, sigs)
+		if err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// Validates blocks on the new blocks topic with `verify`, before they're delivered
+// or relayed. Must be called before the node starts.
+func (n *P2PNode) ValidateBlocks(verify func(block *messages.Block) (error)) (error) {
+	reject := func(from peer.ID, reason string, err error) (pubsub.ValidationResult) {
+		p2pLog.Debugw("rejected gossipped block", "peer", from, "reason", reason, "err", err)
+		if n.metrics != nil {
+			n.metrics.GossipRejectedBlocks.WithLabelValues(reason).Inc()
+		}
+		// Only forged blocks get a peer banned.
+		switch reason {
+		case "stale":
+			return pubsub.ValidationIgnore
+		case "invalid":
+			n.peers.InvalidBlock(from)
+		default:
+			n.peers.MalformedBlock(from)
+		}
+		return pubsub.ValidationReject
+	}
+
+	validator := func(ctx context.Context, from peer.ID, msg *pubsub.Message) (pubsub.ValidationResult) {
+		if maxGossipBlockSize < len(msg.Data) {
+			return reject(from, "too_large", fmt.Errorf("block is %d bytes, max is %d", len(msg.Data), maxGossipBlockSize))
+		}
+
+		block := &messages.Block{}
+		err := proto.Unmarshal(msg.Data, block)
+		if err != nil {
+			return reject(from, "malformed", err)
+		}
+
+		err = verify(block)
+		// We publish our own blocks once they're on our tip.
+		if err == errStaleBlock && from == n.Host.ID() {
+			err = nil
+		}
+		if err == errStaleBlock {
+			return reject(from, "stale", err)
+		}
+		if err != nil {
+			return reject(from, "invalid", err)
+		}
+
+		// Saves decoding the block again.
+		msg.ValidatorData = block
+		n.peers.ValidBlock(from)
+		return pubsub.ValidationAccept
+	}
+
+	return n.ps.RegisterTopicValidator(topicName(PUBSUB_TOPIC_NEW_BLOCKS), validator)
+}
+
+// Peer scoring parameters for GossipSub.
+func peerScoreParams() (*pubsub.PeerScoreParams, *pubsub.PeerScoreThresholds) {
+	newBlocks := &pubsub.TopicScoreParams{
+		TopicWeight: 1,
+
+		// A little score for staying in the mesh.
+		TimeInMeshWeight: 0.01,
+		TimeInMeshQuantum: time.Second,
+		TimeInMeshCap: 100,
+
+		// Score for delivering new blocks first.
+		FirstMessageDeliveriesWeight: 1,
+		FirstMessageDeliveriesDecay: pubsub.ScoreParameterDecay(10 * time.Minute),
+		FirstMessageDeliveriesCap: 50,
+
+		// Relaying an invalid block graylists a peer for a while. The penalty is the
+		// square of the number of invalid blocks, so repeat offenders stay out longer.
+		InvalidMessageDeliveriesWeight: -100,
+		InvalidMessageDeliveriesDecay: pubsub.ScoreParameterDecay(time.Hour),
+	}
+
+	// Only invalid advertisements are scored.
+	primaryAds := &pubsub.TopicScoreParams{
+		TopicWeight: 1,
+		TimeInMeshQuantum: time.Second,
+		InvalidMessageDeliveriesWeight: -100,
+		InvalidMessageDeliveriesDecay: pubsub.ScoreParameterDecay(time.Hour),
+	}
+
+	params := &pubsub.PeerScoreParams{
+		Topics: map[string]*pubsub.TopicScoreParams{
+			topicName(PUBSUB_TOPIC_NEW_BLOCKS): newBlocks,
+			topicName(PUBSUB_TOPIC_PRIMARY): primaryAds,
+		},
+		TopicScoreCap: 100,
+		AppSpecificScore: func(peer.ID) (float64) {
+			return 0
+		},
+		BehaviourPenaltyWeight: -10,
+		BehaviourPenaltyThreshold: 5,
+		BehaviourPenaltyDecay: pubsub.ScoreParameterDecay(time.Hour),
+		DecayInterval: time.Second,
+		DecayToZero: 0.01,
+		// Remember a peer's score after it disconnects, so it can't reset it by reconnecting.
+		RetainScore: time.Hour,
+	}
+
+	thresholds := &pubsub.PeerScoreThresholds{
+		GossipThreshold: -10,
+		PublishThreshold: -50,
+		GraylistThreshold: -80,
+		AcceptPXThreshold: 10,
+		OpportunisticGraftThreshold: 5,
+	}
+
+	return params, thresholds
+}
@@ -0,0 +1,142 @@
+package sequencer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/golang/protobuf/proto"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/messages"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/utils"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// Returns the first block of a new chain.
+func firstTestBlock(t *testing.T) (*messages.Block) {
+	seq, err := getMockSequencer(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sequenceTestBlocks(t, seq, 1)
+	blocks, err := seq.GetBlocks(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return blocks[0]
+}
+
+func randomTestSigner(t *testing.T) (*utils.EthereumECDSASigner) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return utils.NewEthereumECDSASignerFromKey(key)
+}
+
+func TestVerifyGossipBlock(t *testing.T) {
+	block := firstTestBlock(t)
+	replica := newTestReplica(t)
+	assert.NoError(t, replica.VerifyGossipBlock(block))
+
+	forged := proto.Clone(block).(*messages.Block)
+	forged.Body.Data = []byte{0xff}
+	assert.Error(t, replica.VerifyGossipBlock(forged))
+	forged = proto.Clone(block).(*messages.Block)
+	forged.Body = nil
+	assert.EqualError(t, replica.VerifyGossipBlock(forged), "block body is empty")
+	assert.Error(t, replica.VerifyGossipBlock(block.Signed(randomTestSigner(t))))
+
+	// The next operator's blocks are let through before we've processed the handover.
+	next := randomTestSigner(t)
+	replica.AddOperatorHandover(block.SigHash(), crypto.FromECDSAPub(next.GetPubkey()), nil)
+	handedOver := messages.ConstructBlock(block.Body)
+	handedOver.Height = 2
+	handedOver.PrevBlockHash = block.SigHash()
+	assert.NoError(t, replica.VerifyGossipBlock(handedOver.Signed(next)))
+	assert.NoError(t, replica.VerifyGossipBlock(block))
+}
+
+func TestVerifyGossipBlockStale(t *testing.T) {
+	seq, err := getMockSequencer(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sequenceTestBlocks(t, seq, 2)
+	blocks, err := seq.GetBlocks(1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, errStaleBlock, seq.VerifyGossipBlock(blocks[0]))
+	assert.Equal(t, errStaleBlock, seq.VerifyGossipBlock(blocks[1]))
+}
+
+func TestGossipValidation(t *testing.T) {
+	block := firstTestBlock(t)
+	replica := newTestReplica(t)
+
+	primaryNode, replicaNode := newTestP2PNode(t), newTestP2PNode(t)
+	replicaNode.metrics = NewMetrics()
+	assert.NoError(t, replicaNode.ValidateBlocks(replica.VerifyGossipBlock))
+	received := make(chan *messages.Block, 10)
+	go replicaNode.ListenForNewBlocks(func(block *messages.Block) {
+		received <- block
+	})
+	connectTestNodes(t, replicaNode, primaryNode)
+	assert.Eventually(t, func() (bool) {
+		return 0 < len(primaryNode.newBlocks.ListPeers())
+	}, 5 * time.Second, 10 * time.Millisecond)
+
+	primaryNode.GossipNewBlock(block)
+	select {
+	case got := <-received:
+		assert.True(t, proto.Equal(block, got))
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the block")
+	}
+
+	// Forged blocks are dropped before they're delivered.
+	forged := proto.Clone(block).(*messages.Block)
+	forged.Body.Data = []byte{0xff}
+	primaryNode.GossipNewBlock(forged)
+	rejected := replicaNode.metrics.GossipRejectedBlocks.WithLabelValues("invalid")
+	assert.Eventually(t, func() (bool) {
+		return testutil.ToFloat64(rejected) == 1
+	}, 5 * time.Second, 10 * time.Millisecond)
+	assert.Len(t, received, 0)
+}
+
+func TestGossipFromPrimary(t *testing.T) {
+	primary, err := getMockSequencer(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replica := newTestReplica(t)
+
+	// Both validate blocks, as the node does.
+	primaryNode, replicaNode := newTestP2PNode(t), newTestP2PNode(t)
+	assert.NoError(t, primaryNode.ValidateBlocks(primary.VerifyGossipBlock))
+	assert.NoError(t, replicaNode.ValidateBlocks(replica.VerifyGossipBlock))
+	received := make(chan *messages.Block, 10)
+	go replicaNode.ListenForNewBlocks(func(block *messages.Block) {
+		received <- block
+	})
+	connectTestNodes(t, replicaNode, primaryNode)
+	assert.Eventually(t, func() (bool) {
+		return 0 < len(primaryNode.newBlocks.ListPeers())
+	}, 5 * time.Second, 10 * time.Millisecond)
+
+	// The primary gossips blocks once they're on its tip.
+	sequenceTestBlocks(t, primary, 1)
+	blocks, err := primary.GetBlocks(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	primaryNode.GossipNewBlock(blocks[0])
+	select {
+	case got := <-received:
+		assert.True(t, proto.Equal(blocks[0], got))
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the block")
+	}
+}
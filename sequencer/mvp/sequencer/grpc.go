@@ -0,0 +1,129 @@
+package sequencer
+
+import (
+	"context"
+	"errors"
+
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/messages"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// gRPC frontend for the sequencer, served alongside JSON-RPC.
+type grpcSequencerServer struct {
+	messages.UnimplementedSequencerServer
+	seq *SequencerCore
+}
+
+func newGRPCServer(seq *SequencerCore) (*grpc.Server) {
+	server := grpc.NewServer()
+	messages.RegisterSequencerServer(server, &grpcSequencerServer{seq: seq})
+	return server
+}
+
+func (s *grpcSequencerServer) Append(ctx context.Context, req *messages.AppendRequest) (*messages.AppendResponse, error) {
+	if req.Tx == nil {
+		return nil, status.Error(codes.InvalidArgument, "missing tx")
+	}
+
+	sequence, err := s.seq.SequenceMessage(req.Tx)
+	if err != nil {
+		return nil, appendError(err)
+	}
+
+	return &messages.AppendResponse{Sequence: sequence}, nil
+}
+
+func (s *grpcSequencerServer) GetRange(ctx context.Context, req *messages.GetRangeRequest) (*messages.GetTransactions, error) {
+	reply, err := s.seq.Get(req.From, req.To)
+	if err != nil {
+		return nil, readError(err)
+	}
+	return reply, nil
+}
+
+func (s *grpcSequencerServer) GetBlock(ctx context.Context, req *messages.GetBlockRequest) (*messages.Block, error) {
+	blocks, err := s.seq.GetBlocks(uint64(req.Height), uint64(req.Height))
+	if err != nil {
+		return nil, readError(err)
+	}
+	if len(blocks) == 0 {
+		return nil, status.Errorf(codes.NotFound, "block %d not found", req.Height)
+	}
+	return blocks[0], nil
+}
+
+func (s *grpcSequencerServer) GetTx(ctx context.Context, req *messages.GetTxRequest) (*messages.SequenceTx, error) {
+	reply, err := s.seq.Get(req.Sequence, req.Sequence)
+	if err != nil {
+		return nil, readError(err)
+	}
+	if len(reply.Txs) == 0 {
+		return nil, status.Errorf(codes.NotFound, "tx %d not found", req.Sequence)
+	}
+	return reply.Txs[0], nil
+}
+
+func (s *grpcSequencerServer) Info(ctx context.Context, req *messages.InfoRequest) (*messages.GetSequencerInfo, error) {
+	reply, err := s.seq.Info()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return reply, nil
+}
+
+func (s *grpcSequencerServer) GetCheckpoint(ctx context.Context, req *messages.GetCheckpointRequest) (*messages.Checkpoint, error) {
+	cp, err := s.seq.GetCheckpoint(req.Height)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if cp == nil {
+		return nil, status.Errorf(codes.NotFound, "checkpoint %d not found", req.Height)
+	}
+	return cp, nil
+}
+
+func (s *grpcSequencerServer) Subscribe(req *messages.SubscribeRequest, stream messages.Sequencer_SubscribeServer) (error) {
+	sub := s.seq.SubscribeBlocks(req.FromHeight)
+	defer sub.Unsubscribe()
+	rpcLog.Debugw("grpc: subscribe", "from", req.FromHeight)
+
+	for {
+		select {
+		case block := <-sub.Blocks():
+			err := stream.Send(block)
+			if err != nil {
+				return err
+			}
+		case err, ok := <-sub.Err():
+			if ok {
+				return readError(err)
+			}
+			return nil
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+// Only rejected txs are the client's fault. Replicas can't sequence, and anything
+// else is a failure on our side, like the database or the signer.
+func appendError(err error) (error) {
+	switch rejectReason(err) {
+	case "malformed", "invalid_signature", "expired":
+		return status.Error(codes.InvalidArgument, err.Error())
+	case "replica_mode":
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// Reads of pruned blocks fail with OutOfRange, so clients know to try an archive node.
+func readError(err error) (error) {
+	var pruned *PrunedError
+	if errors.As(err, &pruned) {
+		return status.Error(codes.OutOfRange, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
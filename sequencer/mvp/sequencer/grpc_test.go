@@ -0,0 +1,45 @@
+package sequencer
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/messages"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/utils"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAppendErrorCodes(t *testing.T) {
+	seq, err := getMockSequencer(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &grpcSequencerServer{seq: seq}
+	signer := utils.NewEthereumECDSASigner("3977045d27df7e401ecf1596fd3ae86b59f666944f81ba8dbf547c2269902f6b")
+	msg := messages.ConstructSequenceMessage("0xc4a6abb1cc341e7b796bdc0fb11c50a12d4e998cc4e8e3cb44badf185a8e00f7", time.Minute)
+	msg.SetFrom(signer.GetPubkey())
+	msg = msg.Signed(signer)
+
+	res, err := server.Append(context.Background(), &messages.AppendRequest{Tx: msg})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), res.Sequence)
+
+	_, err = server.Append(context.Background(), &messages.AppendRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	forged := messages.ConstructSequenceMessage("0x01", time.Minute)
+	forged.SetFrom(signer.GetPubkey())
+	_, err = server.Append(context.Background(), &messages.AppendRequest{Tx: forged})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Replicas can't sequence.
+	replica := &grpcSequencerServer{seq: newTestReplica(t)}
+	_, err = replica.Append(context.Background(), &messages.AppendRequest{Tx: msg})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	// Other failures are ours.
+	assert.Equal(t, codes.Internal, status.Code(appendError(fmt.Errorf("error syncing blocks: disk full"))))
+}
@@ -0,0 +1,263 @@
+package sequencer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/messages"
+	libp2pHost "github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/protocol"
+	"github.com/libp2p/go-msgio"
+)
+
+// Handshake.
+//
+// When nodes connect, the dialer opens a handshake stream and sends its chain ID,
+// genesis hash, protocol version, mode and tip height. The listener replies with its
+// own. Both sides check the other is on the same chain and speaks a compatible version
+// of the protocol, and disconnect it otherwise. Peers which don't handshake in time
+// are disconnected too, as they're running something else.
+
+const handshakeProtocolId = protocol.ID("/goliath/handshake/1.0.0")
+
+const (
+	// Version of the P2P messages. Bump it when they change, and MinProtocolVersion
+	// when the change is incompatible.
+	ProtocolVersion = 1
+	MinProtocolVersion = 1
+
+	handshakeTimeout = 10 * time.Second
+	// Incompatible peers are blocked for this long.
+	incompatiblePeerBackoff = 10 * time.Minute
+	handshakeMaxMessageSize = 4096
+)
+
+// Capabilities, for optional protocols.
+const (
+	CapabilityRelayTree = "relaytree"
+)
+
+type Handshaker struct {
+	host libp2pHost.Host
+	p2p *P2PNode
+	seq *SequencerCore
+	mode SequencerMode
+	metrics *Metrics
+
+	chainID string
+	genesisHash []byte
+	capabilities []string
+
+	mu sync.Mutex
+	// Handshakes of connected peers.
+	peers map[peer.ID]*messages.Handshake
+}
+
+func NewHandshaker(seq *SequencerCore, p2p *P2PNode, mode SequencerMode) (*Handshaker) {
+	host := p2p.Host
+	h := &Handshaker{
+		host: host,
+		p2p: p2p,
+		seq: seq,
+		mode: mode,
+		metrics: seq.Metrics,
+		peers: make(map[peer.ID]*messages.Handshake),
+	}
+	host.SetStreamHandler(handshakeProtocolId, h.handleStream)
+	host.Network().Notify(&network.NotifyBundle{
+		ConnectedF: func(_ network.Network, conn network.Conn) {
+			go h.onConnected(conn)
+		},
+		DisconnectedF: func(net network.Network, conn network.Conn) {
+			if net.Connectedness(conn.RemotePeer()) != network.Connected {
+				h.mu.Lock()
+				delete(h.peers, conn.RemotePeer())
+				h.mu.Unlock()
+				// Including when it's banned.
+				h.seq.forgetPeerHeight(conn.RemotePeer())
+			}
+		},
+	})
+	return h
+}
+
+// Sets the chain we're on. Must be called before the node starts.
+func (h *Handshaker) SetGenesis(chainID string, genesisHash []byte) {
+	h.chainID = chainID
+	h.genesisHash = genesisHash
+}
+
+// Advertises an optional protocol to peers. Must be called before the node starts.
+func (h *Handshaker) AddCapability(capability string) {
+	h.capabilities = append(h.capabilities, capability)
+}
+
+// Returns the peer's handshake, or nil if it hasn't completed one.
+func (h *Handshaker) Peer(id peer.ID) (*messages.Handshake) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.peers[id]
+}
+
+func (h *Handshaker) handshake() (*messages.Handshake) {
+	mode := "primary"
+	if h.mode == ReplicaMode {
+		mode = "replica"
+	}
+	tip, _ := h.seq.feed.current()
+
+	return &messages.Handshake{
+		ChainId: h.chainID,
+		GenesisHash: h.genesisHash,
+		ProtocolVersion: ProtocolVersion,
+		MinProtocolVersion: MinProtocolVersion,
+		Mode: mode,
+		Height: tip,
+		Capabilities: h.capabilities,
+	}
+}
+
+// Checks the peer is on our chain, and speaks a compatible protocol version.
+// Returns the reason for the metrics, and the error.
+func (h *Handshaker) check(theirs *messages.Handshake) (string, error) {
+	if theirs.ChainId != h.chainID {
+		return "chain", fmt.Errorf("peer is on chain %q, we're on %q", theirs.ChainId, h.chainID)
+	}
+	// A peer without a genesis could be on any chain, so the hashes must match exactly.
+	if !bytes.Equal(theirs.GenesisHash, h.genesisHash) {
+		return "genesis", fmt.Errorf("peer has a different genesis for chain %q", h.chainID)
+	}
+	if theirs.ProtocolVersion < MinProtocolVersion || ProtocolVersion < theirs.MinProtocolVersion {
+		return "version", fmt.Errorf(
+			"peer speaks protocol version %d (min %d), we speak %d (min %d)",
+			theirs.ProtocolVersion, theirs.MinProtocolVersion, ProtocolVersion, MinProtocolVersion,
+		)
+	}
+	return "", nil
+}
+
+// Records the peer's handshake, or disconnects it.
+func (h *Handshaker) complete(id peer.ID, theirs *messages.Handshake) {
+	reason, err := h.check(theirs)
+	if err != nil {
+		h.disconnect(id, reason, err)
+		return
+	}
+
+	h.mu.Lock()
+	h.peers[id] = theirs
+	h.mu.Unlock()
+	// So a replica isn't ready until it has caught up with its peers.
+	h.seq.observePeerHeight(id, theirs.Height)
+	p2pLog.Debugw(
+		"handshake",
+		"peer", id,
+		"mode", theirs.Mode,
+		"height", theirs.Height,
+		"version", theirs.ProtocolVersion,
+		"capabilities", theirs.Capabilities,
+	)
+}
+
+func (h *Handshaker) disconnect(id peer.ID, reason string, err error) {
+	p2pLog.Warnw("disconnecting incompatible peer", "peer", id, "reason", err)
+	if h.metrics != nil {
+		h.metrics.HandshakeFailures.WithLabelValues(reason).Inc()
+	}
+	h.p2p.BlockPeer(id, incompatiblePeerBackoff)
+}
+
+func (h *Handshaker) onConnected(conn network.Conn) {
+	id := conn.RemotePeer()
+
+	// The listener waits for the dialer's handshake.
+	if conn.Stat().Direction != network.DirOutbound {
+		time.Sleep(handshakeTimeout)
+		if h.Peer(id) == nil && h.host.Network().Connectedness(id) == network.Connected {
+			h.disconnect(id, "no_handshake", fmt.Errorf("peer didn't handshake"))
+		}
+		return
+	}
+
+	if h.Peer(id) != nil {
+		return
+	}
+	theirs, err := h.request(id)
+	if err != nil {
+		if h.host.Network().Connectedness(id) == network.Connected {
+			h.disconnect(id, "no_handshake", fmt.Errorf("handshake failed: %s", err))
+		}
+		return
+	}
+	h.complete(id, theirs)
+}
+
+// Sends our handshake to the peer, and reads theirs.
+func (h *Handshaker) request(id peer.ID) (*messages.Handshake, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), handshakeTimeout)
+	defer cancel()
+	s, err := h.host.NewStream(ctx, id, handshakeProtocolId)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+	s.SetDeadline(time.Now().Add(handshakeTimeout))
+
+	err = writeHandshake(s, h.handshake())
+	if err != nil {
+		s.Reset()
+		return nil, err
+	}
+	theirs, err := readHandshake(s)
+	if err != nil {
+		s.Reset()
+		return nil, err
+	}
+	return theirs, nil
+}
+
+func (h *Handshaker) handleStream(s network.Stream) {
+	defer s.Close()
+	s.SetDeadline(time.Now().Add(handshakeTimeout))
+
+	theirs, err := readHandshake(s)
+	if err != nil {
+		p2pLog.Debugw("error reading handshake", "peer", s.Conn().RemotePeer(), "err", err)
+		s.Reset()
+		return
+	}
+	// We reply even if they're incompatible, so they can log why. Then we wait for
+	// them to read it, so disconnecting them doesn't cut it off.
+	err = writeHandshake(s, h.handshake())
+	if err != nil {
+		s.Reset()
+		return
+	}
+	s.CloseWrite()
+	s.Read(make([]byte, 1))
+	h.complete(s.Conn().RemotePeer(), theirs)
+}
+
+func readHandshake(s network.Stream) (*messages.Handshake, error) {
+	buf, err := msgio.NewVarintReaderSize(s, handshakeMaxMessageSize).ReadMsg()
+	if err != nil {
+		return nil, err
+	}
+	msg := &messages.Handshake{}
+	err = proto.Unmarshal(buf, msg)
+	return msg, err
+}
+
+func writeHandshake(s network.Stream, msg *messages.Handshake) (error) {
+	buf, err := proto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return msgio.NewVarintWriter(s).WriteMsg(buf)
+}
@@ -0,0 +1,100 @@
+package sequencer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/messages"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandshakeCheck(t *testing.T) {
+	h := &Handshaker{}
+	h.SetGenesis("goliath-test", []byte{1, 2, 3})
+
+	ours := func() (*messages.Handshake) {
+		return &messages.Handshake{
+			ChainId: "goliath-test",
+			GenesisHash: []byte{1, 2, 3},
+			ProtocolVersion: ProtocolVersion,
+			MinProtocolVersion: MinProtocolVersion,
+		}
+	}
+
+	reason, err := h.check(ours())
+	assert.NoError(t, err)
+	assert.Equal(t, "", reason)
+
+	tests := []struct {
+		name string
+		change func(theirs *messages.Handshake)
+		reason string
+	}{
+		{"other chain", func(theirs *messages.Handshake) { theirs.ChainId = "other" }, "chain"},
+		{"other genesis", func(theirs *messages.Handshake) { theirs.GenesisHash = []byte{4, 5, 6} }, "genesis"},
+		{"no genesis", func(theirs *messages.Handshake) { theirs.GenesisHash = nil }, "genesis"},
+		{"too old", func(theirs *messages.Handshake) { theirs.ProtocolVersion = MinProtocolVersion - 1 }, "version"},
+		{"too new", func(theirs *messages.Handshake) { theirs.MinProtocolVersion = ProtocolVersion + 1 }, "version"},
+	}
+	for _, test := range tests {
+		theirs := ours()
+		test.change(theirs)
+		reason, err := h.check(theirs)
+		assert.Error(t, err, test.name)
+		assert.Equal(t, test.reason, reason, test.name)
+	}
+
+	// A node without a genesis only talks to others without one.
+	h.SetGenesis("goliath-test", nil)
+	_, err = h.check(ours())
+	assert.Error(t, err)
+	theirs := ours()
+	theirs.GenesisHash = nil
+	_, err = h.check(theirs)
+	assert.NoError(t, err)
+}
+
+func newTestHandshaker(t *testing.T, chainID string) (*Handshaker) {
+	node := newTestP2PNode(t)
+	h := NewHandshaker(newTestReplica(t), node, ReplicaMode)
+	h.SetGenesis(chainID, []byte{1, 2, 3})
+	return h
+}
+
+func claimedHeight(h *Handshaker, id peer.ID) (bool) {
+	h.seq.peerHeightsMu.Lock()
+	defer h.seq.peerHeightsMu.Unlock()
+	_, ok := h.seq.peerHeights[id]
+	return ok
+}
+
+func TestHandshake(t *testing.T) {
+	a := newTestHandshaker(t, "goliath-test")
+	b := newTestHandshaker(t, "goliath-test")
+	connectTestNodes(t, a.p2p, b.p2p)
+	assert.Eventually(t, func() (bool) {
+		return a.Peer(b.host.ID()) != nil && b.Peer(a.host.ID()) != nil
+	}, 5 * time.Second, 10 * time.Millisecond)
+	assert.Equal(t, "replica", a.Peer(b.host.ID()).Mode)
+	assert.True(t, claimedHeight(a, b.host.ID()))
+
+	// Peers on another chain are disconnected, and can't reconnect.
+	other := newTestHandshaker(t, "other")
+	connectTestNodes(t, other.p2p, a.p2p)
+	assert.Eventually(t, func() (bool) {
+		return a.host.Network().Connectedness(other.host.ID()) != network.Connected
+	}, 5 * time.Second, 10 * time.Millisecond)
+	assert.Nil(t, a.Peer(other.host.ID()))
+	assert.False(t, claimedHeight(a, other.host.ID()))
+
+	// Disconnected peers' heights are forgotten.
+	a.host.Network().ClosePeer(b.host.ID())
+	assert.Eventually(t, func() (bool) {
+		return !claimedHeight(a, b.host.ID()) && a.Peer(b.host.ID()) == nil
+	}, 5 * time.Second, 10 * time.Millisecond)
+	err := a.host.Connect(context.Background(), peer.AddrInfo{ID: other.host.ID(), Addrs: other.host.Addrs()})
+	assert.Error(t, err)
+}
@@ -0,0 +1,128 @@
+package sequencer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	libp2pHost "github.com/libp2p/go-libp2p-core/host"
+)
+
+// Health checks, for load balancers and orchestrators (eg. Kubernetes probes).
+//
+// /healthz - the process is alive, and the database is reachable.
+// /readyz  - the node can serve traffic. A replica must be within `maxLag` blocks
+//            of the highest block seen on the network, or of the median of its
+//            peers' tips, and have at least one peer. A primary must be able to sign.
+
+// Default number of blocks a replica can be behind and still be ready.
+const DefaultReadyMaxLag = 10
+
+const healthCheckTimeout = 2 * time.Second
+
+type HealthChecker struct {
+	seq *SequencerCore
+	host libp2pHost.Host
+	mode SequencerMode
+	maxLag int64
+}
+
+func NewHealthChecker(seq *SequencerCore, host libp2pHost.Host, mode SequencerMode, maxLag int64) (*HealthChecker) {
+	return &HealthChecker{
+		seq: seq,
+		host: host,
+		mode: mode,
+		maxLag: maxLag,
+	}
+}
+
+// Checks the process is alive and the database is reachable.
+func (h *HealthChecker) Healthy(ctx context.Context) (error) {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	err := h.seq.db.PingContext(ctx)
+	if err != nil {
+		return fmt.Errorf("database unreachable: %s", err)
+	}
+	return nil
+}
+
+// Checks the node is ready to serve traffic.
+func (h *HealthChecker) Ready(ctx context.Context) (error) {
+	err := h.Healthy(ctx)
+	if err != nil {
+		return err
+	}
+
+	switch h.mode {
+	case PrimaryMode:
+		if h.seq.signer == nil {
+			return fmt.Errorf("no operator key configured")
+		}
+		// Remote signers only sign blocks, so we check they're up instead.
+		if remote, ok := h.seq.signer.(upchecker); ok {
+			err = remote.Upcheck()
+		} else {
+			_, err = h.seq.signer.Sign(crypto.Keccak256([]byte("goliath-sequencer/readyz")))
+		}
+		if err != nil {
+			return fmt.Errorf("operator can't sign: %s", err)
+		}
+
+	case ReplicaMode:
+		if h.host != nil && len(h.host.Network().Peers()) == 0 {
+			return fmt.Errorf("no peers connected")
+		}
+
+		lag := h.seq.Lag()
+		if h.maxLag < lag {
+			return fmt.Errorf("replica is %d blocks behind the tip (max %d)", lag, h.maxLag)
+		}
+	}
+
+	return nil
+}
+
+type upchecker interface {
+	Upcheck() (error)
+}
+
+type healthStatus struct {
+	Status string `json:"status"`
+	Error string `json:"error,omitempty"`
+	Height int64 `json:"height"`
+	Lag int64 `json:"lag"`
+}
+
+func (h *HealthChecker) handler(check func(context.Context) (error)) (http.HandlerFunc) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tip, _ := h.seq.feed.current()
+		res := healthStatus{
+			Status: "ok",
+			Height: tip,
+			Lag: h.seq.Lag(),
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+
+		err := check(r.Context())
+		if err != nil {
+			res.Status = "unavailable"
+			res.Error = err.Error()
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+
+		json.NewEncoder(w).Encode(res)
+	}
+}
+
+// Registers /healthz and /readyz on the mux.
+func (h *HealthChecker) register(mux *http.ServeMux) {
+	mux.HandleFunc("/healthz", h.handler(h.Healthy))
+	mux.HandleFunc("/readyz", h.handler(h.Ready))
+}
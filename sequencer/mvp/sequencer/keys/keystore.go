@@ -0,0 +1,165 @@
+package keys
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+	libp2pCrypto "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// Passphrase-encrypted key files.
+//
+// The operator key is stored in Ethereum's keystore v3 format, so it can be used with
+// other Ethereum tooling. The libp2p key uses the same encryption (scrypt + AES-128-CTR),
+// in a file which records the peer ID instead of an address.
+
+const (
+	OperatorKey = "operator"
+	P2PKey = "p2p"
+)
+
+// Scrypt parameters for new key files. Lowered in tests.
+var (
+	ScryptN = keystore.StandardScryptN
+	ScryptP = keystore.StandardScryptP
+)
+
+type p2pKeyJSON struct {
+	Version int `json:"version"`
+	ID string `json:"id"`
+	Type string `json:"type"`
+	PeerID string `json:"peer_id"`
+	Crypto keystore.CryptoJSON `json:"crypto"`
+}
+
+// The public fields of a key file, readable without the passphrase.
+type KeyInfo struct {
+	Type string
+	// The operator's Ethereum address, or the node's peer ID.
+	ID string
+}
+
+func EncryptOperatorKey(key *ecdsa.PrivateKey, passphrase string) ([]byte, error) {
+	return keystore.EncryptKey(&keystore.Key{
+		Id: uuid.New(),
+		Address: crypto.PubkeyToAddress(key.PublicKey),
+		PrivateKey: key,
+	}, passphrase, ScryptN, ScryptP)
+}
+
+func DecryptOperatorKey(data []byte, passphrase string) (*ecdsa.PrivateKey, error) {
+	key, err := keystore.DecryptKey(data, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return key.PrivateKey, nil
+}
+
+func EncryptP2PKey(key libp2pCrypto.PrivKey, passphrase string) ([]byte, error) {
+	raw, err := libp2pCrypto.MarshalPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := peer.IDFromPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	cryptoJSON, err := keystore.EncryptDataV3(raw, []byte(passphrase), ScryptN, ScryptP)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(p2pKeyJSON{
+		Version: 3,
+		ID: uuid.New().String(),
+		Type: P2PKey,
+		PeerID: id.Pretty(),
+		Crypto: cryptoJSON,
+	})
+}
+
+func DecryptP2PKey(data []byte, passphrase string) (libp2pCrypto.PrivKey, error) {
+	file := p2pKeyJSON{}
+	err := json.Unmarshal(data, &file)
+	if err != nil {
+		return nil, err
+	}
+	if file.Type != P2PKey {
+		return nil, fmt.Errorf("not a p2p key file")
+	}
+
+	raw, err := keystore.DecryptDataV3(file.Crypto, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	return libp2pCrypto.UnmarshalPrivateKey(raw)
+}
+
+// Reads the public fields of a key file.
+func Inspect(data []byte) (*KeyInfo, error) {
+	var fields struct {
+		Type string `json:"type"`
+		PeerID string `json:"peer_id"`
+		Address string `json:"address"`
+		Crypto *json.RawMessage `json:"crypto"`
+	}
+	err := json.Unmarshal(data, &fields)
+	if err != nil {
+		return nil, fmt.Errorf("not a key file: %s", err)
+	}
+	if fields.Crypto == nil {
+		return nil, fmt.Errorf("not a key file: missing crypto")
+	}
+
+	if fields.Type == P2PKey {
+		return &KeyInfo{Type: P2PKey, ID: fields.PeerID}, nil
+	}
+	return &KeyInfo{Type: OperatorKey, ID: "0x" + fields.Address}, nil
+}
+
+// Writes a key file, readable only by the owner.
+func WriteKeyFile(path string, data []byte) (error) {
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// Reads and decrypts the operator key file at `path`.
+func LoadOperatorKey(path string, passphrase string) (*ecdsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read operator key: %s", err)
+	}
+
+	key, err := DecryptOperatorKey(data, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't decrypt operator key %s: %s", path, err)
+	}
+	return key, nil
+}
+
+// Reads and decrypts the p2p key file at `path`.
+func LoadP2PKey(path string, passphrase string) (libp2pCrypto.PrivKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read p2p key: %s", err)
+	}
+
+	key, err := DecryptP2PKey(data, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't decrypt p2p key %s: %s", path, err)
+	}
+	return key, nil
+}
@@ -0,0 +1,67 @@
+package keys
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	libp2pCrypto "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	ScryptN = keystore.LightScryptN
+	ScryptP = keystore.LightScryptP
+}
+
+func TestOperatorKeyRoundTrip(t *testing.T) {
+	key, _ := crypto.HexToECDSA("3fd7f88cb790c6a8b54d4e1aaebba6775f427bb8fa2276e933b7c3440f164caa")
+
+	data, err := EncryptOperatorKey(key, "hunter2")
+	assert.Nil(t, err)
+
+	// Compatible with Ethereum's keystore.
+	ethKey, err := keystore.DecryptKey(data, "hunter2")
+	assert.Nil(t, err)
+	assert.Equal(t, crypto.PubkeyToAddress(key.PublicKey), ethKey.Address)
+
+	decrypted, err := DecryptOperatorKey(data, "hunter2")
+	assert.Nil(t, err)
+	assert.Equal(t, crypto.FromECDSA(key), crypto.FromECDSA(decrypted))
+
+	_, err = DecryptOperatorKey(data, "wrong")
+	assert.Equal(t, keystore.ErrDecrypt, err)
+
+	info, err := Inspect(data)
+	assert.Nil(t, err)
+	assert.Equal(t, OperatorKey, info.Type)
+	assert.Equal(t, "0x" + hex.EncodeToString(crypto.PubkeyToAddress(key.PublicKey).Bytes()), info.ID)
+}
+
+func TestP2PKeyRoundTrip(t *testing.T) {
+	key, _, _ := libp2pCrypto.GenerateKeyPair(libp2pCrypto.Ed25519, -1)
+	id, _ := peer.IDFromPrivateKey(key)
+
+	data, err := EncryptP2PKey(key, "hunter2")
+	assert.Nil(t, err)
+
+	decrypted, err := DecryptP2PKey(data, "hunter2")
+	assert.Nil(t, err)
+	assert.True(t, key.Equals(decrypted))
+
+	_, err = DecryptP2PKey(data, "wrong")
+	assert.Equal(t, keystore.ErrDecrypt, err)
+
+	info, err := Inspect(data)
+	assert.Nil(t, err)
+	assert.Equal(t, P2PKey, info.Type)
+	assert.Equal(t, id.Pretty(), info.ID)
+
+	// An operator key file isn't a p2p key file.
+	operatorKey, _ := crypto.GenerateKey()
+	operatorData, _ := EncryptOperatorKey(operatorKey, "hunter2")
+	_, err = DecryptP2PKey(operatorData, "hunter2")
+	assert.NotNil(t, err)
+}
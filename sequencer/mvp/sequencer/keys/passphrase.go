@@ -0,0 +1,54 @@
+package keys
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// Reads a passphrase from `passwordFile`, or if it's empty, prompts for it on the terminal.
+// When `confirm` is set, the prompt asks for the passphrase twice.
+func ReadPassphrase(passwordFile string, prompt string, confirm bool) (string, error) {
+	if passwordFile != "" {
+		data, err := os.ReadFile(passwordFile)
+		if err != nil {
+			return "", fmt.Errorf("couldn't read password file: %s", err)
+		}
+		// Only the first line, so files written by `echo` work.
+		return strings.TrimRight(strings.SplitN(string(data), "\n", 2)[0], "\r"), nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("no password file given, and stdin is not a terminal to prompt on")
+	}
+
+	passphrase, err := promptPassphrase(fd, prompt)
+	if err != nil {
+		return "", err
+	}
+
+	if confirm {
+		repeated, err := promptPassphrase(fd, "Repeat passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if passphrase != repeated {
+			return "", fmt.Errorf("passphrases do not match")
+		}
+	}
+
+	return passphrase, nil
+}
+
+func promptPassphrase(fd int, prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	data, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("couldn't read passphrase: %s", err)
+	}
+	return string(data), nil
+}
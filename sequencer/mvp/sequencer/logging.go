@@ -0,0 +1,64 @@
+package sequencer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ipfs/go-log/v2"
+)
+
+// Subsystem loggers. Each has its own level, set with SetupLogging or the
+// GOLOG_LOG_LEVEL environment variable (eg. GOLOG_LOG_LEVEL="info,p2p=debug").
+var (
+	coreLog = log.Logger("core")
+	p2pLog = log.Logger("p2p")
+	rpcLog = log.Logger("rpc")
+	dbLog = log.Logger("db")
+)
+
+// The sequencer's logging subsystems.
+var LogSubsystems = []string{"core", "p2p", "rpc", "db"}
+
+// Configures logging for the node.
+//  - format is "text" or "json".
+//  - level is the level for the sequencer's subsystems (debug, info, warn, error).
+//  - subsystemLevels overrides the level per subsystem, eg. "p2p=debug,db=warn".
+//    This also accepts libp2p's subsystems, eg. "pubsub=debug".
+func SetupLogging(format string, level string, subsystemLevels string) (error) {
+	cfg := log.GetConfig()
+
+	switch format {
+	case "text":
+		cfg.Format = log.ColorizedOutput
+	case "json":
+		cfg.Format = log.JSONOutput
+	default:
+		return fmt.Errorf("unknown log format: %s", format)
+	}
+	log.SetupLogging(cfg)
+
+	for _, subsystem := range LogSubsystems {
+		err := log.SetLogLevel(subsystem, level)
+		if err != nil {
+			return fmt.Errorf("invalid log level %s: %s", level, err)
+		}
+	}
+
+	for _, entry := range strings.Split(subsystemLevels, ",") {
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid subsystem log level '%s', expected subsystem=level", entry)
+		}
+
+		err := log.SetLogLevel(parts[0], parts[1])
+		if err != nil {
+			return fmt.Errorf("invalid log level for %s: %s", parts[0], err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package messages
+
+import (
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/golang/protobuf/proto"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/utils"
+)
+
+// Advertisements are signed with the same key as blocks, under their own prefix.
+var advertisementDomain = []byte("goliath/advertisement")
+
+func (ad *SequencerPrimaryAdvertisement) SigHash() ([]byte) {
+	unsigned := proto.Clone(ad).(*SequencerPrimaryAdvertisement)
+	unsigned.Sig = nil
+	unsigned.Sigs = nil
+
+	buf, err := proto.Marshal(unsigned)
+	if err != nil {
+		panic(err)
+	}
+
+	return crypto.Keccak256(advertisementDomain, buf)
+}
+
+// A signer which is given the whole advertisement to sign, rather than its digest.
+type AdvertisementSigner interface {
+	SignAdvertisement(ad *SequencerPrimaryAdvertisement) (sig []byte, err error)
+}
+
+// A signer for a committee of operators, which returns a signature from each
+// member that co-signed the advertisement.
+type AdvertisementCoSigner interface {
+	CoSignAdvertisement(ad *SequencerPrimaryAdvertisement) (sigs [][]byte, err error)
+}
+
+// Returns a new SequencerPrimaryAdvertisement with a signature, or an error if the
+// signer refused to sign it.
+func (ad *SequencerPrimaryAdvertisement) SignWith(signer utils.Signer) (*SequencerPrimaryAdvertisement, error) {
+	signed := proto.Clone(ad).(*SequencerPrimaryAdvertisement)
+
+	var err error
+	switch s := signer.(type) {
+	case AdvertisementCoSigner:
+		signed.Sigs, err = s.CoSignAdvertisement(ad)
+	case AdvertisementSigner:
+		signed.Sig, err = s.SignAdvertisement(ad)
+	default:
+		signed.Sig, err = signer.Sign(ad.SigHash())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return signed, nil
+}
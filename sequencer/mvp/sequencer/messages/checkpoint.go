@@ -0,0 +1,59 @@
+package messages
+
+import (
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/golang/protobuf/proto"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/utils"
+)
+
+// Checkpoints are signed with the same key as blocks. The digest is prefixed, so a
+// checkpoint signature can never be passed off as a block signature.
+var checkpointDomain = []byte("goliath/checkpoint")
+
+func (cp *Checkpoint) SigHash() ([]byte) {
+	unsigned := proto.Clone(cp).(*Checkpoint)
+	unsigned.Sig = nil
+	unsigned.Sigs = nil
+
+	buf, err := proto.Marshal(unsigned)
+	if err != nil {
+		panic(err)
+	}
+
+	return crypto.Keccak256(checkpointDomain, buf)
+}
+
+// A signer which is given the whole checkpoint to sign, rather than its digest.
+type CheckpointSigner interface {
+	SignCheckpoint(cp *Checkpoint) (sig []byte, err error)
+}
+
+// A signer for a committee of operators, which returns a signature from each
+// member that co-signed the checkpoint.
+type CheckpointCoSigner interface {
+	CoSignCheckpoint(cp *Checkpoint) (sigs [][]byte, err error)
+}
+
+// Returns a new Checkpoint with a signature, or an error if the signer refused to sign it.
+func (cp *Checkpoint) SignWith(signer utils.Signer) (*Checkpoint, error) {
+	signed := proto.Clone(cp).(*Checkpoint)
+
+	var err error
+	switch s := signer.(type) {
+	case CheckpointCoSigner:
+		signed.Sigs, err = s.CoSignCheckpoint(cp)
+	case CheckpointSigner:
+		signed.Sig, err = s.SignCheckpoint(cp)
+	default:
+		signed.Sig, err = signer.Sign(cp.SigHash())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return signed, nil
+}
+
+func (cp *Checkpoint) PrettyHash() (string) {
+	return hexutil.Encode(cp.TipHash)
+}
@@ -0,0 +1,105 @@
+package messages
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// A k-of-n committee of operators, which co-sign each block.
+//
+// A block is valid if it carries signatures from at least `Threshold` distinct members
+// in `Sigs`. Each member runs a signer with slashing protection, so a member never signs
+// two different blocks at the same height. The threshold must be a majority, so any two
+// sets of signers overlap in at least one member - to equivocate, an attacker has to
+// compromise enough keys to reach the threshold on their own.
+type Committee struct {
+	Threshold int
+	// Uncompressed secp256k1 pubkeys of the members.
+	Pubkeys [][]byte
+}
+
+func (c *Committee) Validate() (error) {
+	n := len(c.Pubkeys)
+	if n == 0 {
+		return fmt.Errorf("committee has no members")
+	}
+	if c.Threshold < 1 || n < c.Threshold {
+		return fmt.Errorf("committee threshold must be between 1 and %d, got %d", n, c.Threshold)
+	}
+	// Two disjoint sets of signers could sign conflicting blocks without anyone equivocating.
+	if 2 * c.Threshold <= n {
+		return fmt.Errorf("committee threshold must be a majority, got %d-of-%d", c.Threshold, n)
+	}
+
+	for i, pubkey := range c.Pubkeys {
+		_, err := crypto.UnmarshalPubkey(pubkey)
+		if err != nil {
+			return fmt.Errorf("committee member %d has invalid pubkey: %s", i, err)
+		}
+		if c.memberIndex(pubkey) != i {
+			return fmt.Errorf("committee member %s is listed twice", hexutil.Encode(pubkey))
+		}
+	}
+
+	return nil
+}
+
+// Returns true if `pubkey` is a member of the committee.
+func (c *Committee) IsMember(pubkey []byte) (bool) {
+	return c.memberIndex(pubkey) != -1
+}
+
+func (c *Committee) memberIndex(pubkey []byte) (int) {
+	for i, member := range c.Pubkeys {
+		if bytes.Equal(member, pubkey) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Verifies the block was co-signed by at least `Threshold` distinct members.
+func (c *Committee) VerifyBlock(block *Block) (error) {
+	return c.Verify(block.SigHash(), block.Sigs)
+}
+
+// Verifies `sigs` over `digestHash` are from at least `Threshold` distinct members.
+func (c *Committee) Verify(digestHash []byte, sigs [][]byte) (error) {
+	signed := make(map[int]bool)
+
+	for _, sig := range sigs {
+		if len(sig) != crypto.SignatureLength {
+			return fmt.Errorf("invalid signature")
+		}
+
+		pubkey, err := crypto.Ecrecover(digestHash, sig)
+		if err != nil {
+			return fmt.Errorf("invalid signature")
+		}
+		// Remove recovery id (last byte) from signature.
+		if !crypto.VerifySignature(pubkey, digestHash, sig[:len(sig)-1]) {
+			return fmt.Errorf("invalid signature")
+		}
+
+		i := c.memberIndex(pubkey)
+		if i == -1 {
+			return fmt.Errorf("signed by non-member %s", hexutil.Encode(pubkey))
+		}
+		if signed[i] {
+			return fmt.Errorf("signed twice by member %s", hexutil.Encode(pubkey))
+		}
+		signed[i] = true
+	}
+
+	if len(signed) < c.Threshold {
+		return fmt.Errorf("only %d of %d required committee signatures", len(signed), c.Threshold)
+	}
+	return nil
+}
+
+func (c *Committee) String() (string) {
+	return fmt.Sprintf("%d-of-%d committee", c.Threshold, len(c.Pubkeys))
+}
@@ -33,6 +33,9 @@ type Block struct {
 	Body          *SequenceTx `protobuf:"bytes,2,opt,name=body,proto3" json:"body,omitempty"`
 	Sig           []byte      `protobuf:"bytes,3,opt,name=sig,proto3" json:"sig,omitempty"`
 	Height        int64       `protobuf:"varint,4,opt,name=height,proto3" json:"height,omitempty"`
+	// Signatures from a committee of operators, when the chain is run by a k-of-n
+	// committee rather than a single operator. See Committee.
+	Sigs [][]byte `protobuf:"bytes,5,rep,name=sigs,proto3" json:"sigs,omitempty"`
 }
 
 func (x *Block) Reset() {
@@ -95,6 +98,95 @@ func (x *Block) GetHeight() int64 {
 	return 0
 }
 
+func (x *Block) GetSigs() [][]byte {
+	if x != nil {
+		return x.Sigs
+	}
+	return nil
+}
+
+// A block without its body. Pruned nodes keep headers for blocks whose bodies they've
+// deleted. A body from cold storage can be checked against `hash`.
+type BlockHeader struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Height        int64  `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
+	PrevBlockHash []byte `protobuf:"bytes,2,opt,name=prev_block_hash,json=prevBlockHash,proto3" json:"prev_block_hash,omitempty"`
+	// Hash of the full block.
+	Hash []byte   `protobuf:"bytes,3,opt,name=hash,proto3" json:"hash,omitempty"`
+	Sig  []byte   `protobuf:"bytes,4,opt,name=sig,proto3" json:"sig,omitempty"`
+	Sigs [][]byte `protobuf:"bytes,5,rep,name=sigs,proto3" json:"sigs,omitempty"`
+}
+
+func (x *BlockHeader) Reset() {
+	*x = BlockHeader{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sequencer_messages_defs_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BlockHeader) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlockHeader) ProtoMessage() {}
+
+func (x *BlockHeader) ProtoReflect() protoreflect.Message {
+	mi := &file_sequencer_messages_defs_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BlockHeader.ProtoReflect.Descriptor instead.
+func (*BlockHeader) Descriptor() ([]byte, []int) {
+	return file_sequencer_messages_defs_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *BlockHeader) GetHeight() int64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *BlockHeader) GetPrevBlockHash() []byte {
+	if x != nil {
+		return x.PrevBlockHash
+	}
+	return nil
+}
+
+func (x *BlockHeader) GetHash() []byte {
+	if x != nil {
+		return x.Hash
+	}
+	return nil
+}
+
+func (x *BlockHeader) GetSig() []byte {
+	if x != nil {
+		return x.Sig
+	}
+	return nil
+}
+
+func (x *BlockHeader) GetSigs() [][]byte {
+	if x != nil {
+		return x.Sigs
+	}
+	return nil
+}
+
 type SequenceTx struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -105,15 +197,15 @@ type SequenceTx struct {
 	Data        []byte             `protobuf:"bytes,4,opt,name=data,proto3" json:"data,omitempty"`
 	Sig         []byte             `protobuf:"bytes,5,opt,name=sig,proto3" json:"sig,omitempty"`
 	Nonce       []byte             `protobuf:"bytes,6,opt,name=nonce,proto3" json:"nonce,omitempty"`
-	StateReads  []byte             `protobuf:"bytes,7,opt,name=state_reads,json=stateReads,proto3" json:"state_reads,omitempty"`
-	StateWrites []byte             `protobuf:"bytes,8,opt,name=state_writes,json=stateWrites,proto3" json:"state_writes,omitempty"`
+	StateReads  *AccessList        `protobuf:"bytes,9,opt,name=state_reads,json=stateReads,proto3" json:"state_reads,omitempty"`
+	StateWrites *AccessList        `protobuf:"bytes,10,opt,name=state_writes,json=stateWrites,proto3" json:"state_writes,omitempty"`
 	Expires     []*ExpiryCondition `protobuf:"bytes,20,rep,name=expires,proto3" json:"expires,omitempty"`
 }
 
 func (x *SequenceTx) Reset() {
 	*x = SequenceTx{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sequencer_messages_defs_proto_msgTypes[1]
+		mi := &file_sequencer_messages_defs_proto_msgTypes[2]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -126,7 +218,7 @@ func (x *SequenceTx) String() string {
 func (*SequenceTx) ProtoMessage() {}
 
 func (x *SequenceTx) ProtoReflect() protoreflect.Message {
-	mi := &file_sequencer_messages_defs_proto_msgTypes[1]
+	mi := &file_sequencer_messages_defs_proto_msgTypes[2]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -139,7 +231,7 @@ func (x *SequenceTx) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SequenceTx.ProtoReflect.Descriptor instead.
 func (*SequenceTx) Descriptor() ([]byte, []int) {
-	return file_sequencer_messages_defs_proto_rawDescGZIP(), []int{1}
+	return file_sequencer_messages_defs_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *SequenceTx) GetFrom() []byte {
@@ -177,14 +269,14 @@ func (x *SequenceTx) GetNonce() []byte {
 	return nil
 }
 
-func (x *SequenceTx) GetStateReads() []byte {
+func (x *SequenceTx) GetStateReads() *AccessList {
 	if x != nil {
 		return x.StateReads
 	}
 	return nil
 }
 
-func (x *SequenceTx) GetStateWrites() []byte {
+func (x *SequenceTx) GetStateWrites() *AccessList {
 	if x != nil {
 		return x.StateWrites
 	}
@@ -198,33 +290,34 @@ func (x *SequenceTx) GetExpires() []*ExpiryCondition {
 	return nil
 }
 
-type ExpiryCondition struct {
+// The accounts and storage slots a tx touches.
+// Used by the scheduler to build a dependency graph for parallel execution.
+type AccessList struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	// Types that are assignable to Condition:
-	//	*ExpiryCondition_Unix
-	Condition isExpiryCondition_Condition `protobuf_oneof:"condition"`
+	Accounts []*AccountAccess     `protobuf:"bytes,1,rep,name=accounts,proto3" json:"accounts,omitempty"`
+	Storage  []*StorageSlotAccess `protobuf:"bytes,2,rep,name=storage,proto3" json:"storage,omitempty"`
 }
 
-func (x *ExpiryCondition) Reset() {
-	*x = ExpiryCondition{}
+func (x *AccessList) Reset() {
+	*x = AccessList{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sequencer_messages_defs_proto_msgTypes[2]
+		mi := &file_sequencer_messages_defs_proto_msgTypes[3]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *ExpiryCondition) String() string {
+func (x *AccessList) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ExpiryCondition) ProtoMessage() {}
+func (*AccessList) ProtoMessage() {}
 
-func (x *ExpiryCondition) ProtoReflect() protoreflect.Message {
-	mi := &file_sequencer_messages_defs_proto_msgTypes[2]
+func (x *AccessList) ProtoReflect() protoreflect.Message {
+	mi := &file_sequencer_messages_defs_proto_msgTypes[3]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -235,60 +328,50 @@ func (x *ExpiryCondition) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ExpiryCondition.ProtoReflect.Descriptor instead.
-func (*ExpiryCondition) Descriptor() ([]byte, []int) {
-	return file_sequencer_messages_defs_proto_rawDescGZIP(), []int{2}
+// Deprecated: Use AccessList.ProtoReflect.Descriptor instead.
+func (*AccessList) Descriptor() ([]byte, []int) {
+	return file_sequencer_messages_defs_proto_rawDescGZIP(), []int{3}
 }
 
-func (m *ExpiryCondition) GetCondition() isExpiryCondition_Condition {
-	if m != nil {
-		return m.Condition
+func (x *AccessList) GetAccounts() []*AccountAccess {
+	if x != nil {
+		return x.Accounts
 	}
 	return nil
 }
 
-func (x *ExpiryCondition) GetUnix() *UNIXExpiryCondition {
-	if x, ok := x.GetCondition().(*ExpiryCondition_Unix); ok {
-		return x.Unix
+func (x *AccessList) GetStorage() []*StorageSlotAccess {
+	if x != nil {
+		return x.Storage
 	}
 	return nil
 }
 
-type isExpiryCondition_Condition interface {
-	isExpiryCondition_Condition()
-}
-
-type ExpiryCondition_Unix struct {
-	Unix *UNIXExpiryCondition `protobuf:"bytes,1,opt,name=unix,proto3,oneof"`
-}
-
-func (*ExpiryCondition_Unix) isExpiryCondition_Condition() {}
-
-type UNIXExpiryCondition struct {
+type AccountAccess struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Time uint64 `protobuf:"varint,1,opt,name=time,proto3" json:"time,omitempty"`
+	Address []byte `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
 }
 
-func (x *UNIXExpiryCondition) Reset() {
-	*x = UNIXExpiryCondition{}
+func (x *AccountAccess) Reset() {
+	*x = AccountAccess{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sequencer_messages_defs_proto_msgTypes[3]
+		mi := &file_sequencer_messages_defs_proto_msgTypes[4]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *UNIXExpiryCondition) String() string {
+func (x *AccountAccess) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UNIXExpiryCondition) ProtoMessage() {}
+func (*AccountAccess) ProtoMessage() {}
 
-func (x *UNIXExpiryCondition) ProtoReflect() protoreflect.Message {
-	mi := &file_sequencer_messages_defs_proto_msgTypes[3]
+func (x *AccountAccess) ProtoReflect() protoreflect.Message {
+	mi := &file_sequencer_messages_defs_proto_msgTypes[4]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -299,45 +382,44 @@ func (x *UNIXExpiryCondition) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use UNIXExpiryCondition.ProtoReflect.Descriptor instead.
-func (*UNIXExpiryCondition) Descriptor() ([]byte, []int) {
-	return file_sequencer_messages_defs_proto_rawDescGZIP(), []int{3}
+// Deprecated: Use AccountAccess.ProtoReflect.Descriptor instead.
+func (*AccountAccess) Descriptor() ([]byte, []int) {
+	return file_sequencer_messages_defs_proto_rawDescGZIP(), []int{4}
 }
 
-func (x *UNIXExpiryCondition) GetTime() uint64 {
+func (x *AccountAccess) GetAddress() []byte {
 	if x != nil {
-		return x.Time
+		return x.Address
 	}
-	return 0
+	return nil
 }
 
-type GetTransactions struct {
+type StorageSlotAccess struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	From uint64        `protobuf:"varint,1,opt,name=from,proto3" json:"from,omitempty"`
-	To   uint64        `protobuf:"varint,2,opt,name=to,proto3" json:"to,omitempty"`
-	Txs  []*SequenceTx `protobuf:"bytes,3,rep,name=txs,proto3" json:"txs,omitempty"`
+	Address []byte `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Slot    []byte `protobuf:"bytes,2,opt,name=slot,proto3" json:"slot,omitempty"`
 }
 
-func (x *GetTransactions) Reset() {
-	*x = GetTransactions{}
+func (x *StorageSlotAccess) Reset() {
+	*x = StorageSlotAccess{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sequencer_messages_defs_proto_msgTypes[4]
+		mi := &file_sequencer_messages_defs_proto_msgTypes[5]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *GetTransactions) String() string {
+func (x *StorageSlotAccess) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetTransactions) ProtoMessage() {}
+func (*StorageSlotAccess) ProtoMessage() {}
 
-func (x *GetTransactions) ProtoReflect() protoreflect.Message {
-	mi := &file_sequencer_messages_defs_proto_msgTypes[4]
+func (x *StorageSlotAccess) ProtoReflect() protoreflect.Message {
+	mi := &file_sequencer_messages_defs_proto_msgTypes[5]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -348,57 +430,54 @@ func (x *GetTransactions) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetTransactions.ProtoReflect.Descriptor instead.
-func (*GetTransactions) Descriptor() ([]byte, []int) {
-	return file_sequencer_messages_defs_proto_rawDescGZIP(), []int{4}
-}
-
-func (x *GetTransactions) GetFrom() uint64 {
-	if x != nil {
-		return x.From
-	}
-	return 0
+// Deprecated: Use StorageSlotAccess.ProtoReflect.Descriptor instead.
+func (*StorageSlotAccess) Descriptor() ([]byte, []int) {
+	return file_sequencer_messages_defs_proto_rawDescGZIP(), []int{5}
 }
 
-func (x *GetTransactions) GetTo() uint64 {
+func (x *StorageSlotAccess) GetAddress() []byte {
 	if x != nil {
-		return x.To
+		return x.Address
 	}
-	return 0
+	return nil
 }
 
-func (x *GetTransactions) GetTxs() []*SequenceTx {
+func (x *StorageSlotAccess) GetSlot() []byte {
 	if x != nil {
-		return x.Txs
+		return x.Slot
 	}
 	return nil
 }
 
-type GetSequencerInfo struct {
+type ExpiryCondition struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Count uint64 `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	// Types that are assignable to Condition:
+	//	*ExpiryCondition_Unix
+	//	*ExpiryCondition_Height
+	//	*ExpiryCondition_Sequence
+	Condition isExpiryCondition_Condition `protobuf_oneof:"condition"`
 }
 
-func (x *GetSequencerInfo) Reset() {
-	*x = GetSequencerInfo{}
+func (x *ExpiryCondition) Reset() {
+	*x = ExpiryCondition{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sequencer_messages_defs_proto_msgTypes[5]
+		mi := &file_sequencer_messages_defs_proto_msgTypes[6]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *GetSequencerInfo) String() string {
+func (x *ExpiryCondition) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetSequencerInfo) ProtoMessage() {}
+func (*ExpiryCondition) ProtoMessage() {}
 
-func (x *GetSequencerInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_sequencer_messages_defs_proto_msgTypes[5]
+func (x *ExpiryCondition) ProtoReflect() protoreflect.Message {
+	mi := &file_sequencer_messages_defs_proto_msgTypes[6]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -409,43 +488,86 @@ func (x *GetSequencerInfo) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetSequencerInfo.ProtoReflect.Descriptor instead.
-func (*GetSequencerInfo) Descriptor() ([]byte, []int) {
-	return file_sequencer_messages_defs_proto_rawDescGZIP(), []int{5}
+// Deprecated: Use ExpiryCondition.ProtoReflect.Descriptor instead.
+func (*ExpiryCondition) Descriptor() ([]byte, []int) {
+	return file_sequencer_messages_defs_proto_rawDescGZIP(), []int{6}
 }
 
-func (x *GetSequencerInfo) GetCount() uint64 {
-	if x != nil {
-		return x.Count
+func (m *ExpiryCondition) GetCondition() isExpiryCondition_Condition {
+	if m != nil {
+		return m.Condition
 	}
-	return 0
+	return nil
 }
 
-type SequencerPrimaryAdvertisement struct {
+func (x *ExpiryCondition) GetUnix() *UNIXExpiryCondition {
+	if x, ok := x.GetCondition().(*ExpiryCondition_Unix); ok {
+		return x.Unix
+	}
+	return nil
+}
+
+func (x *ExpiryCondition) GetHeight() *HeightExpiryCondition {
+	if x, ok := x.GetCondition().(*ExpiryCondition_Height); ok {
+		return x.Height
+	}
+	return nil
+}
+
+func (x *ExpiryCondition) GetSequence() *SequenceExpiryCondition {
+	if x, ok := x.GetCondition().(*ExpiryCondition_Sequence); ok {
+		return x.Sequence
+	}
+	return nil
+}
+
+type isExpiryCondition_Condition interface {
+	isExpiryCondition_Condition()
+}
+
+type ExpiryCondition_Unix struct {
+	Unix *UNIXExpiryCondition `protobuf:"bytes,1,opt,name=unix,proto3,oneof"`
+}
+
+type ExpiryCondition_Height struct {
+	Height *HeightExpiryCondition `protobuf:"bytes,2,opt,name=height,proto3,oneof"`
+}
+
+type ExpiryCondition_Sequence struct {
+	Sequence *SequenceExpiryCondition `protobuf:"bytes,3,opt,name=sequence,proto3,oneof"`
+}
+
+func (*ExpiryCondition_Unix) isExpiryCondition_Condition() {}
+
+func (*ExpiryCondition_Height) isExpiryCondition_Condition() {}
+
+func (*ExpiryCondition_Sequence) isExpiryCondition_Condition() {}
+
+type UNIXExpiryCondition struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Multiaddress []byte `protobuf:"bytes,1,opt,name=multiaddress,proto3" json:"multiaddress,omitempty"`
+	Time uint64 `protobuf:"varint,1,opt,name=time,proto3" json:"time,omitempty"`
 }
 
-func (x *SequencerPrimaryAdvertisement) Reset() {
-	*x = SequencerPrimaryAdvertisement{}
+func (x *UNIXExpiryCondition) Reset() {
+	*x = UNIXExpiryCondition{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sequencer_messages_defs_proto_msgTypes[6]
+		mi := &file_sequencer_messages_defs_proto_msgTypes[7]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *SequencerPrimaryAdvertisement) String() string {
+func (x *UNIXExpiryCondition) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SequencerPrimaryAdvertisement) ProtoMessage() {}
+func (*UNIXExpiryCondition) ProtoMessage() {}
 
-func (x *SequencerPrimaryAdvertisement) ProtoReflect() protoreflect.Message {
-	mi := &file_sequencer_messages_defs_proto_msgTypes[6]
+func (x *UNIXExpiryCondition) ProtoReflect() protoreflect.Message {
+	mi := &file_sequencer_messages_defs_proto_msgTypes[7]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -456,43 +578,1246 @@ func (x *SequencerPrimaryAdvertisement) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SequencerPrimaryAdvertisement.ProtoReflect.Descriptor instead.
-func (*SequencerPrimaryAdvertisement) Descriptor() ([]byte, []int) {
-	return file_sequencer_messages_defs_proto_rawDescGZIP(), []int{6}
+// Deprecated: Use UNIXExpiryCondition.ProtoReflect.Descriptor instead.
+func (*UNIXExpiryCondition) Descriptor() ([]byte, []int) {
+	return file_sequencer_messages_defs_proto_rawDescGZIP(), []int{7}
 }
 
-func (x *SequencerPrimaryAdvertisement) GetMultiaddress() []byte {
+func (x *UNIXExpiryCondition) GetTime() uint64 {
 	if x != nil {
-		return x.Multiaddress
+		return x.Time
 	}
-	return nil
+	return 0
 }
 
-type P2PMessage struct {
+// The tx cannot be included in a block above this height.
+type HeightExpiryCondition struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Block *Block `protobuf:"bytes,1,opt,name=block,proto3" json:"block,omitempty"`
+	Height int64 `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
 }
 
-func (x *P2PMessage) Reset() {
-	*x = P2PMessage{}
+func (x *HeightExpiryCondition) Reset() {
+	*x = HeightExpiryCondition{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sequencer_messages_defs_proto_msgTypes[7]
+		mi := &file_sequencer_messages_defs_proto_msgTypes[8]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *P2PMessage) String() string {
+func (x *HeightExpiryCondition) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*P2PMessage) ProtoMessage() {}
+func (*HeightExpiryCondition) ProtoMessage() {}
 
-func (x *P2PMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_sequencer_messages_defs_proto_msgTypes[7]
+func (x *HeightExpiryCondition) ProtoReflect() protoreflect.Message {
+	mi := &file_sequencer_messages_defs_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeightExpiryCondition.ProtoReflect.Descriptor instead.
+func (*HeightExpiryCondition) Descriptor() ([]byte, []int) {
+	return file_sequencer_messages_defs_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *HeightExpiryCondition) GetHeight() int64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+// The tx cannot be assigned a sequence number above this one.
+type SequenceExpiryCondition struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Sequence uint64 `protobuf:"varint,1,opt,name=sequence,proto3" json:"sequence,omitempty"`
+}
+
+func (x *SequenceExpiryCondition) Reset() {
+	*x = SequenceExpiryCondition{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sequencer_messages_defs_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SequenceExpiryCondition) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SequenceExpiryCondition) ProtoMessage() {}
+
+func (x *SequenceExpiryCondition) ProtoReflect() protoreflect.Message {
+	mi := &file_sequencer_messages_defs_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SequenceExpiryCondition.ProtoReflect.Descriptor instead.
+func (*SequenceExpiryCondition) Descriptor() ([]byte, []int) {
+	return file_sequencer_messages_defs_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *SequenceExpiryCondition) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+type GetTransactions struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	From uint64        `protobuf:"varint,1,opt,name=from,proto3" json:"from,omitempty"`
+	To   uint64        `protobuf:"varint,2,opt,name=to,proto3" json:"to,omitempty"`
+	Txs  []*SequenceTx `protobuf:"bytes,3,rep,name=txs,proto3" json:"txs,omitempty"`
+}
+
+func (x *GetTransactions) Reset() {
+	*x = GetTransactions{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sequencer_messages_defs_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetTransactions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTransactions) ProtoMessage() {}
+
+func (x *GetTransactions) ProtoReflect() protoreflect.Message {
+	mi := &file_sequencer_messages_defs_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTransactions.ProtoReflect.Descriptor instead.
+func (*GetTransactions) Descriptor() ([]byte, []int) {
+	return file_sequencer_messages_defs_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *GetTransactions) GetFrom() uint64 {
+	if x != nil {
+		return x.From
+	}
+	return 0
+}
+
+func (x *GetTransactions) GetTo() uint64 {
+	if x != nil {
+		return x.To
+	}
+	return 0
+}
+
+func (x *GetTransactions) GetTxs() []*SequenceTx {
+	if x != nil {
+		return x.Txs
+	}
+	return nil
+}
+
+type GetSequencerInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Count uint64 `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+}
+
+func (x *GetSequencerInfo) Reset() {
+	*x = GetSequencerInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sequencer_messages_defs_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetSequencerInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSequencerInfo) ProtoMessage() {}
+
+func (x *GetSequencerInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_sequencer_messages_defs_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSequencerInfo.ProtoReflect.Descriptor instead.
+func (*GetSequencerInfo) Descriptor() ([]byte, []int) {
+	return file_sequencer_messages_defs_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetSequencerInfo) GetCount() uint64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+// The primary's current addresses, signed by the operator. The primary gossips
+// this periodically, so replicas can find it again if it moves.
+type SequencerPrimaryAdvertisement struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The primary's multiaddrs, in binary form.
+	Multiaddress [][]byte `protobuf:"bytes,1,rep,name=multiaddress,proto3" json:"multiaddress,omitempty"`
+	// The primary's peer ID, in binary form.
+	PeerId []byte `protobuf:"bytes,2,opt,name=peer_id,json=peerId,proto3" json:"peer_id,omitempty"`
+	// Unix time in milliseconds. Replicas only accept newer advertisements.
+	Timestamp int64  `protobuf:"varint,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Sig       []byte `protobuf:"bytes,4,opt,name=sig,proto3" json:"sig,omitempty"`
+	// Signatures from the operator committee, if the operator is a committee.
+	Sigs [][]byte `protobuf:"bytes,5,rep,name=sigs,proto3" json:"sigs,omitempty"`
+}
+
+func (x *SequencerPrimaryAdvertisement) Reset() {
+	*x = SequencerPrimaryAdvertisement{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sequencer_messages_defs_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SequencerPrimaryAdvertisement) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SequencerPrimaryAdvertisement) ProtoMessage() {}
+
+func (x *SequencerPrimaryAdvertisement) ProtoReflect() protoreflect.Message {
+	mi := &file_sequencer_messages_defs_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SequencerPrimaryAdvertisement.ProtoReflect.Descriptor instead.
+func (*SequencerPrimaryAdvertisement) Descriptor() ([]byte, []int) {
+	return file_sequencer_messages_defs_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *SequencerPrimaryAdvertisement) GetMultiaddress() [][]byte {
+	if x != nil {
+		return x.Multiaddress
+	}
+	return nil
+}
+
+func (x *SequencerPrimaryAdvertisement) GetPeerId() []byte {
+	if x != nil {
+		return x.PeerId
+	}
+	return nil
+}
+
+func (x *SequencerPrimaryAdvertisement) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *SequencerPrimaryAdvertisement) GetSig() []byte {
+	if x != nil {
+		return x.Sig
+	}
+	return nil
+}
+
+func (x *SequencerPrimaryAdvertisement) GetSigs() [][]byte {
+	if x != nil {
+		return x.Sigs
+	}
+	return nil
+}
+
+// Exchanged when nodes connect. Peers on another chain, or on an incompatible
+// protocol version, are disconnected.
+type Handshake struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ChainId string `protobuf:"bytes,1,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	// Hash of the chain's genesis. Empty if the node has no genesis.
+	GenesisHash     []byte `protobuf:"bytes,2,opt,name=genesis_hash,json=genesisHash,proto3" json:"genesis_hash,omitempty"`
+	ProtocolVersion uint32 `protobuf:"varint,3,opt,name=protocol_version,json=protocolVersion,proto3" json:"protocol_version,omitempty"`
+	// The oldest protocol version the node can talk to.
+	MinProtocolVersion uint32 `protobuf:"varint,4,opt,name=min_protocol_version,json=minProtocolVersion,proto3" json:"min_protocol_version,omitempty"`
+	// "primary" or "replica".
+	Mode   string `protobuf:"bytes,5,opt,name=mode,proto3" json:"mode,omitempty"`
+	Height int64  `protobuf:"varint,6,opt,name=height,proto3" json:"height,omitempty"`
+	// Optional protocols the node runs, eg. "relaytree".
+	Capabilities []string `protobuf:"bytes,7,rep,name=capabilities,proto3" json:"capabilities,omitempty"`
+}
+
+func (x *Handshake) Reset() {
+	*x = Handshake{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sequencer_messages_defs_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Handshake) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Handshake) ProtoMessage() {}
+
+func (x *Handshake) ProtoReflect() protoreflect.Message {
+	mi := &file_sequencer_messages_defs_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Handshake.ProtoReflect.Descriptor instead.
+func (*Handshake) Descriptor() ([]byte, []int) {
+	return file_sequencer_messages_defs_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *Handshake) GetChainId() string {
+	if x != nil {
+		return x.ChainId
+	}
+	return ""
+}
+
+func (x *Handshake) GetGenesisHash() []byte {
+	if x != nil {
+		return x.GenesisHash
+	}
+	return nil
+}
+
+func (x *Handshake) GetProtocolVersion() uint32 {
+	if x != nil {
+		return x.ProtocolVersion
+	}
+	return 0
+}
+
+func (x *Handshake) GetMinProtocolVersion() uint32 {
+	if x != nil {
+		return x.MinProtocolVersion
+	}
+	return 0
+}
+
+func (x *Handshake) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *Handshake) GetHeight() int64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *Handshake) GetCapabilities() []string {
+	if x != nil {
+		return x.Capabilities
+	}
+	return nil
+}
+
+type P2PMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Block *Block `protobuf:"bytes,1,opt,name=block,proto3" json:"block,omitempty"`
+	// The first message on a relay stream.
+	Request *RelayRequest `protobuf:"bytes,2,opt,name=request,proto3" json:"request,omitempty"`
+	Status  *RelayStatus  `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+}
+
+func (x *P2PMessage) Reset() {
+	*x = P2PMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sequencer_messages_defs_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *P2PMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*P2PMessage) ProtoMessage() {}
+
+func (x *P2PMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_sequencer_messages_defs_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use P2PMessage.ProtoReflect.Descriptor instead.
+func (*P2PMessage) Descriptor() ([]byte, []int) {
+	return file_sequencer_messages_defs_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *P2PMessage) GetBlock() *Block {
+	if x != nil {
+		return x.Block
+	}
+	return nil
+}
+
+func (x *P2PMessage) GetRequest() *RelayRequest {
+	if x != nil {
+		return x.Request
+	}
+	return nil
+}
+
+func (x *P2PMessage) GetStatus() *RelayStatus {
+	if x != nil {
+		return x.Status
+	}
+	return nil
+}
+
+type RelayRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Asks to become a child of the peer, which then streams blocks on the stream.
+	// Otherwise the peer replies with its status and closes the stream.
+	Attach bool `protobuf:"varint,1,opt,name=attach,proto3" json:"attach,omitempty"`
+}
+
+func (x *RelayRequest) Reset() {
+	*x = RelayRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sequencer_messages_defs_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RelayRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RelayRequest) ProtoMessage() {}
+
+func (x *RelayRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sequencer_messages_defs_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RelayRequest.ProtoReflect.Descriptor instead.
+func (*RelayRequest) Descriptor() ([]byte, []int) {
+	return file_sequencer_messages_defs_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *RelayRequest) GetAttach() bool {
+	if x != nil {
+		return x.Attach
+	}
+	return false
+}
+
+// A node's position in the relay tree.
+type RelayStatus struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Number of children the node can accept.
+	FreeSlots int64 `protobuf:"varint,1,opt,name=free_slots,json=freeSlots,proto3" json:"free_slots,omitempty"`
+	// Peer IDs from the primary to the node, inclusive. Empty if the node isn't in the tree.
+	Path [][]byte `protobuf:"bytes,2,rep,name=path,proto3" json:"path,omitempty"`
+	// Set in reply to an attach request, if the node accepted the child.
+	Attached bool `protobuf:"varint,3,opt,name=attached,proto3" json:"attached,omitempty"`
+}
+
+func (x *RelayStatus) Reset() {
+	*x = RelayStatus{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sequencer_messages_defs_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RelayStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RelayStatus) ProtoMessage() {}
+
+func (x *RelayStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_sequencer_messages_defs_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RelayStatus.ProtoReflect.Descriptor instead.
+func (*RelayStatus) Descriptor() ([]byte, []int) {
+	return file_sequencer_messages_defs_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *RelayStatus) GetFreeSlots() int64 {
+	if x != nil {
+		return x.FreeSlots
+	}
+	return 0
+}
+
+func (x *RelayStatus) GetPath() [][]byte {
+	if x != nil {
+		return x.Path
+	}
+	return nil
+}
+
+func (x *RelayStatus) GetAttached() bool {
+	if x != nil {
+		return x.Attached
+	}
+	return false
+}
+
+// A checkpoint commits to the chain at a height, so new replicas can sync from it
+// instead of from genesis. Signed by the operator.
+type Checkpoint struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Height int64 `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
+	// Hash of the block at `height`.
+	TipHash []byte `protobuf:"bytes,2,opt,name=tip_hash,json=tipHash,proto3" json:"tip_hash,omitempty"`
+	// Root of the accumulator over the hashes of blocks 1 to `height`.
+	AccumulatorRoot []byte `protobuf:"bytes,3,opt,name=accumulator_root,json=accumulatorRoot,proto3" json:"accumulator_root,omitempty"`
+	// Peaks of the accumulator, which hash to the root. Replicas extend the
+	// accumulator from them.
+	AccumulatorPeaks [][]byte `protobuf:"bytes,4,rep,name=accumulator_peaks,json=accumulatorPeaks,proto3" json:"accumulator_peaks,omitempty"`
+	Sig              []byte   `protobuf:"bytes,5,opt,name=sig,proto3" json:"sig,omitempty"`
+	// Signatures from the operator committee, if the operator is a committee.
+	Sigs [][]byte `protobuf:"bytes,6,rep,name=sigs,proto3" json:"sigs,omitempty"`
+}
+
+func (x *Checkpoint) Reset() {
+	*x = Checkpoint{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sequencer_messages_defs_proto_msgTypes[17]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Checkpoint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Checkpoint) ProtoMessage() {}
+
+func (x *Checkpoint) ProtoReflect() protoreflect.Message {
+	mi := &file_sequencer_messages_defs_proto_msgTypes[17]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Checkpoint.ProtoReflect.Descriptor instead.
+func (*Checkpoint) Descriptor() ([]byte, []int) {
+	return file_sequencer_messages_defs_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *Checkpoint) GetHeight() int64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *Checkpoint) GetTipHash() []byte {
+	if x != nil {
+		return x.TipHash
+	}
+	return nil
+}
+
+func (x *Checkpoint) GetAccumulatorRoot() []byte {
+	if x != nil {
+		return x.AccumulatorRoot
+	}
+	return nil
+}
+
+func (x *Checkpoint) GetAccumulatorPeaks() [][]byte {
+	if x != nil {
+		return x.AccumulatorPeaks
+	}
+	return nil
+}
+
+func (x *Checkpoint) GetSig() []byte {
+	if x != nil {
+		return x.Sig
+	}
+	return nil
+}
+
+func (x *Checkpoint) GetSigs() [][]byte {
+	if x != nil {
+		return x.Sigs
+	}
+	return nil
+}
+
+type SyncRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Request:
+	//	*SyncRequest_GetBlocks
+	//	*SyncRequest_GetCheckpoint
+	Request isSyncRequest_Request `protobuf_oneof:"request"`
+}
+
+func (x *SyncRequest) Reset() {
+	*x = SyncRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sequencer_messages_defs_proto_msgTypes[18]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SyncRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SyncRequest) ProtoMessage() {}
+
+func (x *SyncRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sequencer_messages_defs_proto_msgTypes[18]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SyncRequest.ProtoReflect.Descriptor instead.
+func (*SyncRequest) Descriptor() ([]byte, []int) {
+	return file_sequencer_messages_defs_proto_rawDescGZIP(), []int{18}
+}
+
+func (m *SyncRequest) GetRequest() isSyncRequest_Request {
+	if m != nil {
+		return m.Request
+	}
+	return nil
+}
+
+func (x *SyncRequest) GetGetBlocks() *GetBlocksRequest {
+	if x, ok := x.GetRequest().(*SyncRequest_GetBlocks); ok {
+		return x.GetBlocks
+	}
+	return nil
+}
+
+func (x *SyncRequest) GetGetCheckpoint() *GetCheckpointRequest {
+	if x, ok := x.GetRequest().(*SyncRequest_GetCheckpoint); ok {
+		return x.GetCheckpoint
+	}
+	return nil
+}
+
+type isSyncRequest_Request interface {
+	isSyncRequest_Request()
+}
+
+type SyncRequest_GetBlocks struct {
+	GetBlocks *GetBlocksRequest `protobuf:"bytes,1,opt,name=get_blocks,json=getBlocks,proto3,oneof"`
+}
+
+type SyncRequest_GetCheckpoint struct {
+	GetCheckpoint *GetCheckpointRequest `protobuf:"bytes,2,opt,name=get_checkpoint,json=getCheckpoint,proto3,oneof"`
+}
+
+func (*SyncRequest_GetBlocks) isSyncRequest_Request() {}
+
+func (*SyncRequest_GetCheckpoint) isSyncRequest_Request() {}
+
+// Blocks from `from_height` to `to_height`, inclusive.
+type GetBlocksRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FromHeight int64 `protobuf:"varint,1,opt,name=from_height,json=fromHeight,proto3" json:"from_height,omitempty"`
+	ToHeight   int64 `protobuf:"varint,2,opt,name=to_height,json=toHeight,proto3" json:"to_height,omitempty"`
+}
+
+func (x *GetBlocksRequest) Reset() {
+	*x = GetBlocksRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sequencer_messages_defs_proto_msgTypes[19]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetBlocksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBlocksRequest) ProtoMessage() {}
+
+func (x *GetBlocksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sequencer_messages_defs_proto_msgTypes[19]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBlocksRequest.ProtoReflect.Descriptor instead.
+func (*GetBlocksRequest) Descriptor() ([]byte, []int) {
+	return file_sequencer_messages_defs_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *GetBlocksRequest) GetFromHeight() int64 {
+	if x != nil {
+		return x.FromHeight
+	}
+	return 0
+}
+
+func (x *GetBlocksRequest) GetToHeight() int64 {
+	if x != nil {
+		return x.ToHeight
+	}
+	return 0
+}
+
+// The checkpoint at `height`, or the latest checkpoint if it's 0.
+type GetCheckpointRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Height int64 `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
+}
+
+func (x *GetCheckpointRequest) Reset() {
+	*x = GetCheckpointRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sequencer_messages_defs_proto_msgTypes[20]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetCheckpointRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCheckpointRequest) ProtoMessage() {}
+
+func (x *GetCheckpointRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sequencer_messages_defs_proto_msgTypes[20]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCheckpointRequest.ProtoReflect.Descriptor instead.
+func (*GetCheckpointRequest) Descriptor() ([]byte, []int) {
+	return file_sequencer_messages_defs_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *GetCheckpointRequest) GetHeight() int64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+type SyncResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Blocks     []*Block    `protobuf:"bytes,1,rep,name=blocks,proto3" json:"blocks,omitempty"`
+	Checkpoint *Checkpoint `protobuf:"bytes,2,opt,name=checkpoint,proto3" json:"checkpoint,omitempty"`
+	Error      string      `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	// The height of the peer's tip.
+	TipHeight int64 `protobuf:"varint,4,opt,name=tip_height,json=tipHeight,proto3" json:"tip_height,omitempty"`
+}
+
+func (x *SyncResponse) Reset() {
+	*x = SyncResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sequencer_messages_defs_proto_msgTypes[21]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SyncResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SyncResponse) ProtoMessage() {}
+
+func (x *SyncResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sequencer_messages_defs_proto_msgTypes[21]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SyncResponse.ProtoReflect.Descriptor instead.
+func (*SyncResponse) Descriptor() ([]byte, []int) {
+	return file_sequencer_messages_defs_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *SyncResponse) GetBlocks() []*Block {
+	if x != nil {
+		return x.Blocks
+	}
+	return nil
+}
+
+func (x *SyncResponse) GetCheckpoint() *Checkpoint {
+	if x != nil {
+		return x.Checkpoint
+	}
+	return nil
+}
+
+func (x *SyncResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *SyncResponse) GetTipHeight() int64 {
+	if x != nil {
+		return x.TipHeight
+	}
+	return 0
+}
+
+type AppendRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Tx *SequenceTx `protobuf:"bytes,1,opt,name=tx,proto3" json:"tx,omitempty"`
+}
+
+func (x *AppendRequest) Reset() {
+	*x = AppendRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sequencer_messages_defs_proto_msgTypes[22]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AppendRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AppendRequest) ProtoMessage() {}
+
+func (x *AppendRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sequencer_messages_defs_proto_msgTypes[22]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AppendRequest.ProtoReflect.Descriptor instead.
+func (*AppendRequest) Descriptor() ([]byte, []int) {
+	return file_sequencer_messages_defs_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *AppendRequest) GetTx() *SequenceTx {
+	if x != nil {
+		return x.Tx
+	}
+	return nil
+}
+
+type AppendResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Sequence int64 `protobuf:"varint,1,opt,name=sequence,proto3" json:"sequence,omitempty"`
+}
+
+func (x *AppendResponse) Reset() {
+	*x = AppendResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sequencer_messages_defs_proto_msgTypes[23]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AppendResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AppendResponse) ProtoMessage() {}
+
+func (x *AppendResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sequencer_messages_defs_proto_msgTypes[23]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AppendResponse.ProtoReflect.Descriptor instead.
+func (*AppendResponse) Descriptor() ([]byte, []int) {
+	return file_sequencer_messages_defs_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *AppendResponse) GetSequence() int64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+type GetRangeRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	From uint64 `protobuf:"varint,1,opt,name=from,proto3" json:"from,omitempty"`
+	To   uint64 `protobuf:"varint,2,opt,name=to,proto3" json:"to,omitempty"`
+}
+
+func (x *GetRangeRequest) Reset() {
+	*x = GetRangeRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sequencer_messages_defs_proto_msgTypes[24]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetRangeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRangeRequest) ProtoMessage() {}
+
+func (x *GetRangeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sequencer_messages_defs_proto_msgTypes[24]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRangeRequest.ProtoReflect.Descriptor instead.
+func (*GetRangeRequest) Descriptor() ([]byte, []int) {
+	return file_sequencer_messages_defs_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *GetRangeRequest) GetFrom() uint64 {
+	if x != nil {
+		return x.From
+	}
+	return 0
+}
+
+func (x *GetRangeRequest) GetTo() uint64 {
+	if x != nil {
+		return x.To
+	}
+	return 0
+}
+
+type GetBlockRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Height int64 `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
+}
+
+func (x *GetBlockRequest) Reset() {
+	*x = GetBlockRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sequencer_messages_defs_proto_msgTypes[25]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetBlockRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBlockRequest) ProtoMessage() {}
+
+func (x *GetBlockRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sequencer_messages_defs_proto_msgTypes[25]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBlockRequest.ProtoReflect.Descriptor instead.
+func (*GetBlockRequest) Descriptor() ([]byte, []int) {
+	return file_sequencer_messages_defs_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *GetBlockRequest) GetHeight() int64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+type GetTxRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Sequence uint64 `protobuf:"varint,1,opt,name=sequence,proto3" json:"sequence,omitempty"`
+}
+
+func (x *GetTxRequest) Reset() {
+	*x = GetTxRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sequencer_messages_defs_proto_msgTypes[26]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetTxRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTxRequest) ProtoMessage() {}
+
+func (x *GetTxRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sequencer_messages_defs_proto_msgTypes[26]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTxRequest.ProtoReflect.Descriptor instead.
+func (*GetTxRequest) Descriptor() ([]byte, []int) {
+	return file_sequencer_messages_defs_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *GetTxRequest) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+type InfoRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *InfoRequest) Reset() {
+	*x = InfoRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sequencer_messages_defs_proto_msgTypes[27]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *InfoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InfoRequest) ProtoMessage() {}
+
+func (x *InfoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sequencer_messages_defs_proto_msgTypes[27]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InfoRequest.ProtoReflect.Descriptor instead.
+func (*InfoRequest) Descriptor() ([]byte, []int) {
+	return file_sequencer_messages_defs_proto_rawDescGZIP(), []int{27}
+}
+
+type SubscribeRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FromHeight int64 `protobuf:"varint,1,opt,name=from_height,json=fromHeight,proto3" json:"from_height,omitempty"`
+}
+
+func (x *SubscribeRequest) Reset() {
+	*x = SubscribeRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sequencer_messages_defs_proto_msgTypes[28]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SubscribeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeRequest) ProtoMessage() {}
+
+func (x *SubscribeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sequencer_messages_defs_proto_msgTypes[28]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -503,16 +1828,16 @@ func (x *P2PMessage) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use P2PMessage.ProtoReflect.Descriptor instead.
-func (*P2PMessage) Descriptor() ([]byte, []int) {
-	return file_sequencer_messages_defs_proto_rawDescGZIP(), []int{7}
+// Deprecated: Use SubscribeRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeRequest) Descriptor() ([]byte, []int) {
+	return file_sequencer_messages_defs_proto_rawDescGZIP(), []int{28}
 }
 
-func (x *P2PMessage) GetBlock() *Block {
+func (x *SubscribeRequest) GetFromHeight() int64 {
 	if x != nil {
-		return x.Block
+		return x.FromHeight
 	}
-	return nil
+	return 0
 }
 
 var File_sequencer_messages_defs_proto protoreflect.FileDescriptor
@@ -520,56 +1845,206 @@ var File_sequencer_messages_defs_proto protoreflect.FileDescriptor
 var file_sequencer_messages_defs_proto_rawDesc = []byte{
 	0x0a, 0x1d, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x72, 0x2f, 0x6d, 0x65, 0x73, 0x73,
 	0x61, 0x67, 0x65, 0x73, 0x2f, 0x64, 0x65, 0x66, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22,
-	0x78, 0x0a, 0x05, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x24, 0x0a, 0x0d, 0x70, 0x72, 0x65, 0x76,
-	0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52,
-	0x0d, 0x70, 0x72, 0x65, 0x76, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73, 0x68, 0x12, 0x1f,
-	0x0a, 0x04, 0x62, 0x6f, 0x64, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x53,
-	0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x52, 0x04, 0x62, 0x6f, 0x64, 0x79, 0x12,
-	0x10, 0x0a, 0x03, 0x73, 0x69, 0x67, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x73, 0x69,
-	0x67, 0x12, 0x16, 0x0a, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28,
-	0x03, 0x52, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x22, 0xdc, 0x01, 0x0a, 0x0a, 0x53, 0x65,
-	0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x12, 0x12, 0x0a, 0x04, 0x66, 0x72, 0x6f, 0x6d,
-	0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x66, 0x72, 0x6f, 0x6d, 0x12, 0x0e, 0x0a, 0x02,
-	0x74, 0x6f, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x02, 0x74, 0x6f, 0x12, 0x12, 0x0a, 0x04,
-	0x64, 0x61, 0x74, 0x61, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61,
-	0x12, 0x10, 0x0a, 0x03, 0x73, 0x69, 0x67, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x73,
-	0x69, 0x67, 0x12, 0x14, 0x0a, 0x05, 0x6e, 0x6f, 0x6e, 0x63, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28,
-	0x0c, 0x52, 0x05, 0x6e, 0x6f, 0x6e, 0x63, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x74, 0x61, 0x74,
-	0x65, 0x5f, 0x72, 0x65, 0x61, 0x64, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0a, 0x73,
-	0x74, 0x61, 0x74, 0x65, 0x52, 0x65, 0x61, 0x64, 0x73, 0x12, 0x21, 0x0a, 0x0c, 0x73, 0x74, 0x61,
-	0x74, 0x65, 0x5f, 0x77, 0x72, 0x69, 0x74, 0x65, 0x73, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0c, 0x52,
-	0x0b, 0x73, 0x74, 0x61, 0x74, 0x65, 0x57, 0x72, 0x69, 0x74, 0x65, 0x73, 0x12, 0x2a, 0x0a, 0x07,
-	0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x18, 0x14, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x10, 0x2e,
-	0x45, 0x78, 0x70, 0x69, 0x72, 0x79, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x52,
-	0x07, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x22, 0x4a, 0x0a, 0x0f, 0x45, 0x78, 0x70, 0x69,
-	0x72, 0x79, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x2a, 0x0a, 0x04, 0x75,
-	0x6e, 0x69, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x55, 0x4e, 0x49, 0x58,
-	0x45, 0x78, 0x70, 0x69, 0x72, 0x79, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x48,
-	0x00, 0x52, 0x04, 0x75, 0x6e, 0x69, 0x78, 0x42, 0x0b, 0x0a, 0x09, 0x63, 0x6f, 0x6e, 0x64, 0x69,
-	0x74, 0x69, 0x6f, 0x6e, 0x22, 0x29, 0x0a, 0x13, 0x55, 0x4e, 0x49, 0x58, 0x45, 0x78, 0x70, 0x69,
-	0x72, 0x79, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x74,
-	0x69, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x22,
-	0x54, 0x0a, 0x0f, 0x47, 0x65, 0x74, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f,
-	0x6e, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x66, 0x72, 0x6f, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04,
-	0x52, 0x04, 0x66, 0x72, 0x6f, 0x6d, 0x12, 0x0e, 0x0a, 0x02, 0x74, 0x6f, 0x18, 0x02, 0x20, 0x01,
-	0x28, 0x04, 0x52, 0x02, 0x74, 0x6f, 0x12, 0x1d, 0x0a, 0x03, 0x74, 0x78, 0x73, 0x18, 0x03, 0x20,
-	0x03, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x53, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x54, 0x78,
-	0x52, 0x03, 0x74, 0x78, 0x73, 0x22, 0x28, 0x0a, 0x10, 0x47, 0x65, 0x74, 0x53, 0x65, 0x71, 0x75,
-	0x65, 0x6e, 0x63, 0x65, 0x72, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75,
-	0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x22,
-	0x43, 0x0a, 0x1d, 0x53, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x72, 0x50, 0x72, 0x69, 0x6d,
-	0x61, 0x72, 0x79, 0x41, 0x64, 0x76, 0x65, 0x72, 0x74, 0x69, 0x73, 0x65, 0x6d, 0x65, 0x6e, 0x74,
-	0x12, 0x22, 0x0a, 0x0c, 0x6d, 0x75, 0x6c, 0x74, 0x69, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0c, 0x6d, 0x75, 0x6c, 0x74, 0x69, 0x61, 0x64, 0x64,
-	0x72, 0x65, 0x73, 0x73, 0x22, 0x2a, 0x0a, 0x0a, 0x50, 0x32, 0x50, 0x4d, 0x65, 0x73, 0x73, 0x61,
-	0x67, 0x65, 0x12, 0x1c, 0x0a, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x0b, 0x32, 0x06, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b,
-	0x42, 0x4c, 0x5a, 0x4a, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6c,
-	0x69, 0x61, 0x6d, 0x7a, 0x65, 0x62, 0x65, 0x64, 0x65, 0x65, 0x2f, 0x67, 0x6f, 0x6c, 0x69, 0x61,
-	0x74, 0x68, 0x2d, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x2f, 0x73, 0x65,
-	0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x72, 0x2f, 0x6d, 0x76, 0x70, 0x2f, 0x73, 0x65, 0x71, 0x75,
-	0x65, 0x6e, 0x63, 0x65, 0x72, 0x2f, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x62, 0x06,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x8c, 0x01, 0x0a, 0x05, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x24, 0x0a, 0x0d, 0x70, 0x72, 0x65,
+	0x76, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x0d, 0x70, 0x72, 0x65, 0x76, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73, 0x68, 0x12,
+	0x1f, 0x0a, 0x04, 0x62, 0x6f, 0x64, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0b, 0x2e,
+	0x53, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x52, 0x04, 0x62, 0x6f, 0x64, 0x79,
+	0x12, 0x10, 0x0a, 0x03, 0x73, 0x69, 0x67, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x73,
+	0x69, 0x67, 0x12, 0x16, 0x0a, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x69,
+	0x67, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x04, 0x73, 0x69, 0x67, 0x73, 0x22, 0x87,
+	0x01, 0x0a, 0x0b, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x12, 0x16,
+	0x0a, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06,
+	0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x12, 0x26, 0x0a, 0x0f, 0x70, 0x72, 0x65, 0x76, 0x5f, 0x62,
+	0x6c, 0x6f, 0x63, 0x6b, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x0d, 0x70, 0x72, 0x65, 0x76, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73, 0x68, 0x12, 0x12,
+	0x0a, 0x04, 0x68, 0x61, 0x73, 0x68, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x68, 0x61,
+	0x73, 0x68, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x69, 0x67, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x03, 0x73, 0x69, 0x67, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x69, 0x67, 0x73, 0x18, 0x05, 0x20, 0x03,
+	0x28, 0x0c, 0x52, 0x04, 0x73, 0x69, 0x67, 0x73, 0x22, 0x82, 0x02, 0x0a, 0x0a, 0x53, 0x65, 0x71,
+	0x75, 0x65, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x12, 0x12, 0x0a, 0x04, 0x66, 0x72, 0x6f, 0x6d, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x66, 0x72, 0x6f, 0x6d, 0x12, 0x0e, 0x0a, 0x02, 0x74,
+	0x6f, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x02, 0x74, 0x6f, 0x12, 0x12, 0x0a, 0x04, 0x64,
+	0x61, 0x74, 0x61, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x12,
+	0x10, 0x0a, 0x03, 0x73, 0x69, 0x67, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x73, 0x69,
+	0x67, 0x12, 0x14, 0x0a, 0x05, 0x6e, 0x6f, 0x6e, 0x63, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x05, 0x6e, 0x6f, 0x6e, 0x63, 0x65, 0x12, 0x2c, 0x0a, 0x0b, 0x73, 0x74, 0x61, 0x74, 0x65,
+	0x5f, 0x72, 0x65, 0x61, 0x64, 0x73, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x41,
+	0x63, 0x63, 0x65, 0x73, 0x73, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x0a, 0x73, 0x74, 0x61, 0x74, 0x65,
+	0x52, 0x65, 0x61, 0x64, 0x73, 0x12, 0x2e, 0x0a, 0x0c, 0x73, 0x74, 0x61, 0x74, 0x65, 0x5f, 0x77,
+	0x72, 0x69, 0x74, 0x65, 0x73, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x41, 0x63,
+	0x63, 0x65, 0x73, 0x73, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x0b, 0x73, 0x74, 0x61, 0x74, 0x65, 0x57,
+	0x72, 0x69, 0x74, 0x65, 0x73, 0x12, 0x2a, 0x0a, 0x07, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73,
+	0x18, 0x14, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x45, 0x78, 0x70, 0x69, 0x72, 0x79, 0x43,
+	0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x07, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65,
+	0x73, 0x4a, 0x04, 0x08, 0x07, 0x10, 0x08, 0x4a, 0x04, 0x08, 0x08, 0x10, 0x09, 0x22, 0x66, 0x0a,
+	0x0a, 0x41, 0x63, 0x63, 0x65, 0x73, 0x73, 0x4c, 0x69, 0x73, 0x74, 0x12, 0x2a, 0x0a, 0x08, 0x61,
+	0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0e, 0x2e,
+	0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x41, 0x63, 0x63, 0x65, 0x73, 0x73, 0x52, 0x08, 0x61,
+	0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x73, 0x12, 0x2c, 0x0a, 0x07, 0x73, 0x74, 0x6f, 0x72, 0x61,
+	0x67, 0x65, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x53, 0x74, 0x6f, 0x72, 0x61,
+	0x67, 0x65, 0x53, 0x6c, 0x6f, 0x74, 0x41, 0x63, 0x63, 0x65, 0x73, 0x73, 0x52, 0x07, 0x73, 0x74,
+	0x6f, 0x72, 0x61, 0x67, 0x65, 0x22, 0x29, 0x0a, 0x0d, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74,
+	0x41, 0x63, 0x63, 0x65, 0x73, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73,
+	0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73,
+	0x22, 0x41, 0x0a, 0x11, 0x53, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x53, 0x6c, 0x6f, 0x74, 0x41,
+	0x63, 0x63, 0x65, 0x73, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12,
+	0x12, 0x0a, 0x04, 0x73, 0x6c, 0x6f, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x73,
+	0x6c, 0x6f, 0x74, 0x22, 0xb4, 0x01, 0x0a, 0x0f, 0x45, 0x78, 0x70, 0x69, 0x72, 0x79, 0x43, 0x6f,
+	0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x2a, 0x0a, 0x04, 0x75, 0x6e, 0x69, 0x78, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x55, 0x4e, 0x49, 0x58, 0x45, 0x78, 0x70, 0x69,
+	0x72, 0x79, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x48, 0x00, 0x52, 0x04, 0x75,
+	0x6e, 0x69, 0x78, 0x12, 0x30, 0x0a, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x48, 0x65, 0x69, 0x67, 0x68, 0x74, 0x45, 0x78, 0x70, 0x69,
+	0x72, 0x79, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x48, 0x00, 0x52, 0x06, 0x68,
+	0x65, 0x69, 0x67, 0x68, 0x74, 0x12, 0x36, 0x0a, 0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63,
+	0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x53, 0x65, 0x71, 0x75, 0x65, 0x6e,
+	0x63, 0x65, 0x45, 0x78, 0x70, 0x69, 0x72, 0x79, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f,
+	0x6e, 0x48, 0x00, 0x52, 0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x42, 0x0b, 0x0a,
+	0x09, 0x63, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x29, 0x0a, 0x13, 0x55, 0x4e,
+	0x49, 0x58, 0x45, 0x78, 0x70, 0x69, 0x72, 0x79, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f,
+	0x6e, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x04, 0x74, 0x69, 0x6d, 0x65, 0x22, 0x2f, 0x0a, 0x15, 0x48, 0x65, 0x69, 0x67, 0x68, 0x74, 0x45,
+	0x78, 0x70, 0x69, 0x72, 0x79, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x16,
+	0x0a, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06,
+	0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x22, 0x35, 0x0a, 0x17, 0x53, 0x65, 0x71, 0x75, 0x65, 0x6e,
+	0x63, 0x65, 0x45, 0x78, 0x70, 0x69, 0x72, 0x79, 0x43, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f,
+	0x6e, 0x12, 0x1a, 0x0a, 0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x22, 0x54, 0x0a,
+	0x0f, 0x47, 0x65, 0x74, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x12, 0x12, 0x0a, 0x04, 0x66, 0x72, 0x6f, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04,
+	0x66, 0x72, 0x6f, 0x6d, 0x12, 0x0e, 0x0a, 0x02, 0x74, 0x6f, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x02, 0x74, 0x6f, 0x12, 0x1d, 0x0a, 0x03, 0x74, 0x78, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x0b, 0x2e, 0x53, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x52, 0x03,
+	0x74, 0x78, 0x73, 0x22, 0x28, 0x0a, 0x10, 0x47, 0x65, 0x74, 0x53, 0x65, 0x71, 0x75, 0x65, 0x6e,
+	0x63, 0x65, 0x72, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0xa0, 0x01,
+	0x0a, 0x1d, 0x53, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x72, 0x50, 0x72, 0x69, 0x6d, 0x61,
+	0x72, 0x79, 0x41, 0x64, 0x76, 0x65, 0x72, 0x74, 0x69, 0x73, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x12,
+	0x22, 0x0a, 0x0c, 0x6d, 0x75, 0x6c, 0x74, 0x69, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18,
+	0x01, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x0c, 0x6d, 0x75, 0x6c, 0x74, 0x69, 0x61, 0x64, 0x64, 0x72,
+	0x65, 0x73, 0x73, 0x12, 0x17, 0x0a, 0x07, 0x70, 0x65, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x70, 0x65, 0x65, 0x72, 0x49, 0x64, 0x12, 0x1c, 0x0a, 0x09,
+	0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x69,
+	0x67, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x73, 0x69, 0x67, 0x12, 0x12, 0x0a, 0x04,
+	0x73, 0x69, 0x67, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x04, 0x73, 0x69, 0x67, 0x73,
+	0x22, 0xf6, 0x01, 0x0a, 0x09, 0x48, 0x61, 0x6e, 0x64, 0x73, 0x68, 0x61, 0x6b, 0x65, 0x12, 0x19,
+	0x0a, 0x08, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x07, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x49, 0x64, 0x12, 0x21, 0x0a, 0x0c, 0x67, 0x65, 0x6e,
+	0x65, 0x73, 0x69, 0x73, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x0b, 0x67, 0x65, 0x6e, 0x65, 0x73, 0x69, 0x73, 0x48, 0x61, 0x73, 0x68, 0x12, 0x29, 0x0a, 0x10,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c,
+	0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x30, 0x0a, 0x14, 0x6d, 0x69, 0x6e, 0x5f, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x12, 0x6d, 0x69, 0x6e, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x63,
+	0x6f, 0x6c, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x6d, 0x6f, 0x64,
+	0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6d, 0x6f, 0x64, 0x65, 0x12, 0x16, 0x0a,
+	0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x68,
+	0x65, 0x69, 0x67, 0x68, 0x74, 0x12, 0x22, 0x0a, 0x0c, 0x63, 0x61, 0x70, 0x61, 0x62, 0x69, 0x6c,
+	0x69, 0x74, 0x69, 0x65, 0x73, 0x18, 0x07, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0c, 0x63, 0x61, 0x70,
+	0x61, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x69, 0x65, 0x73, 0x22, 0x79, 0x0a, 0x0a, 0x50, 0x32, 0x50,
+	0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x1c, 0x0a, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x06, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x05,
+	0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x27, 0x0a, 0x07, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x52, 0x65, 0x6c, 0x61, 0x79, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x07, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x24,
+	0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c,
+	0x2e, 0x52, 0x65, 0x6c, 0x61, 0x79, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x22, 0x26, 0x0a, 0x0c, 0x52, 0x65, 0x6c, 0x61, 0x79, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x61, 0x74, 0x74, 0x61, 0x63, 0x68, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x61, 0x74, 0x74, 0x61, 0x63, 0x68, 0x22, 0x5c, 0x0a, 0x0b,
+	0x52, 0x65, 0x6c, 0x61, 0x79, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x1d, 0x0a, 0x0a, 0x66,
+	0x72, 0x65, 0x65, 0x5f, 0x73, 0x6c, 0x6f, 0x74, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x09, 0x66, 0x72, 0x65, 0x65, 0x53, 0x6c, 0x6f, 0x74, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61,
+	0x74, 0x68, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x12, 0x1a,
+	0x0a, 0x08, 0x61, 0x74, 0x74, 0x61, 0x63, 0x68, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x08, 0x61, 0x74, 0x74, 0x61, 0x63, 0x68, 0x65, 0x64, 0x22, 0xbd, 0x01, 0x0a, 0x0a, 0x43,
+	0x68, 0x65, 0x63, 0x6b, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x68, 0x65, 0x69,
+	0x67, 0x68, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68,
+	0x74, 0x12, 0x19, 0x0a, 0x08, 0x74, 0x69, 0x70, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x07, 0x74, 0x69, 0x70, 0x48, 0x61, 0x73, 0x68, 0x12, 0x29, 0x0a, 0x10,
+	0x61, 0x63, 0x63, 0x75, 0x6d, 0x75, 0x6c, 0x61, 0x74, 0x6f, 0x72, 0x5f, 0x72, 0x6f, 0x6f, 0x74,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0f, 0x61, 0x63, 0x63, 0x75, 0x6d, 0x75, 0x6c, 0x61,
+	0x74, 0x6f, 0x72, 0x52, 0x6f, 0x6f, 0x74, 0x12, 0x2b, 0x0a, 0x11, 0x61, 0x63, 0x63, 0x75, 0x6d,
+	0x75, 0x6c, 0x61, 0x74, 0x6f, 0x72, 0x5f, 0x70, 0x65, 0x61, 0x6b, 0x73, 0x18, 0x04, 0x20, 0x03,
+	0x28, 0x0c, 0x52, 0x10, 0x61, 0x63, 0x63, 0x75, 0x6d, 0x75, 0x6c, 0x61, 0x74, 0x6f, 0x72, 0x50,
+	0x65, 0x61, 0x6b, 0x73, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x69, 0x67, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x03, 0x73, 0x69, 0x67, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x69, 0x67, 0x73, 0x18, 0x06,
+	0x20, 0x03, 0x28, 0x0c, 0x52, 0x04, 0x73, 0x69, 0x67, 0x73, 0x22, 0x8c, 0x01, 0x0a, 0x0b, 0x53,
+	0x79, 0x6e, 0x63, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x32, 0x0a, 0x0a, 0x67, 0x65,
+	0x74, 0x5f, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11,
+	0x2e, 0x47, 0x65, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x48, 0x00, 0x52, 0x09, 0x67, 0x65, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x12, 0x3e,
+	0x0a, 0x0e, 0x67, 0x65, 0x74, 0x5f, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x70, 0x6f, 0x69, 0x6e, 0x74,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x47, 0x65, 0x74, 0x43, 0x68, 0x65, 0x63,
+	0x6b, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x48, 0x00, 0x52,
+	0x0d, 0x67, 0x65, 0x74, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x42, 0x09,
+	0x0a, 0x07, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x50, 0x0a, 0x10, 0x47, 0x65, 0x74,
+	0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1f, 0x0a,
+	0x0b, 0x66, 0x72, 0x6f, 0x6d, 0x5f, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x0a, 0x66, 0x72, 0x6f, 0x6d, 0x48, 0x65, 0x69, 0x67, 0x68, 0x74, 0x12, 0x1b,
+	0x0a, 0x09, 0x74, 0x6f, 0x5f, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x08, 0x74, 0x6f, 0x48, 0x65, 0x69, 0x67, 0x68, 0x74, 0x22, 0x2e, 0x0a, 0x14, 0x47,
+	0x65, 0x74, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x22, 0x90, 0x01, 0x0a, 0x0c,
+	0x53, 0x79, 0x6e, 0x63, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1e, 0x0a, 0x06,
+	0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x06, 0x2e, 0x42,
+	0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x06, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x12, 0x2b, 0x0a, 0x0a,
+	0x63, 0x68, 0x65, 0x63, 0x6b, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x0b, 0x2e, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x52, 0x0a, 0x63,
+	0x68, 0x65, 0x63, 0x6b, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72,
+	0x6f, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x12,
+	0x1d, 0x0a, 0x0a, 0x74, 0x69, 0x70, 0x5f, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x09, 0x74, 0x69, 0x70, 0x48, 0x65, 0x69, 0x67, 0x68, 0x74, 0x22, 0x2c,
+	0x0a, 0x0d, 0x41, 0x70, 0x70, 0x65, 0x6e, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x1b, 0x0a, 0x02, 0x74, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x53, 0x65,
+	0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x54, 0x78, 0x52, 0x02, 0x74, 0x78, 0x22, 0x2c, 0x0a, 0x0e,
+	0x41, 0x70, 0x70, 0x65, 0x6e, 0x64, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1a,
+	0x0a, 0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x22, 0x35, 0x0a, 0x0f, 0x47, 0x65,
+	0x74, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a,
+	0x04, 0x66, 0x72, 0x6f, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x66, 0x72, 0x6f,
+	0x6d, 0x12, 0x0e, 0x0a, 0x02, 0x74, 0x6f, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02, 0x74,
+	0x6f, 0x22, 0x29, 0x0a, 0x0f, 0x47, 0x65, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x22, 0x2a, 0x0a, 0x0c,
+	0x47, 0x65, 0x74, 0x54, 0x78, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1a, 0x0a, 0x08,
+	0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08,
+	0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x22, 0x0d, 0x0a, 0x0b, 0x49, 0x6e, 0x66, 0x6f,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x33, 0x0a, 0x10, 0x53, 0x75, 0x62, 0x73, 0x63,
+	0x72, 0x69, 0x62, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1f, 0x0a, 0x0b, 0x66,
+	0x72, 0x6f, 0x6d, 0x5f, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x0a, 0x66, 0x72, 0x6f, 0x6d, 0x48, 0x65, 0x69, 0x67, 0x68, 0x74, 0x32, 0xb9, 0x02, 0x0a,
+	0x09, 0x53, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x72, 0x12, 0x29, 0x0a, 0x06, 0x41, 0x70,
+	0x70, 0x65, 0x6e, 0x64, 0x12, 0x0e, 0x2e, 0x41, 0x70, 0x70, 0x65, 0x6e, 0x64, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x41, 0x70, 0x70, 0x65, 0x6e, 0x64, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2e, 0x0a, 0x08, 0x47, 0x65, 0x74, 0x52, 0x61, 0x6e, 0x67,
+	0x65, 0x12, 0x10, 0x2e, 0x47, 0x65, 0x74, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x10, 0x2e, 0x47, 0x65, 0x74, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x24, 0x0a, 0x08, 0x47, 0x65, 0x74, 0x42, 0x6c, 0x6f, 0x63,
+	0x6b, 0x12, 0x10, 0x2e, 0x47, 0x65, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x06, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x23, 0x0a, 0x05, 0x47,
+	0x65, 0x74, 0x54, 0x78, 0x12, 0x0d, 0x2e, 0x47, 0x65, 0x74, 0x54, 0x78, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x0b, 0x2e, 0x53, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x54, 0x78,
+	0x12, 0x27, 0x0a, 0x04, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x0c, 0x2e, 0x49, 0x6e, 0x66, 0x6f, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x11, 0x2e, 0x47, 0x65, 0x74, 0x53, 0x65, 0x71, 0x75,
+	0x65, 0x6e, 0x63, 0x65, 0x72, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x28, 0x0a, 0x09, 0x53, 0x75, 0x62,
+	0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x12, 0x11, 0x2e, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69,
+	0x62, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x06, 0x2e, 0x42, 0x6c, 0x6f, 0x63,
+	0x6b, 0x30, 0x01, 0x12, 0x33, 0x0a, 0x0d, 0x47, 0x65, 0x74, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x70,
+	0x6f, 0x69, 0x6e, 0x74, 0x12, 0x15, 0x2e, 0x47, 0x65, 0x74, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x70,
+	0x6f, 0x69, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0b, 0x2e, 0x43, 0x68,
+	0x65, 0x63, 0x6b, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x42, 0x4c, 0x5a, 0x4a, 0x67, 0x69, 0x74, 0x68,
+	0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6c, 0x69, 0x61, 0x6d, 0x7a, 0x65, 0x62, 0x65, 0x64,
+	0x65, 0x65, 0x2f, 0x67, 0x6f, 0x6c, 0x69, 0x61, 0x74, 0x68, 0x2d, 0x62, 0x6c, 0x6f, 0x63, 0x6b,
+	0x63, 0x68, 0x61, 0x69, 0x6e, 0x2f, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x72, 0x2f,
+	0x6d, 0x76, 0x70, 0x2f, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x72, 0x2f, 0x6d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -584,28 +2059,76 @@ func file_sequencer_messages_defs_proto_rawDescGZIP() []byte {
 	return file_sequencer_messages_defs_proto_rawDescData
 }
 
-var file_sequencer_messages_defs_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_sequencer_messages_defs_proto_msgTypes = make([]protoimpl.MessageInfo, 29)
 var file_sequencer_messages_defs_proto_goTypes = []interface{}{
 	(*Block)(nil),                         // 0: Block
-	(*SequenceTx)(nil),                    // 1: SequenceTx
-	(*ExpiryCondition)(nil),               // 2: ExpiryCondition
-	(*UNIXExpiryCondition)(nil),           // 3: UNIXExpiryCondition
-	(*GetTransactions)(nil),               // 4: GetTransactions
-	(*GetSequencerInfo)(nil),              // 5: GetSequencerInfo
-	(*SequencerPrimaryAdvertisement)(nil), // 6: SequencerPrimaryAdvertisement
-	(*P2PMessage)(nil),                    // 7: P2PMessage
+	(*BlockHeader)(nil),                   // 1: BlockHeader
+	(*SequenceTx)(nil),                    // 2: SequenceTx
+	(*AccessList)(nil),                    // 3: AccessList
+	(*AccountAccess)(nil),                 // 4: AccountAccess
+	(*StorageSlotAccess)(nil),             // 5: StorageSlotAccess
+	(*ExpiryCondition)(nil),               // 6: ExpiryCondition
+	(*UNIXExpiryCondition)(nil),           // 7: UNIXExpiryCondition
+	(*HeightExpiryCondition)(nil),         // 8: HeightExpiryCondition
+	(*SequenceExpiryCondition)(nil),       // 9: SequenceExpiryCondition
+	(*GetTransactions)(nil),               // 10: GetTransactions
+	(*GetSequencerInfo)(nil),              // 11: GetSequencerInfo
+	(*SequencerPrimaryAdvertisement)(nil), // 12: SequencerPrimaryAdvertisement
+	(*Handshake)(nil),                     // 13: Handshake
+	(*P2PMessage)(nil),                    // 14: P2PMessage
+	(*RelayRequest)(nil),                  // 15: RelayRequest
+	(*RelayStatus)(nil),                   // 16: RelayStatus
+	(*Checkpoint)(nil),                    // 17: Checkpoint
+	(*SyncRequest)(nil),                   // 18: SyncRequest
+	(*GetBlocksRequest)(nil),              // 19: GetBlocksRequest
+	(*GetCheckpointRequest)(nil),          // 20: GetCheckpointRequest
+	(*SyncResponse)(nil),                  // 21: SyncResponse
+	(*AppendRequest)(nil),                 // 22: AppendRequest
+	(*AppendResponse)(nil),                // 23: AppendResponse
+	(*GetRangeRequest)(nil),               // 24: GetRangeRequest
+	(*GetBlockRequest)(nil),               // 25: GetBlockRequest
+	(*GetTxRequest)(nil),                  // 26: GetTxRequest
+	(*InfoRequest)(nil),                   // 27: InfoRequest
+	(*SubscribeRequest)(nil),              // 28: SubscribeRequest
 }
 var file_sequencer_messages_defs_proto_depIdxs = []int32{
-	1, // 0: Block.body:type_name -> SequenceTx
-	2, // 1: SequenceTx.expires:type_name -> ExpiryCondition
-	3, // 2: ExpiryCondition.unix:type_name -> UNIXExpiryCondition
-	1, // 3: GetTransactions.txs:type_name -> SequenceTx
-	0, // 4: P2PMessage.block:type_name -> Block
-	5, // [5:5] is the sub-list for method output_type
-	5, // [5:5] is the sub-list for method input_type
-	5, // [5:5] is the sub-list for extension type_name
-	5, // [5:5] is the sub-list for extension extendee
-	0, // [0:5] is the sub-list for field type_name
+	2,  // 0: Block.body:type_name -> SequenceTx
+	3,  // 1: SequenceTx.state_reads:type_name -> AccessList
+	3,  // 2: SequenceTx.state_writes:type_name -> AccessList
+	6,  // 3: SequenceTx.expires:type_name -> ExpiryCondition
+	4,  // 4: AccessList.accounts:type_name -> AccountAccess
+	5,  // 5: AccessList.storage:type_name -> StorageSlotAccess
+	7,  // 6: ExpiryCondition.unix:type_name -> UNIXExpiryCondition
+	8,  // 7: ExpiryCondition.height:type_name -> HeightExpiryCondition
+	9,  // 8: ExpiryCondition.sequence:type_name -> SequenceExpiryCondition
+	2,  // 9: GetTransactions.txs:type_name -> SequenceTx
+	0,  // 10: P2PMessage.block:type_name -> Block
+	15, // 11: P2PMessage.request:type_name -> RelayRequest
+	16, // 12: P2PMessage.status:type_name -> RelayStatus
+	19, // 13: SyncRequest.get_blocks:type_name -> GetBlocksRequest
+	20, // 14: SyncRequest.get_checkpoint:type_name -> GetCheckpointRequest
+	0,  // 15: SyncResponse.blocks:type_name -> Block
+	17, // 16: SyncResponse.checkpoint:type_name -> Checkpoint
+	2,  // 17: AppendRequest.tx:type_name -> SequenceTx
+	22, // 18: Sequencer.Append:input_type -> AppendRequest
+	24, // 19: Sequencer.GetRange:input_type -> GetRangeRequest
+	25, // 20: Sequencer.GetBlock:input_type -> GetBlockRequest
+	26, // 21: Sequencer.GetTx:input_type -> GetTxRequest
+	27, // 22: Sequencer.Info:input_type -> InfoRequest
+	28, // 23: Sequencer.Subscribe:input_type -> SubscribeRequest
+	20, // 24: Sequencer.GetCheckpoint:input_type -> GetCheckpointRequest
+	23, // 25: Sequencer.Append:output_type -> AppendResponse
+	10, // 26: Sequencer.GetRange:output_type -> GetTransactions
+	0,  // 27: Sequencer.GetBlock:output_type -> Block
+	2,  // 28: Sequencer.GetTx:output_type -> SequenceTx
+	11, // 29: Sequencer.Info:output_type -> GetSequencerInfo
+	0,  // 30: Sequencer.Subscribe:output_type -> Block
+	17, // 31: Sequencer.GetCheckpoint:output_type -> Checkpoint
+	25, // [25:32] is the sub-list for method output_type
+	18, // [18:25] is the sub-list for method input_type
+	18, // [18:18] is the sub-list for extension type_name
+	18, // [18:18] is the sub-list for extension extendee
+	0,  // [0:18] is the sub-list for field type_name
 }
 
 func init() { file_sequencer_messages_defs_proto_init() }
@@ -627,7 +2150,7 @@ func file_sequencer_messages_defs_proto_init() {
 			}
 		}
 		file_sequencer_messages_defs_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SequenceTx); i {
+			switch v := v.(*BlockHeader); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -639,7 +2162,7 @@ func file_sequencer_messages_defs_proto_init() {
 			}
 		}
 		file_sequencer_messages_defs_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ExpiryCondition); i {
+			switch v := v.(*SequenceTx); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -651,7 +2174,7 @@ func file_sequencer_messages_defs_proto_init() {
 			}
 		}
 		file_sequencer_messages_defs_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*UNIXExpiryCondition); i {
+			switch v := v.(*AccessList); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -663,7 +2186,7 @@ func file_sequencer_messages_defs_proto_init() {
 			}
 		}
 		file_sequencer_messages_defs_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*GetTransactions); i {
+			switch v := v.(*AccountAccess); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -675,7 +2198,7 @@ func file_sequencer_messages_defs_proto_init() {
 			}
 		}
 		file_sequencer_messages_defs_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*GetSequencerInfo); i {
+			switch v := v.(*StorageSlotAccess); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -687,7 +2210,7 @@ func file_sequencer_messages_defs_proto_init() {
 			}
 		}
 		file_sequencer_messages_defs_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SequencerPrimaryAdvertisement); i {
+			switch v := v.(*ExpiryCondition); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -699,6 +2222,90 @@ func file_sequencer_messages_defs_proto_init() {
 			}
 		}
 		file_sequencer_messages_defs_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*UNIXExpiryCondition); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sequencer_messages_defs_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*HeightExpiryCondition); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sequencer_messages_defs_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SequenceExpiryCondition); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sequencer_messages_defs_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetTransactions); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sequencer_messages_defs_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetSequencerInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sequencer_messages_defs_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SequencerPrimaryAdvertisement); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sequencer_messages_defs_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Handshake); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sequencer_messages_defs_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*P2PMessage); i {
 			case 0:
 				return &v.state
@@ -710,9 +2317,183 @@ func file_sequencer_messages_defs_proto_init() {
 				return nil
 			}
 		}
+		file_sequencer_messages_defs_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RelayRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sequencer_messages_defs_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RelayStatus); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sequencer_messages_defs_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Checkpoint); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sequencer_messages_defs_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SyncRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sequencer_messages_defs_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetBlocksRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sequencer_messages_defs_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetCheckpointRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sequencer_messages_defs_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SyncResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sequencer_messages_defs_proto_msgTypes[22].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AppendRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sequencer_messages_defs_proto_msgTypes[23].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AppendResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sequencer_messages_defs_proto_msgTypes[24].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetRangeRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sequencer_messages_defs_proto_msgTypes[25].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetBlockRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sequencer_messages_defs_proto_msgTypes[26].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetTxRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sequencer_messages_defs_proto_msgTypes[27].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*InfoRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sequencer_messages_defs_proto_msgTypes[28].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SubscribeRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
-	file_sequencer_messages_defs_proto_msgTypes[2].OneofWrappers = []interface{}{
+	file_sequencer_messages_defs_proto_msgTypes[6].OneofWrappers = []interface{}{
 		(*ExpiryCondition_Unix)(nil),
+		(*ExpiryCondition_Height)(nil),
+		(*ExpiryCondition_Sequence)(nil),
+	}
+	file_sequencer_messages_defs_proto_msgTypes[18].OneofWrappers = []interface{}{
+		(*SyncRequest_GetBlocks)(nil),
+		(*SyncRequest_GetCheckpoint)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -720,9 +2501,9 @@ func file_sequencer_messages_defs_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_sequencer_messages_defs_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   8,
+			NumMessages:   29,
 			NumExtensions: 0,
-			NumServices:   0,
+			NumServices:   1,
 		},
 		GoTypes:           file_sequencer_messages_defs_proto_goTypes,
 		DependencyIndexes: file_sequencer_messages_defs_proto_depIdxs,
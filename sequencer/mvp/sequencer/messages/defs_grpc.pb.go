@@ -0,0 +1,357 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             v3.19.4
+// source: sequencer/messages/defs.proto
+
+package messages
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// SequencerClient is the client API for Sequencer service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type SequencerClient interface {
+	// Sequences a tx, returning its sequence number.
+	Append(ctx context.Context, in *AppendRequest, opts ...grpc.CallOption) (*AppendResponse, error)
+	// Returns the txs between sequence numbers `from` and `to`, inclusive.
+	GetRange(ctx context.Context, in *GetRangeRequest, opts ...grpc.CallOption) (*GetTransactions, error)
+	GetBlock(ctx context.Context, in *GetBlockRequest, opts ...grpc.CallOption) (*Block, error)
+	GetTx(ctx context.Context, in *GetTxRequest, opts ...grpc.CallOption) (*SequenceTx, error)
+	Info(ctx context.Context, in *InfoRequest, opts ...grpc.CallOption) (*GetSequencerInfo, error)
+	// Streams signed blocks from `from_height` onwards, backfilling history first.
+	Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (Sequencer_SubscribeClient, error)
+	// Returns the checkpoint at `height`, or the latest checkpoint if it's 0.
+	GetCheckpoint(ctx context.Context, in *GetCheckpointRequest, opts ...grpc.CallOption) (*Checkpoint, error)
+}
+
+type sequencerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSequencerClient(cc grpc.ClientConnInterface) SequencerClient {
+	return &sequencerClient{cc}
+}
+
+func (c *sequencerClient) Append(ctx context.Context, in *AppendRequest, opts ...grpc.CallOption) (*AppendResponse, error) {
+	out := new(AppendResponse)
+	err := c.cc.Invoke(ctx, "/Sequencer/Append", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sequencerClient) GetRange(ctx context.Context, in *GetRangeRequest, opts ...grpc.CallOption) (*GetTransactions, error) {
+	out := new(GetTransactions)
+	err := c.cc.Invoke(ctx, "/Sequencer/GetRange", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sequencerClient) GetBlock(ctx context.Context, in *GetBlockRequest, opts ...grpc.CallOption) (*Block, error) {
+	out := new(Block)
+	err := c.cc.Invoke(ctx, "/Sequencer/GetBlock", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sequencerClient) GetTx(ctx context.Context, in *GetTxRequest, opts ...grpc.CallOption) (*SequenceTx, error) {
+	out := new(SequenceTx)
+	err := c.cc.Invoke(ctx, "/Sequencer/GetTx", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sequencerClient) Info(ctx context.Context, in *InfoRequest, opts ...grpc.CallOption) (*GetSequencerInfo, error) {
+	out := new(GetSequencerInfo)
+	err := c.cc.Invoke(ctx, "/Sequencer/Info", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sequencerClient) Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (Sequencer_SubscribeClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Sequencer_ServiceDesc.Streams[0], "/Sequencer/Subscribe", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &sequencerSubscribeClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Sequencer_SubscribeClient interface {
+	Recv() (*Block, error)
+	grpc.ClientStream
+}
+
+type sequencerSubscribeClient struct {
+	grpc.ClientStream
+}
+
+func (x *sequencerSubscribeClient) Recv() (*Block, error) {
+	m := new(Block)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *sequencerClient) GetCheckpoint(ctx context.Context, in *GetCheckpointRequest, opts ...grpc.CallOption) (*Checkpoint, error) {
+	out := new(Checkpoint)
+	err := c.cc.Invoke(ctx, "/Sequencer/GetCheckpoint", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SequencerServer is the server API for Sequencer service.
+// All implementations must embed UnimplementedSequencerServer
+// for forward compatibility
+type SequencerServer interface {
+	// Sequences a tx, returning its sequence number.
+	Append(context.Context, *AppendRequest) (*AppendResponse, error)
+	// Returns the txs between sequence numbers `from` and `to`, inclusive.
+	GetRange(context.Context, *GetRangeRequest) (*GetTransactions, error)
+	GetBlock(context.Context, *GetBlockRequest) (*Block, error)
+	GetTx(context.Context, *GetTxRequest) (*SequenceTx, error)
+	Info(context.Context, *InfoRequest) (*GetSequencerInfo, error)
+	// Streams signed blocks from `from_height` onwards, backfilling history first.
+	Subscribe(*SubscribeRequest, Sequencer_SubscribeServer) error
+	// Returns the checkpoint at `height`, or the latest checkpoint if it's 0.
+	GetCheckpoint(context.Context, *GetCheckpointRequest) (*Checkpoint, error)
+	mustEmbedUnimplementedSequencerServer()
+}
+
+// UnimplementedSequencerServer must be embedded to have forward compatible implementations.
+type UnimplementedSequencerServer struct {
+}
+
+func (UnimplementedSequencerServer) Append(context.Context, *AppendRequest) (*AppendResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Append not implemented")
+}
+func (UnimplementedSequencerServer) GetRange(context.Context, *GetRangeRequest) (*GetTransactions, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRange not implemented")
+}
+func (UnimplementedSequencerServer) GetBlock(context.Context, *GetBlockRequest) (*Block, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBlock not implemented")
+}
+func (UnimplementedSequencerServer) GetTx(context.Context, *GetTxRequest) (*SequenceTx, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTx not implemented")
+}
+func (UnimplementedSequencerServer) Info(context.Context, *InfoRequest) (*GetSequencerInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Info not implemented")
+}
+func (UnimplementedSequencerServer) Subscribe(*SubscribeRequest, Sequencer_SubscribeServer) error {
+	return status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
+}
+func (UnimplementedSequencerServer) GetCheckpoint(context.Context, *GetCheckpointRequest) (*Checkpoint, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCheckpoint not implemented")
+}
+func (UnimplementedSequencerServer) mustEmbedUnimplementedSequencerServer() {}
+
+// UnsafeSequencerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SequencerServer will
+// result in compilation errors.
+type UnsafeSequencerServer interface {
+	mustEmbedUnimplementedSequencerServer()
+}
+
+func RegisterSequencerServer(s grpc.ServiceRegistrar, srv SequencerServer) {
+	s.RegisterService(&Sequencer_ServiceDesc, srv)
+}
+
+func _Sequencer_Append_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AppendRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SequencerServer).Append(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Sequencer/Append",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SequencerServer).Append(ctx, req.(*AppendRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sequencer_GetRange_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRangeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SequencerServer).GetRange(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Sequencer/GetRange",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SequencerServer).GetRange(ctx, req.(*GetRangeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sequencer_GetBlock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBlockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SequencerServer).GetBlock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Sequencer/GetBlock",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SequencerServer).GetBlock(ctx, req.(*GetBlockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sequencer_GetTx_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTxRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SequencerServer).GetTx(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Sequencer/GetTx",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SequencerServer).GetTx(ctx, req.(*GetTxRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sequencer_Info_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SequencerServer).Info(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Sequencer/Info",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SequencerServer).Info(ctx, req.(*InfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sequencer_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SequencerServer).Subscribe(m, &sequencerSubscribeServer{stream})
+}
+
+type Sequencer_SubscribeServer interface {
+	Send(*Block) error
+	grpc.ServerStream
+}
+
+type sequencerSubscribeServer struct {
+	grpc.ServerStream
+}
+
+func (x *sequencerSubscribeServer) Send(m *Block) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Sequencer_GetCheckpoint_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCheckpointRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SequencerServer).GetCheckpoint(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Sequencer/GetCheckpoint",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SequencerServer).GetCheckpoint(ctx, req.(*GetCheckpointRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Sequencer_ServiceDesc is the grpc.ServiceDesc for Sequencer service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Sequencer_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "Sequencer",
+	HandlerType: (*SequencerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Append",
+			Handler:    _Sequencer_Append_Handler,
+		},
+		{
+			MethodName: "GetRange",
+			Handler:    _Sequencer_GetRange_Handler,
+		},
+		{
+			MethodName: "GetBlock",
+			Handler:    _Sequencer_GetBlock_Handler,
+		},
+		{
+			MethodName: "GetTx",
+			Handler:    _Sequencer_GetTx_Handler,
+		},
+		{
+			MethodName: "Info",
+			Handler:    _Sequencer_Info_Handler,
+		},
+		{
+			MethodName: "GetCheckpoint",
+			Handler:    _Sequencer_GetCheckpoint_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Subscribe",
+			Handler:       _Sequencer_Subscribe_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "sequencer/messages/defs.proto",
+}
@@ -0,0 +1,200 @@
+package messages
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// JSON encoding for messages, for curl users and non-Go clients.
+//
+// This is protojson, with two changes to make it readable:
+// bytes are 0x-prefixed hex instead of base64, and 64-bit integers are
+// decimal numbers instead of strings.
+
+var protojsonMarshal = protojson.MarshalOptions{
+	EmitUnpopulated: true,
+}
+
+// Encodes a message as JSON.
+func MarshalJSON(msg proto.Message) (json.RawMessage, error) {
+	buf, err := protojsonMarshal.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, err := decodeJSONObject(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	err = convertJSONMessage(msg.ProtoReflect().Descriptor(), obj, toReadableJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(obj)
+}
+
+// Decodes a message from JSON produced by MarshalJSON.
+func UnmarshalJSON(buf []byte, msg proto.Message) (error) {
+	obj, err := decodeJSONObject(buf)
+	if err != nil {
+		return err
+	}
+
+	err = convertJSONMessage(msg.ProtoReflect().Descriptor(), obj, fromReadableJSON)
+	if err != nil {
+		return err
+	}
+
+	buf, err = json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+
+	return protojson.Unmarshal(buf, msg)
+}
+
+func decodeJSONObject(buf []byte) (map[string]interface{}, error) {
+	obj := map[string]interface{}{}
+
+	// Decode numbers as json.Number, so 64-bit integers keep their precision.
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.UseNumber()
+	err := dec.Decode(&obj)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding json: %s", err)
+	}
+
+	return obj, nil
+}
+
+type scalarConverter func(field protoreflect.FieldDescriptor, value interface{}) (interface{}, error)
+
+// Converts the fields of a JSON object in-place, using the message descriptor.
+func convertJSONMessage(desc protoreflect.MessageDescriptor, obj map[string]interface{}, convert scalarConverter) (error) {
+	fields := desc.Fields()
+
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+
+		// protojson accepts both the JSON name and the proto name.
+		names := []string{field.JSONName()}
+		if string(field.Name()) != field.JSONName() {
+			names = append(names, string(field.Name()))
+		}
+
+		for _, name := range names {
+			value, ok := obj[name]
+			if !ok || value == nil {
+				continue
+			}
+
+			converted, err := convertJSONField(field, value, convert)
+			if err != nil {
+				return fmt.Errorf("field %s: %s", name, err)
+			}
+			obj[name] = converted
+		}
+	}
+
+	return nil
+}
+
+func convertJSONField(field protoreflect.FieldDescriptor, value interface{}, convert scalarConverter) (interface{}, error) {
+	if field.IsList() {
+		list, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected a list")
+		}
+
+		for i, elem := range list {
+			converted, err := convertJSONValue(field, elem, convert)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = converted
+		}
+		return list, nil
+	}
+
+	if field.IsMap() {
+		entries, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected an object")
+		}
+
+		for key, elem := range entries {
+			converted, err := convertJSONValue(field.MapValue(), elem, convert)
+			if err != nil {
+				return nil, err
+			}
+			entries[key] = converted
+		}
+		return entries, nil
+	}
+
+	return convertJSONValue(field, value, convert)
+}
+
+func convertJSONValue(field protoreflect.FieldDescriptor, value interface{}, convert scalarConverter) (interface{}, error) {
+	if field.Kind() == protoreflect.MessageKind || field.Kind() == protoreflect.GroupKind {
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected an object")
+		}
+		return obj, convertJSONMessage(field.Message(), obj, convert)
+	}
+
+	return convert(field, value)
+}
+
+func toReadableJSON(field protoreflect.FieldDescriptor, value interface{}) (interface{}, error) {
+	switch field.Kind() {
+	case protoreflect.BytesKind:
+		str, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a base64 string")
+		}
+
+		buf, err := base64.StdEncoding.DecodeString(str)
+		if err != nil {
+			return nil, err
+		}
+		return hexutil.Encode(buf), nil
+
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		str, ok := value.(string)
+		if !ok {
+			return value, nil
+		}
+		return json.Number(str), nil
+	}
+
+	return value, nil
+}
+
+func fromReadableJSON(field protoreflect.FieldDescriptor, value interface{}) (interface{}, error) {
+	if field.Kind() != protoreflect.BytesKind {
+		return value, nil
+	}
+
+	str, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("expected a hex string")
+	}
+
+	buf, err := hexutil.Decode(str)
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.EncodeToString(buf), nil
+}
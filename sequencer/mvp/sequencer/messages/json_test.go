@@ -0,0 +1,34 @@
+package messages
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/utils"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestBlockJSON(t *testing.T) {
+	signer := utils.NewEthereumECDSASigner("3fd7f88cb790c6a8b54d4e1aaebba6775f427bb8fa2276e933b7c3440f164caa")
+	block := ConstructBlock(ConstructSequenceMessage("0x0001", 0))
+	block.Height = 42
+	block.PrevBlockHash = hexutil.MustDecode("0xabcd")
+	block = block.Signed(signer)
+
+	buf, err := MarshalJSON(block)
+	assert.Nil(t, err)
+
+	// Bytes are hex, and heights are decimal numbers.
+	obj := map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal(buf, &obj))
+	assert.Equal(t, "0xabcd", obj["prevBlockHash"])
+	assert.Equal(t, float64(42), obj["height"])
+	assert.Equal(t, "0x0001", obj["body"].(map[string]interface{})["data"])
+
+	// And it round-trips.
+	decoded := &Block{}
+	assert.Nil(t, UnmarshalJSON(buf, decoded))
+	assert.True(t, proto.Equal(block, decoded), "decode(encode(block)) != block")
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/golang/protobuf/proto"
 	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/utils"
@@ -79,7 +80,116 @@ func ConstructSequenceMessage(txData string, expiresIn time.Duration) (*Sequence
 	return &msg
 }
 
+// Expires the message after the block at `height`.
+func (msg *SequenceTx) ExpiresAfterHeight(height int64) {
+	msg.Expires = append(msg.Expires, &ExpiryCondition{
+		Condition: &ExpiryCondition_Height{
+			Height: &HeightExpiryCondition{
+				Height: height,
+			},
+		},
+	})
+}
+
+// Expires the message after the tx at sequence number `sequence`.
+func (msg *SequenceTx) ExpiresAfterSequence(sequence uint64) {
+	msg.Expires = append(msg.Expires, &ExpiryCondition{
+		Condition: &ExpiryCondition_Sequence{
+			Sequence: &SequenceExpiryCondition{
+				Sequence: sequence,
+			},
+		},
+	})
+}
+
+// The position in the chain a message is being checked for inclusion at.
+type ExpiryContext struct {
+	Height int64
+	Sequence uint64
+
+	// UNIX expiry depends on the local clock, so only the primary checks it.
+	// Replicas leave this unset, and verify the deterministic conditions only.
+	CheckUnix bool
+	Now time.Time
+}
+
+// Checks the message's expiry conditions against the given context.
+func (msg *SequenceTx) CheckExpiry(ctx ExpiryContext) (error) {
+	for _, expiryCondition := range msg.Expires {
+		switch cond := expiryCondition.GetCondition().(type) {
+		case *ExpiryCondition_Unix:
+			if !ctx.CheckUnix {
+				continue
+			}
+			if cond.Unix.Time < uint64(ctx.Now.UnixMilli()) {
+				return fmt.Errorf("message expired")
+			}
+		case *ExpiryCondition_Height:
+			if cond.Height.Height < ctx.Height {
+				return fmt.Errorf("message expired")
+			}
+		case *ExpiryCondition_Sequence:
+			if cond.Sequence.Sequence < ctx.Sequence {
+				return fmt.Errorf("message expired")
+			}
+		default:
+			return fmt.Errorf("unknown expiry condition type %T", cond)
+		}
+	}
+
+	return nil
+}
+
+
+
+// Access lists.
+
+const addressLength = 20
+const storageSlotLength = 32
+
+// Validates the addresses and slots in the access list. A nil list is valid.
+func (list *AccessList) Validate() (error) {
+	for _, account := range list.GetAccounts() {
+		if len(account.Address) != addressLength {
+			return fmt.Errorf("access list account address must be %d bytes, got %d", addressLength, len(account.Address))
+		}
+	}
+
+	for _, slot := range list.GetStorage() {
+		if len(slot.Address) != addressLength {
+			return fmt.Errorf("access list storage address must be %d bytes, got %d", addressLength, len(slot.Address))
+		}
+		if len(slot.Slot) != storageSlotLength {
+			return fmt.Errorf("access list storage slot must be %d bytes, got %d", storageSlotLength, len(slot.Slot))
+		}
+	}
+
+	return nil
+}
+
+// Converts an EIP-2930 access list. Every tuple is an account access,
+// and each of its storage keys is a storage slot access.
+func AccessListFromEIP2930(eip2930 types.AccessList) (*AccessList) {
+	list := &AccessList{
+		Accounts: []*AccountAccess{},
+		Storage: []*StorageSlotAccess{},
+	}
+
+	for _, tuple := range eip2930 {
+		list.Accounts = append(list.Accounts, &AccountAccess{
+			Address: tuple.Address.Bytes(),
+		})
 
+		for _, key := range tuple.StorageKeys {
+			list.Storage = append(list.Storage, &StorageSlotAccess{
+				Address: tuple.Address.Bytes(),
+				Slot: key.Bytes(),
+			})
+		}
+	}
+
+	return list
+}
 
 
 
@@ -98,6 +208,7 @@ func ConstructBlock(sequenceMessage *SequenceTx) (*Block) {
 func (block *Block) SigHash() ([]byte) {
 	unsigned := proto.Clone(block).(*Block)
 	unsigned.Sig = []byte{}
+	unsigned.Sigs = nil
 
 	buf, err := proto.Marshal(unsigned)
 	if err != nil {
@@ -109,14 +220,52 @@ func (block *Block) SigHash() ([]byte) {
 }
 
 func (block *Block) Signed(signer utils.Signer) (*Block) {
-	signature, err := signer.Sign(block.SigHash())
+	signed, err := block.SignWith(signer)
 	if err != nil {
 		panic(err)
 	}
-	
+	return signed
+}
+
+// A signer which is given the whole block to sign, rather than its digest, so it can
+// check what it's signing (eg. a remote signer with slashing protection).
+type BlockSigner interface {
+	SignBlock(block *Block) (sig []byte, err error)
+}
+
+// A signer for a committee of operators, which returns a signature from each
+// member that co-signed the block.
+type BlockCoSigner interface {
+	CoSignBlock(block *Block) (sigs [][]byte, err error)
+}
+
+// Returns a new Block with a signature, or an error if the signer refused to sign it.
+func (block *Block) SignWith(signer utils.Signer) (*Block, error) {
+	if coSigner, ok := signer.(BlockCoSigner); ok {
+		sigs, err := coSigner.CoSignBlock(block)
+		if err != nil {
+			return nil, err
+		}
+
+		signed := proto.Clone(block).(*Block)
+		signed.Sigs = sigs
+		return signed, nil
+	}
+
+	var signature []byte
+	var err error
+	if blockSigner, ok := signer.(BlockSigner); ok {
+		signature, err = blockSigner.SignBlock(block)
+	} else {
+		signature, err = signer.Sign(block.SigHash())
+	}
+	if err != nil {
+		return nil, err
+	}
+
 	signed := proto.Clone(block).(*Block)
 	signed.Sig = signature
-	return signed
+	return signed, nil
 }
 
 func (block *Block) PrettyString() string {
@@ -137,4 +286,15 @@ func (block *Block) PrettyString() string {
 
 func (block *Block) PrettyHash() string {
 	return hexutil.Encode(block.SigHash())
-}
\ No newline at end of file
+}
+
+// Returns the block without its body.
+func (block *Block) Header() (*BlockHeader) {
+	return &BlockHeader{
+		Height: block.Height,
+		PrevBlockHash: block.PrevBlockHash,
+		Hash: block.SigHash(),
+		Sig: block.Sig,
+		Sigs: block.Sigs,
+	}
+}
@@ -3,8 +3,11 @@ package messages
 import (
 	"bytes"
 	"testing"
+	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/utils"
 	"github.com/stretchr/testify/assert"
@@ -55,3 +58,53 @@ func TestBlockSigned(t *testing.T) {
 	}
 	assert.True(t, bytes.Equal(pubkey, pubkey2), "pubkeys dont match")
 }
+
+func TestCheckExpiry(t *testing.T) {
+	now := time.Now()
+
+	// Height expiry.
+	msg := ConstructSequenceMessage("0x0001", time.Hour)
+	msg.ExpiresAfterHeight(10)
+	assert.Nil(t, msg.CheckExpiry(ExpiryContext{Height: 10, Sequence: 10}))
+	assert.EqualError(t, msg.CheckExpiry(ExpiryContext{Height: 11, Sequence: 11}), "message expired")
+
+	// Sequence expiry.
+	msg = ConstructSequenceMessage("0x0001", time.Hour)
+	msg.ExpiresAfterSequence(5)
+	assert.Nil(t, msg.CheckExpiry(ExpiryContext{Height: 5, Sequence: 5}))
+	assert.EqualError(t, msg.CheckExpiry(ExpiryContext{Height: 6, Sequence: 6}), "message expired")
+
+	// UNIX expiry is only checked when requested.
+	msg = ConstructSequenceMessage("0x0001", -time.Minute)
+	assert.Nil(t, msg.CheckExpiry(ExpiryContext{Height: 1, Sequence: 1}))
+	assert.EqualError(t, msg.CheckExpiry(ExpiryContext{Height: 1, Sequence: 1, CheckUnix: true, Now: now}), "message expired")
+
+	// Unknown condition kinds are rejected.
+	msg = ConstructSequenceMessage("0x0001", time.Hour)
+	msg.Expires = append(msg.Expires, &ExpiryCondition{})
+	assert.EqualError(t, msg.CheckExpiry(ExpiryContext{Height: 1, Sequence: 1}), "unknown expiry condition type <nil>")
+}
+
+func TestAccessList(t *testing.T) {
+	address := common.HexToAddress("0x58311aaf5ebf42095ee0d620b97697a0b4c2f11c")
+	slot := common.HexToHash("0x01")
+
+	list := AccessListFromEIP2930(types.AccessList{
+		{Address: address, StorageKeys: []common.Hash{slot}},
+	})
+	assert.Len(t, list.Accounts, 1)
+	assert.Len(t, list.Storage, 1)
+	assert.Equal(t, address.Bytes(), list.Storage[0].Address)
+	assert.Equal(t, slot.Bytes(), list.Storage[0].Slot)
+	assert.Nil(t, list.Validate())
+
+	// A nil access list is valid.
+	var empty *AccessList
+	assert.Nil(t, empty.Validate())
+
+	list.Storage[0].Slot = []byte{0x01}
+	assert.EqualError(t, list.Validate(), "access list storage slot must be 32 bytes, got 1")
+
+	list.Accounts[0].Address = []byte{0x01}
+	assert.EqualError(t, list.Validate(), "access list account address must be 20 bytes, got 1")
+}
@@ -0,0 +1,179 @@
+package sequencer
+
+import (
+	"strings"
+
+	libp2pHost "github.com/libp2p/go-libp2p-core/host"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// Prometheus metrics for a sequencer node.
+// Each node has its own registry, so many nodes can run in one process (eg. the benchmark).
+type Metrics struct {
+	Registry *prometheus.Registry
+
+	// Core.
+	AppendLatency prometheus.Histogram
+	SequencedTxs prometheus.Counter
+	RejectedTxs *prometheus.CounterVec
+	Blocks prometheus.Counter
+	BlocksReceived prometheus.Counter
+	ChainHeight prometheus.Gauge
+	ReplicaLag prometheus.Gauge
+	OutOfOrderBlocks prometheus.Gauge
+
+	// Database.
+	DBCommitLatency prometheus.Histogram
+	PrunedBlocks prometheus.Counter
+
+	// P2P.
+	GossipPublishFailures prometheus.Counter
+	GossipRejectedBlocks *prometheus.CounterVec
+	RelayDroppedBlocks prometheus.Counter
+	RelayChildren prometheus.Gauge
+	RelayDepth prometheus.Gauge
+	HandshakeFailures *prometheus.CounterVec
+	BannedPeers prometheus.Counter
+	Connected prometheus.Gauge
+	BootstrapReconnects prometheus.Counter
+}
+
+func NewMetrics() (*Metrics) {
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+
+		AppendLatency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name: "sequencer_append_duration_seconds",
+			Help: "Time from a tx being accepted to its block being committed.",
+			Buckets: prometheus.ExponentialBuckets(0.0001, 2, 16),
+		}),
+		SequencedTxs: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "sequencer_sequenced_txs_total",
+			Help: "Number of txs sequenced by this primary.",
+		}),
+		RejectedTxs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "sequencer_rejected_txs_total",
+			Help: "Number of txs rejected, by reason.",
+		}, []string{"reason"}),
+		Blocks: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "sequencer_blocks_total",
+			Help: "Number of blocks added to the chain, whether produced or ingested.",
+		}),
+		BlocksReceived: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "sequencer_blocks_received_total",
+			Help: "Number of new blocks received from the network by a replica.",
+		}),
+		ChainHeight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "sequencer_chain_height",
+			Help: "Height of the latest block in the chain.",
+		}),
+		ReplicaLag: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "sequencer_replica_lag_blocks",
+			Help: "Number of heights between the chain tip and the highest block seen from the network.",
+		}),
+		OutOfOrderBlocks: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "sequencer_out_of_order_blocks",
+			Help: "Number of blocks buffered while waiting on their parent.",
+		}),
+
+		DBCommitLatency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name: "sequencer_db_commit_duration_seconds",
+			Help: "Time taken to commit a block to the database.",
+			Buckets: prometheus.ExponentialBuckets(0.0001, 2, 16),
+		}),
+		PrunedBlocks: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "sequencer_pruned_blocks_total",
+			Help: "Number of block bodies pruned from the database.",
+		}),
+
+		GossipPublishFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "sequencer_gossip_publish_failures_total",
+			Help: "Number of blocks which failed to publish to the network.",
+		}),
+		GossipRejectedBlocks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "sequencer_gossip_rejected_blocks_total",
+			Help: "Number of gossipped blocks rejected before being relayed, by reason.",
+		}, []string{"reason"}),
+		RelayDroppedBlocks: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "sequencer_relay_dropped_blocks_total",
+			Help: "Number of blocks not relayed to a child in the relay tree because it was too slow.",
+		}),
+		RelayChildren: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "sequencer_relay_children",
+			Help: "Number of children this node streams blocks to in the relay tree.",
+		}),
+		RelayDepth: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "sequencer_relay_depth",
+			Help: "Distance from the primary in the relay tree, or -1 if not in the tree.",
+		}),
+		HandshakeFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "sequencer_handshake_failures_total",
+			Help: "Number of peers disconnected for failing the handshake, by reason.",
+		}, []string{"reason"}),
+		BannedPeers: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "sequencer_peers_banned_total",
+			Help: "Number of peers banned for misbehaving.",
+		}),
+		Connected: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "sequencer_connected",
+			Help: "1 if the replica is connected to the minimum number of peers, 0 otherwise.",
+		}),
+		BootstrapReconnects: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "sequencer_bootstrap_reconnects_total",
+			Help: "Number of attempts to reconnect to bootstrap peers.",
+		}),
+	}
+
+	m.Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.AppendLatency,
+		m.SequencedTxs,
+		m.RejectedTxs,
+		m.Blocks,
+		m.BlocksReceived,
+		m.ChainHeight,
+		m.ReplicaLag,
+		m.OutOfOrderBlocks,
+		m.DBCommitLatency,
+		m.PrunedBlocks,
+		m.GossipPublishFailures,
+		m.GossipRejectedBlocks,
+		m.RelayDroppedBlocks,
+		m.RelayChildren,
+		m.RelayDepth,
+		m.HandshakeFailures,
+		m.BannedPeers,
+		m.Connected,
+		m.BootstrapReconnects,
+	)
+
+	return m
+}
+
+// Reports the number of peers connected to the host.
+func (m *Metrics) registerPeers(host libp2pHost.Host) {
+	m.Registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "sequencer_peers_connected",
+		Help: "Number of P2P peers connected.",
+	}, func() float64 {
+		return float64(len(host.Network().Peers()))
+	}))
+}
+
+// Classifies a rejected tx by its error, for the `reason` label.
+func rejectReason(err error) (string) {
+	msg := err.Error()
+	switch {
+	case strings.HasPrefix(msg, "message is malformed"), strings.HasPrefix(msg, "unknown expiry condition"):
+		return "malformed"
+	case strings.HasPrefix(msg, "invalid signature"):
+		return "invalid_signature"
+	case strings.HasPrefix(msg, "message expired"):
+		return "expired"
+	case strings.HasPrefix(msg, "sequencer is in replica mode"):
+		return "replica_mode"
+	}
+	return "other"
+}
@@ -1,11 +1,10 @@
 package sequencer
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"strings"
-	"sync"
-	"time"
 
 	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/messages"
 	"github.com/libp2p/go-libp2p-core/crypto"
@@ -17,21 +16,37 @@ const (
 	ReplicaMode
 )
 
+// Disseminates new blocks from the primary to the replicas.
+type blockNetwork interface {
+	GossipNewBlock(block *messages.Block)
+	ListenForNewBlocks(handler func(block *messages.Block))
+}
+
 type SequencerNode struct {
 	Seq *SequencerCore
 	P2P *P2PNode
+	// Set when blocks are disseminated over the relay tree instead of gossip.
+	Relay *P2PNode2
+	blocks blockNetwork
 	RPC *RPCNode
+	Syncer *Syncer
+	Handshaker *Handshaker
+	Peers *PeerManager
+	// Keeps replicas connected.
+	Watchdog *Watchdog
 	Mode SequencerMode
 }
 
 func NewSequencerNode(
 	dbPath string, 
 	rpcPort string, 
+	grpcPort string, 
 	p2pPort string, 
 	mode SequencerMode, 
 	p2pPrivateKeyRaw string, 
 	bootstrapPeersStr string, 
 	operatorPrivateKey string,
+	readyMaxLag int64,
 ) (*SequencerNode) {
 	// TODO: use sync=FULL for database durability during power loss.
 	db, err := sql.Open("sqlite3", dbPath)
@@ -48,7 +63,11 @@ func NewSequencerNode(
 
 	// RPC.
 	rpcAddr := fmt.Sprintf("0.0.0.0:%s", rpcPort)
-	rpc := NewRPCNode(rpcAddr, seq)
+	grpcAddr := ""
+	if grpcPort != "" {
+		grpcAddr = fmt.Sprintf("0.0.0.0:%s", grpcPort)
+	}
+	rpc := NewRPCNode(rpcAddr, grpcAddr, seq)
 	
 	// P2P.
 	p2pAddr := fmt.Sprintf("/ip4/0.0.0.0/tcp/%s", p2pPort)
@@ -67,96 +86,132 @@ func NewSequencerNode(
 	if err != nil {
 		panic(fmt.Errorf("couldn't create network node: %s", err))
 	}
+	p2p.metrics = seq.Metrics
+	err = p2p.ValidateBlocks(seq.VerifyGossipBlock)
+	if err != nil {
+		panic(fmt.Errorf("couldn't register block validator: %s", err))
+	}
+	err = p2p.ValidateAdvertisements(seq.VerifyAdvertisement)
+	if err != nil {
+		panic(fmt.Errorf("couldn't register advertisement validator: %s", err))
+	}
+	seq.Metrics.registerPeers(p2p.Host)
+
+	// Health checks.
+	rpc.RegisterHealthChecks(NewHealthChecker(seq, p2p.Host, mode, readyMaxLag))
+
+	// Handshake with peers as they connect.
+	handshaker := NewHandshaker(seq, p2p, mode)
+
+	// Score and ban peers.
+	peers := NewPeerManager(p2p, handshaker)
+	p2p.peers = peers
+
+	// Block sync. Every node serves blocks to replicas.
+	syncer := NewSyncer(seq, p2p.Host)
+	syncer.peers = peers
+
+	// Connectivity watchdog, for replicas.
+	watchdog := NewWatchdog(p2p)
 
 	node := SequencerNode{
 		Seq: seq,
 		P2P: p2p,
+		blocks: p2p,
 		RPC: rpc,
+		Syncer: syncer,
+		Handshaker: handshaker,
+		Peers: peers,
+		Watchdog: watchdog,
 		Mode: mode,
 	}
 	
 	return &node
 }
 
-func (n *SequencerNode) Start() {
+// Disseminates blocks over the relay tree instead of gossip, with up to `fanout`
+// children, or the default for the node's mode if it's 0. Must be called before
+// the node starts.
+func (n *SequencerNode) UseRelayTree(fanout int) {
+	primary := n.Mode == PrimaryMode
+	if fanout == 0 {
+		fanout = DefaultRelayFanout
+		if primary {
+			fanout = DefaultRelayPrimaryFanout
+		}
+	}
+
+	n.Relay = NewP2PNode2(n.P2P.Host, primary, fanout)
+	n.Relay.protocol.metrics = n.Seq.Metrics
+	n.Relay.protocol.peers = n.Peers
+	n.Relay.ValidateBlocks(n.Seq.VerifyGossipBlock)
+	if primary {
+		n.Seq.Metrics.RelayDepth.Set(0)
+	} else {
+		n.Seq.Metrics.RelayDepth.Set(-1)
+	}
+	n.blocks = n.Relay
+	n.Handshaker.AddCapability(CapabilityRelayTree)
+}
+
+// Starts the node, and blocks until it fails. The caller should close the node then.
+func (n *SequencerNode) Start() (error) {
+	failed := make(chan error, 1)
+
 	// Hook them up.
 	if n.Mode == PrimaryMode {
+		// Blocks are gossipped in height order.
 		n.Seq.OnNewBlock(func (block *messages.Block) {
-			go n.P2P.GossipNewBlock(block)
+			n.blocks.GossipNewBlock(block)
 		})
+
+		// Let replicas find us if we move.
+		go n.P2P.AdvertisePrimary(n.Seq.SignAdvertisement)
+
+		// Finish the blocks we were signing when we stopped.
+		go func() {
+			err := n.Seq.ResumePending()
+			if err != nil {
+				coreLog.Warnw("error proposing pending blocks", "err", err)
+			}
+		}()
 	}
 
 	if n.Mode == ReplicaMode {
-		n.P2P.ListenForNewBlocks(func (block *messages.Block) {
+		go n.blocks.ListenForNewBlocks(func (block *messages.Block) {
 			n.Seq.ProcessBlock(block)
 		})
+		go n.P2P.FollowPrimary()
 		
-		if false {
-			go func(){
-				fmt.Println("P2P: bootstrapping P2P connections...")
-
-				// Wait until they're connected for the test.
-				waitConnectedP2P := make(chan bool)
-				numPeersToWaitForConnected := 3
-				go func() {
-					i := 0
-					host := n.P2P.Host
-
-					for true {
-						peers := host.Network().Peers()
-						fmt.Printf("waiting for connections (%2d): num_peers=%d\n", i, len(peers))
-						i++
-						
-						if len(peers) >= numPeersToWaitForConnected  {
-							waitConnectedP2P <- true
-							break
-						}
-
-						time.Sleep(1000 * time.Millisecond)
-					}
-				}()
-
-				<-waitConnectedP2P
-				fmt.Println("P2P: sufficiently connected!")
-			}()
-		}
-
-		// Now we just need a way for the replicas to sync up to the latest block.
-		// 1. Get the latest hash from the sequencer.
-		// 2. Gossip request all of the hashes from peers.
-		go func(){
-			// Fetch 1000 blocks at a time.
-			// What do we do? 
-			// Message "IWANT" to the P2P network.
-			// Select peers.
-			// Literally you want a DHT-like routing mechanism for this.
-		}()
-	}
-
-	var wg sync.WaitGroup
-	wg.Add(2)
-	go func() {
+		// Fail if we can't connect, and stay connected.
 		go func() {
-			defer wg.Done()
-			n.P2P.Start()
+			err := n.Watchdog.Run(context.Background())
+			if err != nil {
+				failed <- fmt.Errorf("couldn't connect to the network: %s", err)
+			}
 		}()
-		go func() {
-			defer wg.Done()
-			n.RPC.Start()
-		}()
-	}()
-	wg.Wait()
-}
 
-// Starts the routine to fetch missing block history.
-// The pubsub network is used for disseminating new blocks only.
-// Replicas sync blocks they've missed by requesting history from peers.
-func (n *SequencerNode) FetchHistory() {
+		// Sync the blocks we've missed from peers.
+		go n.Syncer.Run(context.Background())
+	}
+
+	if n.Relay != nil {
+		n.Relay.Start()
+	}
+	go n.Peers.Run(context.Background())
 
+	go n.P2P.Start()
+	go n.RPC.Start()
+	return <-failed
 }
 
 func (n *SequencerNode) Close() {
+	if err := n.Peers.Save(); err != nil {
+		p2pLog.Warnw("error saving peers", "err", err)
+	}
 	if err := n.P2P.Close(); err != nil {
 		panic(err)
 	}
+	// Syncs the last blocks to disk.
+	n.Seq.Close()
 }
\ No newline at end of file
@@ -5,10 +5,6 @@ import (
 	"fmt"
 	"time"
 
-	// "sync"
-	// discovery "github.com/libp2p/go-libp2p-discovery"
-	// dht "github.com/libp2p/go-libp2p-kad-dht"
-
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/golang/protobuf/proto"
 	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/messages"
@@ -16,21 +12,32 @@ import (
 	"github.com/libp2p/go-libp2p-core/crypto"
 	libp2pHost "github.com/libp2p/go-libp2p-core/host"
 	"github.com/libp2p/go-libp2p-core/peer"
+	dht "github.com/libp2p/go-libp2p-kad-dht"
+	"github.com/libp2p/go-libp2p/p2p/discovery/mdns"
 
-	"github.com/ipfs/go-log/v2"
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
 )
 
-var logger = log.Logger("p2p")
 const DHT_RENDEZVOUS_MAGIC = "goliath/sequencer/queen-st-hungry-jacks"
 const PUBSUB_TOPIC_NEW_BLOCKS = "NewBlocks"
 const PUBSUB_TOPIC_PEER_DISCOVERY = "PeerDiscovery"
+const PUBSUB_TOPIC_PRIMARY = "PrimaryAdvertisement"
 
 type P2PNode struct {
 	Host libp2pHost.Host
 	ctx context.Context
+	ps *pubsub.PubSub
 	newBlocks *pubsub.Topic
 	peerDiscovery *pubsub.Topic
+	primaryAds *pubsub.Topic
+	metrics *Metrics
+	gater *peerGater
+	peers *PeerManager
+
+	bootstrapPeers []peer.AddrInfo
+	// Peer discovery, if enabled.
+	dht *dht.IpfsDHT
+	mdns mdns.Service
 }
 
 func P2PGeneratePrivateKey() (crypto.PrivKey) {
@@ -63,9 +70,11 @@ func NewP2PNode(multiaddr string, privateKey crypto.PrivKey, bootstrapPeers Addr
 		privateKey = P2PGeneratePrivateKey()
 	}
 
+	gater := newPeerGater()
 	host, err := libp2p.New(
 		libp2p.ListenAddrStrings(multiaddr),
 		libp2p.Identity(privateKey),
+		libp2p.ConnectionGater(gater),
 	)
 	if err != nil {
 		panic(err)
@@ -73,7 +82,6 @@ func NewP2PNode(multiaddr string, privateKey crypto.PrivKey, bootstrapPeers Addr
 
 	ctx := context.Background()
 
-	// create a new PubSub service using the GossipSub router
 	bootstrapPeerInfos := []peer.AddrInfo{}
 	for _, addr := range(bootstrapPeers) {
 		peerinfo, err := peer.AddrInfoFromP2pAddr(addr)
@@ -83,44 +91,32 @@ func NewP2PNode(multiaddr string, privateKey crypto.PrivKey, bootstrapPeers Addr
 		bootstrapPeerInfos = append(bootstrapPeerInfos, *peerinfo)
 	}
 
-	// Gossip Sub.
-	// 
-	// gossipSubParams := pubsub.DefaultGossipSubParams()
-	// gossipSubParams.D = 2
-	// gossipSubParams.MaxIHaveLength = 30000
-	
-	// pubsub, err := pubsub.NewGossipSub(
-	// 	ctx, 
-	// 	host,
-	// 	pubsub.WithPeerExchange(true),
-	// 	pubsub.WithDirectPeers(bootstrapPeerInfos),
-	// 	// pubsub.WithGossipSubParams(gossipSubParams),
-	// 	// pubsub.WithFloodPublish(true),
-	// )
-
-	pubsub, err := pubsub.NewFloodSub(
+	// GossipSub, with peer scoring. Peers exchange the addresses of other peers when
+	// they're pruned from the mesh, so replicas don't all need to know each other.
+	scoreParams, scoreThresholds := peerScoreParams()
+	ps, err := pubsub.NewGossipSub(
 		ctx,
 		host,
+		pubsub.WithPeerExchange(true),
+		pubsub.WithPeerScore(scoreParams, scoreThresholds),
+		pubsub.WithMaxMessageSize(maxGossipBlockSize),
 	)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	// Connect to bootstrap peers.
-	for _, peerinfo := range(bootstrapPeerInfos) {
-		err = host.Connect(context.Background(), peerinfo)
-		if err != nil {
-			fmt.Printf("error connecting to peer %s: %s\n", peerinfo.ID.Pretty(), err)
-		}
+	// Join the pubsub topics.
+	newBlocks, err := ps.Join(topicName(PUBSUB_TOPIC_NEW_BLOCKS))
+	if err != nil {
+		return nil, err
 	}
 
-	// Join the pubsub topics.
-	newBlocks, err := pubsub.Join(topicName(PUBSUB_TOPIC_NEW_BLOCKS))
+	peerDiscovery, err := ps.Join(topicName(PUBSUB_TOPIC_PEER_DISCOVERY))
 	if err != nil {
 		return nil, err
 	}
 
-	peerDiscovery, err := pubsub.Join(topicName(PUBSUB_TOPIC_PEER_DISCOVERY))
+	primaryAds, err := ps.Join(topicName(PUBSUB_TOPIC_PRIMARY))
 	if err != nil {
 		return nil, err
 	}
@@ -128,38 +124,48 @@ func NewP2PNode(multiaddr string, privateKey crypto.PrivKey, bootstrapPeers Addr
 	node := &P2PNode{
 		Host: host,
 		ctx: ctx,
+		ps: ps,
 		newBlocks: newBlocks,
 		peerDiscovery: peerDiscovery,
+		primaryAds: primaryAds,
+		gater: gater,
+		bootstrapPeers: bootstrapPeerInfos,
 	}
 	
 	return node, nil
 }
 
 func (n *P2PNode) Start() {
-	fmt.Printf("P2P listening on %s\n", n.Host.Addrs()[0])
+	p2pLog.Infow("P2P listening", "addr", n.Host.Addrs()[0], "id", n.Host.ID())
+	n.connectBootstrapPeers()
+	n.startDiscovery()
 
 	// go n.BroadcastPresenceRoutine()
 	// go n.ListenForNewPeers()
 }
 
-// discoveryNotifee gets notified when we find a new peer
-// HandlePeerFound connects to peers discovered. Once they're connected,
-// the PubSub system will automatically start interacting with them if they also
-// support PubSub.
-func (n *P2PNode) HandlePeerFound(peerinfo peer.AddrInfo) {
-	isConnectedToPeer := len(n.Host.Peerstore().Addrs(peerinfo.ID)) > 0
-	if isConnectedToPeer {
-		return
-	}
-
-	fmt.Printf("connecting to new peer %s\n", peerinfo.ID.Pretty())
-	err := n.Host.Connect(context.Background(), peerinfo)
+// Connects to the bootstrap peers. This happens on start rather than when the node
+// is created, so the handshake is set up first.
+func (n *P2PNode) connectBootstrapPeers() {
+	for _, peerinfo := range(n.bootstrapPeers) {
+		// The genesis lists the primary as a bootstrap peer, which may be us.
+		if peerinfo.ID == n.Host.ID() {
+			continue
+		}
 
-	if err != nil {
-		fmt.Printf("error connecting to peer %s: %s\n", peerinfo.ID.Pretty(), err)
+		err := n.Host.Connect(n.ctx, peerinfo)
+		if err != nil {
+			p2pLog.Warnw("error connecting to peer", "peer", peerinfo.ID.Pretty(), "err", err)
+		}
 	}
 }
 
+// Disconnects the peer, and refuses to connect to it again for `d`.
+func (n *P2PNode) BlockPeer(id peer.ID, d time.Duration) {
+	n.gater.Block(id, d)
+	n.Host.Network().ClosePeer(id)
+}
+
 func (n *P2PNode) GossipNewBlock(block *messages.Block) {
 	buf, err := proto.Marshal(block)
 	if err != nil {
@@ -167,10 +173,13 @@ func (n *P2PNode) GossipNewBlock(block *messages.Block) {
 		panic(fmt.Errorf("error encoding block: %s", err))
 	}
 
-	fmt.Println("pubsub - gossip block:", block.PrettyHash())
+	p2pLog.Debugw("pubsub - gossip block", "height", block.Height, "hash", block.PrettyHash())
 	err = n.newBlocks.Publish(n.ctx, buf)
 	if err != nil {
-		fmt.Println(fmt.Errorf("error gossipping new block: %s", err))
+		p2pLog.Errorw("error gossipping new block", "height", block.Height, "err", err)
+		if n.metrics != nil {
+			n.metrics.GossipPublishFailures.Inc()
+		}
 	}
 }
 
@@ -189,10 +198,12 @@ func (n *P2PNode) ListenForNewBlocks(handler func(block *messages.Block)) {
 			return
 		}
 
-		block := &messages.Block{}
-		proto.Unmarshal(msg.Data, block)
+		block, ok := msg.ValidatorData.(*messages.Block)
+		if !ok {
+			continue
+		}
 
-		fmt.Printf("pubsub - new block: %s\n", block.PrettyString())
+		p2pLog.Debugw("pubsub - new block", "height", block.Height, "hash", block.PrettyHash())
 		handler(block)
 	}
 }
@@ -231,16 +242,17 @@ func (n *P2PNode) ListenForNewPeers() {
 		peerinfo := &peer.AddrInfo{}
 		err = peerinfo.UnmarshalJSON(msg.Data)
 		if err != nil {
-			fmt.Println(fmt.Errorf("error reading peerinfo gossip: %s", err))
+			p2pLog.Warnw("error reading peerinfo gossip", "err", err)
 			continue
 		}
 
-		fmt.Printf("pubsub - peerinfo gossip: %s\n", peerinfo.String())
+		p2pLog.Debugw("pubsub - peerinfo gossip", "peer", peerinfo.String())
 		n.HandlePeerFound(*peerinfo)
 	}
 }
 
 func (n *P2PNode) Close() (error) {
+	n.closeDiscovery()
 	return n.Host.Close()
 }
 
@@ -4,22 +4,17 @@ import (
 	"bufio"
 	"context"
 	"fmt"
-	"io"
+	"sort"
 	"sync"
 	"time"
 
-	// "sync"
-	// discovery "github.com/libp2p/go-libp2p-discovery"
-	// dht "github.com/libp2p/go-libp2p-kad-dht"
-
+	"github.com/golang/protobuf/proto"
 	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/messages"
-	"github.com/libp2p/go-libp2p"
-	"github.com/libp2p/go-libp2p-core/crypto"
 	libp2pHost "github.com/libp2p/go-libp2p-core/host"
 	"github.com/libp2p/go-libp2p-core/network"
 	"github.com/libp2p/go-libp2p-core/peer"
 	"github.com/libp2p/go-libp2p-core/protocol"
-	"github.com/libp2p/go-msgio/protoio"
+	"github.com/libp2p/go-msgio"
 	"github.com/whyrusleeping/timecache"
 )
 
@@ -37,368 +32,600 @@ import (
 // e.g.
 // max_outstreams = 8
 
+// Relay tree.
+//
+// An alternative to gossip, where blocks are streamed down a tree rooted at the primary.
+// Each node streams blocks to at most `fanout` children, over one long-lived stream per
+// child. The primary only streams to a few replicas, which relay to the rest.
+//
+// The tree organises itself. A node which isn't in the tree asks its peers for their
+// status - their path from the primary and their free slots - and attaches to the one
+// closest to the primary with a free slot. A node never attaches to a peer whose path
+// contains itself, so there are no cycles. Parents send their status to their children
+// whenever their path changes, and a node which loses its parent detaches its subtree
+// until it finds a new one.
+//
+// Each child has a bounded queue. Blocks are dropped for a child which falls behind,
+// and it picks them up through block sync. A child which keeps falling behind is
+// disconnected, so it attaches somewhere else.
+
+const protocolId = protocol.ID("/goliath/sequencer/v0.1.0")
+
+const (
+	// Children per replica.
+	DefaultRelayFanout = 8
+	// Children of the primary, which only streams to a few replicas.
+	DefaultRelayPrimaryFanout = 3
+
+	// Messages queued per child.
+	relayQueueSize = 1024
+	// A child is disconnected after this many blocks are dropped in a row.
+	relayMaxDropped = 256
+	// How often a node without a parent looks for one.
+	relayAttachInterval = 2 * time.Second
+	relayRequestTimeout = 10 * time.Second
+	relaySeenTTL = 2 * time.Minute
+)
+
+// DefaultMaximumMessageSize is 1mb.
+const DefaultMaxMessageSize = 1 << 20
+
 type P2PNode2 struct {
 	Host libp2pHost.Host
 	ctx context.Context
 
 	protocol *P2PProtocol
-	newBlocks chan messages.Block
-	// newBlocks *pubsub.Topic
-	// peerDiscovery *pubsub.Topic
 }
 
-// DefaultMaximumMessageSize is 1mb.
-const DefaultMaxMessageSize = 1 << 20
+type relayChild struct {
+	stream network.Stream
+	queue chan *messages.P2PMessage
+	// Number of blocks dropped in a row because the queue was full.
+	dropped int
+	done chan struct{}
+	closeOnce sync.Once
+}
 
+func (c *relayChild) close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.stream.Reset()
+	})
+}
 
 type P2PProtocol struct {
-	// atomic counter for seqnos
-	// NOTE: Must be declared at the top of the struct as we perform atomic
-	// operations on this field.
-	//
-	// See: https://golang.org/pkg/sync/atomic/#pkg-note-BUG
-	counter uint64
-
 	host libp2pHost.Host
+	ctx context.Context
+	primary bool
+	fanout int
+	metrics *Metrics
+	peers *PeerManager
 
-	// maxMessageSize is the maximum message size; it applies globally to all
-	// topics.
-	// maxMessageSize int
+	validate func(block *messages.Block) (error)
+	newBlockHandler func(block *messages.Block)
 
-	// size of the outbound message channel that we maintain for each peer
-	// peerOutboundQueueSize int
+	mu sync.Mutex
+	parent peer.ID
+	parentStream network.Stream
+	// Our path from the primary, ending with us. Empty if we're not in the tree.
+	path []peer.ID
+	children map[peer.ID]*relayChild
 
-	// incoming messages from other peers
-	incoming chan *messages.P2PMessage
+	seenMessagesMx sync.Mutex
+	seenMessages *timecache.TimeCache
+}
 
-	// a notification channel for new peer connections accumulated
-	// newPeers       chan struct{}
-	// newPeersPrioLk sync.RWMutex
-	// newPeersMx     sync.Mutex
-	// newPeersPend   map[peer.ID]struct{}
+func NewP2PProtocol(ctx context.Context, h libp2pHost.Host, primary bool, fanout int) (*P2PProtocol) {
+	p := &P2PProtocol{
+		host: h,
+		ctx: ctx,
+		primary: primary,
+		fanout: fanout,
+		children: make(map[peer.ID]*relayChild),
+		seenMessages: timecache.NewTimeCache(relaySeenTTL),
+	}
+	// The primary is the root.
+	if primary {
+		p.path = []peer.ID{h.ID()}
+	}
+	h.SetStreamHandler(protocolId, p.handleNewStream)
+	return p
+}
 
-	// a notification channel for new outoging peer streams
-	// newPeerStream chan network.Stream
+// Our status, as sent to peers. Must hold mu.
+func (p *P2PProtocol) status() (*messages.RelayStatus) {
+	status := &messages.RelayStatus{}
+	for _, id := range p.path {
+		status.Path = append(status.Path, []byte(id))
+	}
+	if 0 < len(p.path) {
+		status.FreeSlots = int64(p.fanout - len(p.children))
+	}
+	return status
+}
 
-	// // a notification channel for errors opening new peer streams
-	// newPeerError chan peer.ID
+// Sets our path from the primary, given our parent's. Returns false if it contains us.
+// Must hold mu.
+func (p *P2PProtocol) setPath(parentPath [][]byte) (bool) {
+	path := []peer.ID{}
+	for _, raw := range parentPath {
+		id := peer.ID(raw)
+		if id == p.host.ID() {
+			return false
+		}
+		path = append(path, id)
+	}
+	if 0 < len(path) {
+		path = append(path, p.host.ID())
+	}
+	p.path = path
 
-	// // a notification channel for when our peers die
-	// peerDead       chan struct{}
-	// peerDeadPrioLk sync.RWMutex
-	// peerDeadMx     sync.Mutex
-	// peerDeadPend   map[peer.ID]struct{}
-	// // backoff for retrying new connections to dead peers
-	// deadPeerBackoff *backoff
+	if p.metrics != nil {
+		p.metrics.RelayDepth.Set(float64(len(path) - 1))
+	}
 
+	// Tell the children.
+	status := p.status()
+	for _, child := range p.children {
+		select {
+		case child.queue <- &messages.P2PMessage{Status: status}:
+		default:
+		}
+	}
+	return true
+}
 
-	// sendMsg handles messages that have been validated
-	sendMsg chan *messages.P2PMessage
+func (p *P2PProtocol) attached() (bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return 0 < len(p.path)
+}
 
-	// peer blacklist
-	// blacklist     Blacklist
-	// blacklistPeer chan peer.ID
+// Returns false if the block has been seen before.
+func (p *P2PProtocol) markSeen(block *messages.Block) (bool) {
+	id := string(block.SigHash())
+	p.seenMessagesMx.Lock()
+	defer p.seenMessagesMx.Unlock()
+	if p.seenMessages.Has(id) {
+		return false
+	}
+	p.seenMessages.Add(id)
+	return true
+}
 
-	peers []peer.ID
-	// peers map[peer.ID]chan *messages.P2PMessage
+func newRelayReader(s network.Stream) (msgio.Reader) {
+	return msgio.NewVarintReaderSize(s, DefaultMaxMessageSize)
+}
 
-	inboundStreamsMx sync.Mutex
-	inboundStreams   map[peer.ID]network.Stream
+func readRelayMsg(r msgio.Reader) (*messages.P2PMessage, error) {
+	buf, err := r.ReadMsg()
+	if err != nil {
+		return nil, err
+	}
+	msg := &messages.P2PMessage{}
+	err = proto.Unmarshal(buf, msg)
+	r.ReleaseMsg(buf)
+	return msg, err
+}
 
-	seenMessagesMx sync.Mutex
-	seenMessages   *timecache.TimeCache
-	seenMsgTTL     time.Duration
+func writeRelayMsg(w msgio.Writer, msg *messages.P2PMessage) (error) {
+	buf, err := proto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return w.WriteMsg(buf)
+}
 
-	// generator used to compute the ID for a message
-	// idGen *msgIDGenerator
+// Writes a single message, eg. a request.
+func sendRelayMsg(s network.Stream, msg *messages.P2PMessage) (error) {
+	s.SetWriteDeadline(time.Now().Add(relayRequestTimeout))
+	return writeRelayMsg(msgio.NewVarintWriter(s), msg)
+}
 
-	// // key for signing messages; nil when signing is disabled
-	// signKey crypto.PrivKey
-	// // source ID for signed messages; corresponds to signKey, empty when signing is disabled.
-	// // If empty, the author and seq-nr are completely omitted from the messages.
-	// signID peer.ID
-	// // strict mode rejects all unsigned messages prior to validation
-	// signPolicy MessageSignaturePolicy
+// Peers open a stream to us to ask for our status, or to attach as a child.
+func (p *P2PProtocol) handleNewStream(s network.Stream) {
+	peer := s.Conn().RemotePeer()
 
+	s.SetReadDeadline(time.Now().Add(relayRequestTimeout))
+	r := newRelayReader(s)
+	msg, err := readRelayMsg(r)
+	if err != nil || msg.Request == nil {
+		p2pLog.Debugw("bad relay request", "peer", peer, "err", err)
+		s.Reset()
+		return
+	}
+	s.SetReadDeadline(time.Time{})
 
-	newBlockHandler func(block *messages.Block)
+	var child *relayChild
+	if msg.Request.Attach {
+		child = p.addChild(peer, s)
+	}
+	if child == nil {
+		p.mu.Lock()
+		status := p.status()
+		p.mu.Unlock()
+		sendRelayMsg(s, &messages.P2PMessage{Status: status})
+		s.Close()
+		return
+	}
 
-	ctx context.Context
+	p2pLog.Infow("relay child attached", "peer", peer)
+	go p.writeToChild(peer, child)
+
+	// The child doesn't send anything else. Wait for it to go.
+	readRelayMsg(r)
+	p.removeChild(peer, child)
 }
 
-const protocolId = protocol.ID("/goliath/sequencer/v0.1.0")
+// Adds a child, if we have a free slot. Returns nil otherwise.
+func (p *P2PProtocol) addChild(id peer.ID, s network.Stream) (*relayChild) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 
-func NewP2PProtocol(ctx context.Context, h libp2pHost.Host) (*P2PProtocol) {
-	i := &P2PProtocol{
-		host:                  h,
-		ctx:                   ctx,
-		incoming:              make(chan *messages.P2PMessage, 32),
-		// newPeers:              make(chan struct{}, 1),
-		// newPeersPend:          make(map[peer.ID]struct{}),
-		// newPeerStream:         make(chan network.Stream),
-		// newPeerError:          make(chan peer.ID),
-		// peerDead:              make(chan struct{}, 1),
-		// peerDeadPend:          make(map[peer.ID]struct{}),
-		// deadPeerBackoff:       newBackoff(ctx, 1000, BackoffCleanupInterval, MaxBackoffAttempts),
-		sendMsg:               make(chan *messages.P2PMessage, 32),
-		// peers:                 make(map[peer.ID]chan *messages.P2PMessage),
-		peers:                 []peer.ID{},
-		inboundStreams:        make(map[peer.ID]network.Stream),
-	}
-	go i.processLoop(i.ctx)
-	return i
-}
+	if len(p.path) == 0 || id == p.parent {
+		return nil
+	}
+	for _, other := range p.path {
+		if other == id {
+			return nil
+		}
+	}
+	if old, ok := p.children[id]; ok {
+		delete(p.children, id)
+		old.close()
+	}
+	if p.fanout <= len(p.children) {
+		return nil
+	}
 
-func (p *P2PProtocol) handleNewStream(s network.Stream) {
-	peer := s.Conn().RemotePeer()
+	child := &relayChild{
+		stream: s,
+		queue: make(chan *messages.P2PMessage, relayQueueSize),
+		done: make(chan struct{}),
+	}
+	p.children[id] = child
+	if p.metrics != nil {
+		p.metrics.RelayChildren.Set(float64(len(p.children)))
+	}
 
-	fmt.Println("new stream")
+	// Accept the child. It's the first message on the stream.
+	status := p.status()
+	status.Attached = true
+	child.queue <- &messages.P2PMessage{Status: status}
+	return child
+}
 
-	p.inboundStreamsMx.Lock()
-	p.peers = append(p.peers, peer)
-	other, dup := p.inboundStreams[peer]
-	if dup {
-		// log.Debugf("duplicate inbound stream from %s; resetting other stream", peer)
-		other.Reset()
+func (p *P2PProtocol) removeChild(id peer.ID, child *relayChild) {
+	p.mu.Lock()
+	if p.children[id] == child {
+		delete(p.children, id)
+		p2pLog.Infow("relay child detached", "peer", id)
+	}
+	if p.metrics != nil {
+		p.metrics.RelayChildren.Set(float64(len(p.children)))
 	}
-	p.inboundStreams[peer] = s
-	p.inboundStreamsMx.Unlock()
+	p.mu.Unlock()
+	child.close()
+}
 
-	defer func() {
-		p.inboundStreamsMx.Lock()
-		if p.inboundStreams[peer] == s {
-			delete(p.inboundStreams, peer)
-		}
-		p.inboundStreamsMx.Unlock()
-	}()
+// Writes the child's queue to its stream.
+func (p *P2PProtocol) writeToChild(id peer.ID, child *relayChild) {
+	bufw := bufio.NewWriter(child.stream)
+	w := msgio.NewVarintWriter(bufw)
 
-	r := protoio.NewDelimitedReader(s, DefaultMaxMessageSize)
 	for {
-		msg := &messages.P2PMessage{}
-		err := r.ReadMsg(msg)
-
-		if err != nil {
-			if err != io.EOF {
-				s.Reset()
-				fmt.Println("error reading rpc from %s: %s", s.Conn().RemotePeer(), err)
-			} else {
-				// Just be nice. They probably won't read this
-				// but it doesn't hurt to send it.
-				s.Close()
+		select {
+		case msg := <-child.queue:
+			child.stream.SetWriteDeadline(time.Now().Add(relayRequestTimeout))
+			err := writeRelayMsg(w, msg)
+			// Flush once the queue is empty, so bursts are batched.
+			if err == nil && len(child.queue) == 0 {
+				err = bufw.Flush()
 			}
-
+			if err != nil {
+				p2pLog.Debugw("error writing to relay child", "peer", id, "err", err)
+				p.removeChild(id, child)
+				return
+			}
+		case <-child.done:
 			return
-		}
-
-		// rpc.from = peer
-		select {
-		case p.incoming <- msg:
 		case <-p.ctx.Done():
-			// Close is useless because the other side isn't reading.
-			s.Reset()
 			return
 		}
 	}
 }
 
-// processLoop handles all inputs arriving on the channels
-func (p *P2PProtocol) processLoop(ctx context.Context) {
-	defer func() {
-		// Clean up go routines.
-		// for _, ch := range p.peers {
-		// 	close(ch)
-		// }
-		// p.peers = nil
-		// p.topics = nil
-	}()
+// Queues the block for our children.
+func (p *P2PProtocol) relay(block *messages.Block) {
+	msg := &messages.P2PMessage{Block: block}
+	slow := map[peer.ID]*relayChild{}
 
-	for {
+	p.mu.Lock()
+	for id, child := range p.children {
 		select {
-		case msg := <-p.incoming:
-			p.recvMessage(msg)
-		case msg := <-p.sendMsg:
-			p.sendMessage(msg)
-		case <-ctx.Done():
-			// log.Info("pubsub processloop shutting down")
-			return
+		case child.queue <- msg:
+			child.dropped = 0
+		default:
+			child.dropped++
+			if p.metrics != nil {
+				p.metrics.RelayDroppedBlocks.Inc()
+			}
+			if relayMaxDropped <= child.dropped {
+				slow[id] = child
+			}
 		}
 	}
-}
+	p.mu.Unlock()
 
-func (p *P2PProtocol) recvMessage(msg *messages.P2PMessage) {
-	if block := msg.GetBlock(); block != nil {
-		fmt.Printf("pubsub - new block: %s\n", block.PrettyHash())
-		if p.newBlockHandler != nil {
-			p.newBlockHandler(block)
-		}
+	for id, child := range slow {
+		p2pLog.Warnw("disconnecting slow relay child", "peer", id, "dropped", child.dropped)
+		p.removeChild(id, child)
 	}
 }
 
-func (proto *P2PProtocol) AddPeer(pid peer.ID) {
-	proto.peers = append(proto.peers, pid)
+func (p *P2PProtocol) publishBlock(block *messages.Block) (error) {
+	if !p.primary {
+		return fmt.Errorf("only the primary publishes blocks")
+	}
+	p.markSeen(block)
+	p.relay(block)
+	return nil
 }
 
-func (proto *P2PProtocol) sendMessage(msg *messages.P2PMessage) {
-	for _, peer := range proto.peers {
-		stream, err := proto.host.NewStream(proto.ctx, peer, protocolId)
-		if err != nil {
-			fmt.Println("error opening stream:", err)
-			continue
+// Handles a block from our parent.
+func (p *P2PProtocol) handleBlock(from peer.ID, block *messages.Block) (error) {
+	if !p.markSeen(block) {
+		return nil
+	}
+	if p.validate != nil {
+		err := p.validate(block)
+		// We have it already, but our children may not.
+		if err == errStaleBlock {
+			p.relay(block)
+			return nil
 		}
-
-		// var buf []byte
-		// buff := bytes.NewBuffer(buf)
-		
-		// w := protoio.NewDelimitedWriter(bufw)
-		// err = w.WriteMsg(msg)
-		
-		// if DefaultMaxMessageSize < buff.Len() {
-		// 	// message too big
-		// 	// panic
-		// 	panic("msg too big")
-		// }
-
-		bufw := bufio.NewWriter(stream)
-		w := protoio.NewDelimitedWriter(bufw)
-		err = w.WriteMsg(msg)
 		if err != nil {
-			fmt.Println("error writing to stream:", err)
-			continue
-		}
-
-		bufw.Flush()
-		if err != nil {
-			fmt.Println("error writing to stream:", err)
-			continue
+			p.peers.InvalidBlock(from)
+			return err
 		}
 	}
+	p.peers.ValidBlock(from)
 
-	// from := msg.ReceivedFrom
-	// topic := msg.GetTopic()
-	// out := rpcWithMessages(msg.Message)
-	// for pid := range fs.p.topics[topic] {
-	// 	if pid == from || pid == peer.ID(msg.GetFrom()) {
-	// 		continue
-	// 	}
+	p.mu.Lock()
+	handler := p.newBlockHandler
+	p.mu.Unlock()
+	if handler != nil {
+		handler(block)
+	}
 
-	// 	mch, ok := fs.p.peers[pid]
-	// 	if !ok {
-	// 		continue
-	// 	}
+	p.relay(block)
+	return nil
+}
 
-	// 	select {
-	// 	case mch <- out:
-	// 		fs.tracer.SendRPC(out, pid)
-	// 	default:
-	// 		log.Infof("dropping message to peer %s: queue full", pid)
-	// 		fs.tracer.DropRPC(out, pid)
-	// 		// Drop it. The peer is too slow.
-	// 	}
-	// }
+// Asks a peer for its status.
+func (p *P2PProtocol) requestStatus(id peer.ID) (*messages.RelayStatus, error) {
+	ctx, cancel := context.WithTimeout(p.ctx, relayRequestTimeout)
+	defer cancel()
+	s, err := p.host.NewStream(ctx, id, protocolId)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
 
-	// Really we just want to multicast this to all of our peers.
+	err = sendRelayMsg(s, &messages.P2PMessage{Request: &messages.RelayRequest{}})
+	if err != nil {
+		s.Reset()
+		return nil, err
+	}
+	s.SetReadDeadline(time.Now().Add(relayRequestTimeout))
+	msg, err := readRelayMsg(newRelayReader(s))
+	if err != nil {
+		s.Reset()
+		return nil, err
+	}
+	if msg.Status == nil {
+		return nil, fmt.Errorf("peer sent no status")
+	}
+	return msg.Status, nil
 }
 
-func (proto *P2PProtocol) publishBlock(block *messages.Block) (error) {
-	proto.sendMsg <- &messages.P2PMessage{
-		Block: block,
+// Looks for a parent whenever we don't have one. Used by replicas.
+func (p *P2PProtocol) runAttach() {
+	for {
+		if !p.attached() {
+			err := p.findParent()
+			if err != nil {
+				p2pLog.Debugw("couldn't find a relay parent", "err", err)
+			}
+		}
+
+		if !sleep(p.ctx, relayAttachInterval) {
+			return
+		}
 	}
-	return nil
 }
 
-func NewP2PNode2(multiaddr string, privateKey crypto.PrivKey, bootstrapPeers AddrList) (*P2PNode2, error) {
-	if privateKey == nil {
-		privateKey = P2PGeneratePrivateKey()
+// Attaches to the peer closest to the primary with a free slot.
+func (p *P2PProtocol) findParent() (error) {
+	type candidate struct {
+		id peer.ID
+		status *messages.RelayStatus
+	}
+	candidates := []candidate{}
+
+	for _, id := range p.host.Network().Peers() {
+		status, err := p.requestStatus(id)
+		if err != nil || status.FreeSlots < 1 || len(status.Path) == 0 {
+			continue
+		}
+		loop := false
+		for _, raw := range status.Path {
+			if peer.ID(raw) == p.host.ID() {
+				loop = true
+			}
+		}
+		if !loop {
+			candidates = append(candidates, candidate{id, status})
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i].status, candidates[j].status
+		if len(a.Path) != len(b.Path) {
+			return len(a.Path) < len(b.Path)
+		}
+		return a.FreeSlots > b.FreeSlots
+	})
+
+	for _, c := range candidates {
+		err := p.attach(c.id)
+		if err == nil {
+			return nil
+		}
+		p2pLog.Debugw("couldn't attach to relay parent", "peer", c.id, "err", err)
 	}
+	return fmt.Errorf("no peer with a free slot, out of %d", len(p.host.Network().Peers()))
+}
 
-	host, err := libp2p.New(
-		libp2p.ListenAddrStrings(multiaddr),
-		libp2p.Identity(privateKey),
-	)
+// Attaches to a parent, which then streams blocks to us.
+func (p *P2PProtocol) attach(id peer.ID) (error) {
+	ctx, cancel := context.WithTimeout(p.ctx, relayRequestTimeout)
+	defer cancel()
+	s, err := p.host.NewStream(ctx, id, protocolId)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
-	ctx := context.Background()
+	err = sendRelayMsg(s, &messages.P2PMessage{Request: &messages.RelayRequest{Attach: true}})
+	if err != nil {
+		s.Reset()
+		return err
+	}
+	s.SetReadDeadline(time.Now().Add(relayRequestTimeout))
+	r := newRelayReader(s)
+	msg, err := readRelayMsg(r)
+	if err != nil {
+		s.Reset()
+		return err
+	}
+	if msg.Status == nil || !msg.Status.Attached {
+		s.Close()
+		return fmt.Errorf("peer refused")
+	}
+	s.SetReadDeadline(time.Time{})
+
+	p.mu.Lock()
+	old := p.parentStream
+	p.parent, p.parentStream = id, s
+	ok := p.setPath(msg.Status.Path)
+	depth := len(p.path) - 1
+	p.mu.Unlock()
+	if old != nil {
+		old.Reset()
+	}
+	if !ok {
+		p.detach(s)
+		return fmt.Errorf("peer's path contains us")
+	}
 
-	// Setup protocol.
-	protocol := NewP2PProtocol(ctx, host)
-	host.SetStreamHandler(protocolId, protocol.handleNewStream)
+	p2pLog.Infow("attached to relay parent", "peer", id, "depth", depth)
+	go p.readFromParent(id, s, r)
+	return nil
+}
 
-	// Parse bootstrap peers.
-	bootstrapPeerInfos := []peer.AddrInfo{}
-	for _, addr := range(bootstrapPeers) {
-		peerinfo, err := peer.AddrInfoFromP2pAddr(addr)
+func (p *P2PProtocol) readFromParent(id peer.ID, s network.Stream, r msgio.Reader) {
+	for {
+		msg, err := readRelayMsg(r)
+		if err == nil {
+			err = p.handleParentMsg(id, s, msg)
+		}
 		if err != nil {
-			panic(err)
+			p2pLog.Infow("detached from relay parent", "peer", id, "err", err)
+			p.detach(s)
+			return
 		}
-		bootstrapPeerInfos = append(bootstrapPeerInfos, *peerinfo)
 	}
+}
 
-	// Connect to bootstrap peers.
-	for _, peerinfo := range(bootstrapPeerInfos) {
-		err = host.Connect(context.Background(), peerinfo)
+func (p *P2PProtocol) handleParentMsg(id peer.ID, s network.Stream, msg *messages.P2PMessage) (error) {
+	if msg.Block != nil {
+		err := p.handleBlock(id, msg.Block)
 		if err != nil {
-			fmt.Printf("error connecting to peer %s: %s\n", peerinfo.ID.Pretty(), err)
+			return fmt.Errorf("invalid block: %s", err)
 		}
+	}
 
-		protocol.AddPeer(peerinfo.ID)
+	if msg.Status != nil {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.parentStream != s {
+			return fmt.Errorf("no longer our parent")
+		}
+		if !p.setPath(msg.Status.Path) {
+			return fmt.Errorf("parent's path contains us")
+		}
+		if len(p.path) == 0 {
+			return fmt.Errorf("parent left the tree")
+		}
 	}
+	return nil
+}
 
+func (p *P2PProtocol) detach(s network.Stream) {
+	s.Reset()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.parentStream == s {
+		p.parent, p.parentStream = "", nil
+		p.setPath(nil)
+	}
+}
 
-	node := &P2PNode2{
+// Creates a relay tree node on the host. The primary is the root, with up to
+// `fanout` children, as is every replica once it's attached.
+func NewP2PNode2(host libp2pHost.Host, primary bool, fanout int) (*P2PNode2) {
+	ctx := context.Background()
+	return &P2PNode2{
 		Host: host,
 		ctx: ctx,
-		protocol: protocol,
+		protocol: NewP2PProtocol(ctx, host, primary, fanout),
 	}
-	
-	return node, nil
 }
 
 func (n *P2PNode2) Start() {
-	fmt.Printf("P2P listening on %s\n", n.Host.Addrs()[0])
-}
-
-// discoveryNotifee gets notified when we find a new peer
-// HandlePeerFound connects to peers discovered. Once they're connected,
-// the PubSub system will automatically start interacting with them if they also
-// support PubSub.
-func (n *P2PNode2) HandlePeerFound(peerinfo peer.AddrInfo) {
-	isConnectedToPeer := len(n.Host.Peerstore().Addrs(peerinfo.ID)) > 0
-	if isConnectedToPeer {
-		return
+	if !n.protocol.primary {
+		go n.protocol.runAttach()
 	}
+}
 
-	fmt.Printf("connecting to new peer %s\n", peerinfo.ID.Pretty())
-	err := n.Host.Connect(context.Background(), peerinfo)
-
-	if err != nil {
-		fmt.Printf("error connecting to peer %s: %s\n", peerinfo.ID.Pretty(), err)
+// Checks blocks with `verify` before they're handled and relayed. A parent which
+// sends an invalid block is detached from.
+func (n *P2PNode2) ValidateBlocks(verify func(block *messages.Block) (error)) {
+	n.protocol.validate = func(block *messages.Block) (error) {
+		err := verify(block)
+		if err == errStaleBlock && n.protocol.metrics != nil {
+			n.protocol.metrics.GossipRejectedBlocks.WithLabelValues("stale").Inc()
+		} else if err != nil && n.protocol.metrics != nil {
+			n.protocol.metrics.GossipRejectedBlocks.WithLabelValues("invalid").Inc()
+		}
+		return err
 	}
 }
 
 func (n *P2PNode2) GossipNewBlock(block *messages.Block) {
-	fmt.Println("pubsub - gossip block:", block.PrettyHash())
-	
-	err := n.protocol.publishBlock(block)
+	p2pLog.Debugw("relay - publish block", "height", block.Height, "hash", block.PrettyHash())
 
+	err := n.protocol.publishBlock(block)
 	if err != nil {
-		fmt.Println(fmt.Errorf("error gossipping new block: %s", err))
+		p2pLog.Errorw("error publishing new block", "height", block.Height, "err", err)
 	}
 }
 
-
-
 func (n *P2PNode2) ListenForNewBlocks(handler func(*messages.Block)) {
+	n.protocol.mu.Lock()
+	defer n.protocol.mu.Unlock()
 	n.protocol.newBlockHandler = func(block *messages.Block) {
-		fmt.Printf("pubsub - new block: %s\n", block.PrettyHash())
+		p2pLog.Debugw("relay - new block", "height", block.Height, "hash", block.PrettyHash())
 		handler(block)
 	}
 }
-
-
-
-func (n *P2PNode2) Close() (error) {
-	return n.Host.Close()
-}
-
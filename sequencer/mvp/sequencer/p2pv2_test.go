@@ -0,0 +1,95 @@
+package sequencer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/messages"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+func randomPeerID(t *testing.T) (peer.ID) {
+	id, err := peer.IDFromPrivateKey(P2PGeneratePrivateKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestRelaySetPath(t *testing.T) {
+	node := newTestP2PNode(t)
+	p := NewP2PProtocol(context.Background(), node.Host, false, DefaultRelayFanout)
+	us := node.Host.ID()
+	primary := randomPeerID(t)
+	parent := randomPeerID(t)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	assert.True(t, p.setPath([][]byte{[]byte(primary), []byte(parent)}))
+	assert.Equal(t, []peer.ID{primary, parent, us}, p.path)
+
+	// A parent below us would make a cycle.
+	assert.False(t, p.setPath([][]byte{[]byte(primary), []byte(us), []byte(parent)}))
+	assert.Equal(t, []peer.ID{primary, parent, us}, p.path, "path shouldn't change")
+
+	// Detached.
+	assert.True(t, p.setPath(nil))
+	assert.Empty(t, p.path)
+}
+
+func TestRelayTree(t *testing.T) {
+	primaryNode, aNode, bNode := newTestP2PNode(t), newTestP2PNode(t), newTestP2PNode(t)
+	connectTestNodes(t, aNode, primaryNode)
+	connectTestNodes(t, bNode, primaryNode)
+	connectTestNodes(t, bNode, aNode)
+
+	// With a fanout of 1, b has to attach below a.
+	primary := NewP2PNode2(primaryNode.Host, true, 1)
+	a := NewP2PNode2(aNode.Host, false, 1)
+	b := NewP2PNode2(bNode.Host, false, 1)
+	assert.NoError(t, a.protocol.findParent())
+	assert.NoError(t, b.protocol.findParent())
+	assert.Equal(t, []peer.ID{primaryNode.Host.ID(), aNode.Host.ID()}, a.protocol.path)
+	assert.Equal(t, []peer.ID{primaryNode.Host.ID(), aNode.Host.ID(), bNode.Host.ID()}, b.protocol.path)
+
+	aBlocks := make(chan *messages.Block, 2)
+	a.ListenForNewBlocks(func(block *messages.Block) { aBlocks <- block })
+	bBlocks := make(chan *messages.Block, 2)
+	b.ListenForNewBlocks(func(block *messages.Block) { bBlocks <- block })
+
+	block := firstTestBlock(t)
+	primary.GossipNewBlock(block)
+	primary.GossipNewBlock(block)
+	for _, blocks := range []chan *messages.Block{aBlocks, bBlocks} {
+		select {
+		case got := <-blocks:
+			assert.True(t, proto.Equal(block, got))
+		case <-time.After(5 * time.Second):
+			t.Fatal("block wasn't relayed")
+		}
+	}
+
+	// Blocks are only handled once.
+	time.Sleep(100 * time.Millisecond)
+	assert.Empty(t, aBlocks)
+	assert.Empty(t, bBlocks)
+
+	// Blocks a already has are still relayed to b.
+	a.ValidateBlocks(func(block *messages.Block) (error) {
+		return errStaleBlock
+	})
+	held := proto.Clone(block).(*messages.Block)
+	held.Height = 2
+	primary.GossipNewBlock(held)
+	select {
+	case got := <-bBlocks:
+		assert.Equal(t, int64(2), got.Height)
+	case <-time.After(5 * time.Second):
+		t.Fatal("held block wasn't relayed")
+	}
+	assert.Empty(t, aBlocks)
+}
@@ -0,0 +1,409 @@
+package sequencer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/peerstore"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// Peer management.
+//
+// Peers are scored on the blocks they deliver and how they answer sync requests.
+// Honest peers check blocks before relaying them, so a single forged block is
+// enough to get a peer banned for a while. Stale blocks cost nothing, since peers
+// behind us send them too. Malformed blocks and sync timeouts only move a peer to
+// the back of the queue for sync requests - peers on another version send malformed
+// blocks, so neither can get a peer banned on its own.
+// Scores decay towards 0, so peers are forgiven over time.
+//
+// Peers with a positive score are saved to disk, and reconnected to on the next boot.
+
+const (
+	peerMaxScore = 100
+	// Peers are banned when their score falls to this.
+	peerBanScore = -100
+	peerBanDuration = time.Hour
+	// Malformed blocks and sync failures alone can't take a peer's score below this.
+	peerScoreFloor = -50
+
+	validBlockScore = 1
+	invalidBlockScore = peerBanScore
+	malformedBlockScore = -10
+	syncResponseScore = 0.5
+	syncFailureScore = -1
+
+	// Every interval, scores decay by this factor, and known peers are saved.
+	peerScoreDecay = 0.9
+	peerManagerInterval = time.Minute
+	maxSavedPeers = 64
+)
+
+type peerRecord struct {
+	Score float64
+	ValidBlocks int64
+	InvalidBlocks int64
+	SyncResponses int64
+	SyncFailures int64
+	// Moving average of sync response times.
+	SyncLatency time.Duration
+	BannedUntil time.Time
+}
+
+// A peer, as reported by admin_peers.
+type PeerInfo struct {
+	ID string `json:"id"`
+	Addrs []string `json:"addrs"`
+	Connected bool `json:"connected"`
+	// From the handshake.
+	Mode string `json:"mode,omitempty"`
+	Height int64 `json:"height,omitempty"`
+	Score float64 `json:"score"`
+	ValidBlocks int64 `json:"valid_blocks"`
+	InvalidBlocks int64 `json:"invalid_blocks"`
+	SyncResponses int64 `json:"sync_responses"`
+	SyncFailures int64 `json:"sync_failures"`
+	SyncLatencyMs int64 `json:"sync_latency_ms"`
+	BannedUntil *time.Time `json:"banned_until,omitempty"`
+}
+
+// A known-good peer, saved between boots.
+type savedPeer struct {
+	ID string `json:"id"`
+	Addrs []string `json:"addrs"`
+	Score float64 `json:"score"`
+}
+
+type PeerManager struct {
+	p2p *P2PNode
+	handshaker *Handshaker
+	metrics *Metrics
+	// File to save known-good peers to. Empty to not save them.
+	path string
+
+	mu sync.Mutex
+	peers map[peer.ID]*peerRecord
+}
+
+func NewPeerManager(p2p *P2PNode, handshaker *Handshaker) (*PeerManager) {
+	return &PeerManager{
+		p2p: p2p,
+		handshaker: handshaker,
+		metrics: p2p.metrics,
+		peers: make(map[peer.ID]*peerRecord),
+	}
+}
+
+// Saves known-good peers to `path`, and loads the peers saved there before.
+// Must be called before the node starts.
+func (m *PeerManager) SetPeersFile(path string) (error) {
+	m.path = path
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("couldn't read peers file: %s", err)
+	}
+	saved := []savedPeer{}
+	err = json.Unmarshal(data, &saved)
+	if err != nil {
+		return fmt.Errorf("couldn't parse peers file %s: %s", path, err)
+	}
+
+	for _, p := range saved {
+		id, err := peer.Decode(p.ID)
+		if err != nil || id == m.p2p.Host.ID() {
+			continue
+		}
+		addrs := []ma.Multiaddr{}
+		for _, raw := range p.Addrs {
+			addr, err := ma.NewMultiaddr(raw)
+			if err == nil {
+				addrs = append(addrs, addr)
+			}
+		}
+		m.p2p.Host.Peerstore().AddAddrs(id, addrs, peerstore.AddressTTL)
+		m.peers[id] = &peerRecord{Score: p.Score}
+	}
+	p2pLog.Infow("loaded known peers", "count", len(saved), "path", path)
+	return nil
+}
+
+func (m *PeerManager) record(id peer.ID) (*peerRecord) {
+	r, ok := m.peers[id]
+	if !ok {
+		r = &peerRecord{}
+		m.peers[id] = r
+	}
+	return r
+}
+
+// Adjusts the peer's score, banning it if it falls too low.
+func (m *PeerManager) adjust(id peer.ID, delta float64, update func(r *peerRecord)) {
+	// Our own gossip is validated too.
+	if m == nil || id == "" || id == m.p2p.Host.ID() {
+		return
+	}
+
+	m.mu.Lock()
+	r := m.record(id)
+	update(r)
+	r.Score = math.Min(r.Score + delta, peerMaxScore)
+	ban := r.Score <= peerBanScore
+	m.mu.Unlock()
+
+	if ban {
+		m.Ban(id, peerBanDuration, fmt.Errorf("score fell to %d", peerBanScore))
+	}
+}
+
+// Records a valid block delivered by the peer.
+func (m *PeerManager) ValidBlock(id peer.ID) {
+	m.adjust(id, validBlockScore, func(r *peerRecord) {
+		r.ValidBlocks++
+	})
+}
+
+// Records an invalid block delivered by the peer.
+func (m *PeerManager) InvalidBlock(id peer.ID) {
+	m.adjust(id, invalidBlockScore, func(r *peerRecord) {
+		r.InvalidBlocks++
+		// A good score doesn't save it.
+		r.Score = math.Min(r.Score, 0)
+	})
+}
+
+// Records a block from the peer which couldn't be decoded, or was too large.
+func (m *PeerManager) MalformedBlock(id peer.ID) {
+	m.adjust(id, 0, func(r *peerRecord) {
+		r.InvalidBlocks++
+		// Only down to the floor.
+		r.Score = math.Max(r.Score + malformedBlockScore, math.Min(r.Score, peerScoreFloor))
+	})
+}
+
+// Records the peer answering a sync request in `latency`.
+func (m *PeerManager) SyncResponded(id peer.ID, latency time.Duration) {
+	m.adjust(id, syncResponseScore, func(r *peerRecord) {
+		r.SyncResponses++
+		if r.SyncLatency == 0 {
+			r.SyncLatency = latency
+		}
+		r.SyncLatency = (r.SyncLatency * 7 + latency) / 8
+	})
+}
+
+// Records the peer failing to answer a sync request.
+func (m *PeerManager) SyncFailed(id peer.ID) {
+	m.adjust(id, 0, func(r *peerRecord) {
+		r.SyncFailures++
+		// Only down to the floor.
+		r.Score = math.Max(r.Score + syncFailureScore, math.Min(r.Score, peerScoreFloor))
+	})
+}
+
+// Disconnects the peer, and refuses to connect to it for `d`.
+func (m *PeerManager) Ban(id peer.ID, d time.Duration, reason error) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	r := m.record(id)
+	r.BannedUntil = time.Now().Add(d)
+	// It starts afresh once the ban is over.
+	r.Score = 0
+	m.mu.Unlock()
+
+	p2pLog.Warnw("banning peer", "peer", id, "duration", d, "reason", reason)
+	if m.metrics != nil {
+		m.metrics.BannedPeers.Inc()
+	}
+	m.p2p.BlockPeer(id, d)
+}
+
+// Connects to a peer at `addr`, and lifts any ban on it.
+func (m *PeerManager) AddPeer(ctx context.Context, addr string) (error) {
+	info, err := peer.AddrInfoFromString(addr)
+	if err != nil {
+		return fmt.Errorf("invalid peer address %q: %s", addr, err)
+	}
+
+	m.mu.Lock()
+	m.record(info.ID).BannedUntil = time.Time{}
+	m.mu.Unlock()
+	m.p2p.gater.Unblock(info.ID)
+
+	m.p2p.Host.Peerstore().AddAddrs(info.ID, info.Addrs, peerstore.AddressTTL)
+	return m.p2p.Host.Connect(ctx, *info)
+}
+
+// Orders peers from the highest score to the lowest.
+func (m *PeerManager) Sort(peers []peer.ID) ([]peer.ID) {
+	if m == nil {
+		return peers
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	scores := map[peer.ID]float64{}
+	for _, id := range peers {
+		if r, ok := m.peers[id]; ok {
+			scores[id] = r.Score
+		}
+	}
+	sorted := append([]peer.ID{}, peers...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return scores[sorted[i]] > scores[sorted[j]]
+	})
+	return sorted
+}
+
+// Lists connected peers, and the peers we've scored.
+func (m *PeerManager) Peers() ([]PeerInfo) {
+	host := m.p2p.Host
+	ids := map[peer.ID]bool{}
+	for _, id := range host.Network().Peers() {
+		ids[id] = true
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id := range m.peers {
+		ids[id] = true
+	}
+
+	infos := []PeerInfo{}
+	for id := range ids {
+		info := PeerInfo{
+			ID: id.String(),
+			Addrs: []string{},
+			Connected: host.Network().Connectedness(id) == network.Connected,
+		}
+		for _, addr := range host.Peerstore().Addrs(id) {
+			info.Addrs = append(info.Addrs, addr.String())
+		}
+		if hs := m.handshaker.Peer(id); hs != nil {
+			info.Mode = hs.Mode
+			info.Height = hs.Height
+		}
+		if r, ok := m.peers[id]; ok {
+			info.Score = r.Score
+			info.ValidBlocks = r.ValidBlocks
+			info.InvalidBlocks = r.InvalidBlocks
+			info.SyncResponses = r.SyncResponses
+			info.SyncFailures = r.SyncFailures
+			info.SyncLatencyMs = r.SyncLatency.Milliseconds()
+			if time.Now().Before(r.BannedUntil) {
+				until := r.BannedUntil
+				info.BannedUntil = &until
+			}
+		}
+		infos = append(infos, info)
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Score > infos[j].Score
+	})
+	return infos
+}
+
+// Reconnects to the saved peers, then decays scores and saves peers periodically.
+func (m *PeerManager) Run(ctx context.Context) {
+	m.mu.Lock()
+	saved := []peer.ID{}
+	for id := range m.peers {
+		saved = append(saved, id)
+	}
+	m.mu.Unlock()
+	for _, id := range saved {
+		if id == m.p2p.Host.ID() {
+			continue
+		}
+		go func(id peer.ID) {
+			err := m.p2p.Host.Connect(ctx, m.p2p.Host.Peerstore().PeerInfo(id))
+			if err != nil {
+				p2pLog.Debugw("error connecting to known peer", "peer", id, "err", err)
+			}
+		}(id)
+	}
+
+	for sleep(ctx, peerManagerInterval) {
+		m.decay()
+		err := m.Save()
+		if err != nil {
+			p2pLog.Warnw("error saving peers", "err", err)
+		}
+	}
+}
+
+func (m *PeerManager) decay() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, r := range m.peers {
+		r.Score *= peerScoreDecay
+		// Forget peers which have nothing to remember them by.
+		forgotten := math.Abs(r.Score) < 0.01 && time.Now().After(r.BannedUntil)
+		if forgotten && m.p2p.Host.Network().Connectedness(id) != network.Connected {
+			delete(m.peers, id)
+		}
+	}
+}
+
+// Saves the best peers with a positive score to the peers file.
+func (m *PeerManager) Save() (error) {
+	if m == nil || m.path == "" {
+		return nil
+	}
+
+	m.mu.Lock()
+	saved := []savedPeer{}
+	for id, r := range m.peers {
+		if r.Score <= 0 {
+			continue
+		}
+		p := savedPeer{ID: id.String(), Score: r.Score}
+		for _, addr := range m.p2p.Host.Peerstore().Addrs(id) {
+			p.Addrs = append(p.Addrs, addr.String())
+		}
+		if 0 < len(p.Addrs) {
+			saved = append(saved, p)
+		}
+	}
+	m.mu.Unlock()
+
+	sort.Slice(saved, func(i, j int) bool {
+		return saved[i].Score > saved[j].Score
+	})
+	if maxSavedPeers < len(saved) {
+		saved = saved[:maxSavedPeers]
+	}
+
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(m.path), 0755)
+	if err != nil {
+		return err
+	}
+	// Write and rename, so a crash doesn't leave a torn file.
+	tmp := m.path + ".tmp"
+	err = os.WriteFile(tmp, append(data, '\n'), 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, m.path)
+}
@@ -0,0 +1,105 @@
+package sequencer
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/peerstore"
+	ma "github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeerManagerBan(t *testing.T) {
+	node := newTestP2PNode(t)
+	peers := NewPeerManager(node, nil)
+
+	// Sync failures can't get a peer banned.
+	slow := randomPeerID(t)
+	for i := 0; i < 200; i++ {
+		peers.SyncFailed(slow)
+	}
+	assert.Equal(t, float64(peerScoreFloor), peers.peers[slow].Score)
+	assert.False(t, node.gater.Blocked(slow))
+
+	// Nor can malformed blocks.
+	malformed := randomPeerID(t)
+	peers.MalformedBlock(malformed)
+	assert.Equal(t, float64(malformedBlockScore), peers.peers[malformed].Score)
+	for i := 0; i < 20; i++ {
+		peers.MalformedBlock(malformed)
+	}
+	assert.Equal(t, float64(peerScoreFloor), peers.peers[malformed].Score)
+	assert.Equal(t, int64(21), peers.peers[malformed].InvalidBlocks)
+	assert.False(t, node.gater.Blocked(malformed))
+
+	// A peer at the floor is still banned for a forged block.
+	peers.InvalidBlock(malformed)
+	assert.True(t, node.gater.Blocked(malformed))
+
+	// A forged block gets it banned, even with a good score.
+	forger := randomPeerID(t)
+	peers.ValidBlock(forger)
+	peers.InvalidBlock(forger)
+	assert.True(t, node.gater.Blocked(forger))
+	r := peers.peers[forger]
+	assert.Equal(t, int64(1), r.InvalidBlocks)
+	assert.Equal(t, float64(0), r.Score, "score should start afresh after the ban")
+	assert.True(t, time.Now().Add(peerBanDuration - time.Minute).Before(r.BannedUntil))
+
+	// Our own blocks aren't scored.
+	peers.InvalidBlock(node.Host.ID())
+	assert.False(t, node.gater.Blocked(node.Host.ID()))
+}
+
+func TestPeerManagerDecay(t *testing.T) {
+	node := newTestP2PNode(t)
+	peers := NewPeerManager(node, nil)
+
+	good := randomPeerID(t)
+	for i := 0; i < 10; i++ {
+		peers.ValidBlock(good)
+	}
+	banned := randomPeerID(t)
+	peers.InvalidBlock(banned)
+
+	peers.decay()
+	assert.InDelta(t, 10 * peerScoreDecay, peers.peers[good].Score, 0.0001)
+
+	// Disconnected peers are forgotten once their score decays away, unless
+	// they're still banned.
+	for i := 0; i < 100; i++ {
+		peers.decay()
+	}
+	assert.NotContains(t, peers.peers, good)
+	assert.Contains(t, peers.peers, banned)
+}
+
+func TestPeerManagerSave(t *testing.T) {
+	node := newTestP2PNode(t)
+	peers := NewPeerManager(node, nil)
+	path := filepath.Join(t.TempDir(), "peers", "peers.json")
+	assert.NoError(t, peers.SetPeersFile(path))
+
+	addr, _ := ma.NewMultiaddr("/ip4/127.0.0.1/tcp/4001")
+	good, better, bad := randomPeerID(t), randomPeerID(t), randomPeerID(t)
+	for _, id := range []peer.ID{good, better, bad} {
+		node.Host.Peerstore().AddAddr(id, addr, peerstore.PermanentAddrTTL)
+	}
+	peers.ValidBlock(good)
+	peers.ValidBlock(better)
+	peers.SyncResponded(better, time.Millisecond)
+	peers.SyncFailed(bad)
+	assert.Equal(t, []peer.ID{better, good, bad}, peers.Sort([]peer.ID{bad, good, better}))
+	assert.NoError(t, peers.Save())
+
+	// Only peers with a positive score are saved.
+	other := newTestP2PNode(t)
+	loaded := NewPeerManager(other, nil)
+	assert.NoError(t, loaded.SetPeersFile(path))
+	assert.Len(t, loaded.peers, 2)
+	assert.Equal(t, float64(validBlockScore + syncResponseScore), loaded.peers[better].Score)
+	assert.Equal(t, []ma.Multiaddr{addr}, other.Host.Peerstore().Addrs(good))
+	assert.NotContains(t, loaded.peers, bad)
+}
@@ -0,0 +1,111 @@
+package sequencer
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/messages"
+)
+
+// Pending blocks.
+//
+// Remote signers have slashing protection - once they've signed a block, they refuse
+// to sign any other block at its height. So the primary saves each block before asking
+// for a signature, and until it's stored and synced, it's proposed again before any
+// new block. Otherwise a crash, or a committee which only partly co-signed, would
+// leave the primary unable to produce a block at that height.
+
+// Signers which see the whole block can refuse a conflicting one.
+func (s *SequencerCore) protectedSigner() (bool) {
+	_, signsBlocks := s.signer.(messages.BlockSigner)
+	_, coSignsBlocks := s.signer.(messages.BlockCoSigner)
+	return signsBlocks || coSignsBlocks
+}
+
+func (s *SequencerCore) savePending(block *messages.Block) (error) {
+	buf, err := proto.Marshal(block)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec("INSERT OR REPLACE INTO pending_blocks values (?, ?)", block.Height, buf)
+	if err != nil {
+		return fmt.Errorf("error saving pending block: %s", err)
+	}
+	return nil
+}
+
+// Forgets pending blocks up to `height`, once they're synced.
+func (s *SequencerCore) clearPending(height int64) (error) {
+	_, err := s.db.Exec("DELETE FROM pending_blocks WHERE height <= ?", height)
+	return err
+}
+
+// Loads the pending blocks which follow the last block.
+func (s *SequencerCore) loadPending() (error) {
+	rows, err := s.db.Query("SELECT block FROM pending_blocks WHERE height > ? ORDER BY height", s.LastBlock.Height)
+	if err != nil {
+		return fmt.Errorf("error reading pending blocks: %s", err)
+	}
+	defer rows.Close()
+
+	prev := s.LastBlock
+	for rows.Next() {
+		var buf []byte
+		err = rows.Scan(&buf)
+		if err != nil {
+			return err
+		}
+		block := &messages.Block{}
+		err = proto.Unmarshal(buf, block)
+		if err != nil {
+			return fmt.Errorf("error decoding pending block: %s", err)
+		}
+		// Left over from before a block was stored at its height.
+		if block.Height != prev.Height + 1 || !bytes.Equal(block.PrevBlockHash, prev.SigHash()) {
+			break
+		}
+		s.pending = append(s.pending, block)
+		prev = block
+	}
+	if 0 < len(s.pending) {
+		coreLog.Infow("loaded pending blocks", "from", s.pending[0].Height, "count", len(s.pending))
+	}
+	return rows.Err()
+}
+
+// Proposes the pending blocks again, then syncs and publishes them.
+func (s *SequencerCore) resumePending() (error) {
+	if !s.pendingLoaded {
+		err := s.loadPending()
+		if err != nil {
+			return err
+		}
+		s.pendingLoaded = true
+	}
+
+	blocks := []*messages.Block{}
+	for 0 < len(s.pending) {
+		block, err := s.proposeBlock(s.pending[0])
+		if err != nil {
+			if err := s.publishBlocks(blocks); err != nil {
+				return err
+			}
+			return fmt.Errorf("error proposing pending block %d again: %s", s.pending[0].Height, err)
+		}
+		blocks = append(blocks, block)
+		s.pending = s.pending[1:]
+	}
+	return s.publishBlocks(blocks)
+}
+
+// Proposes any blocks left pending from before a crash. The primary calls this on
+// start, rather than waiting for the next tx.
+func (s *SequencerCore) ResumePending() (error) {
+	return s.runOnLoop(func() (error) {
+		if s.signer == nil {
+			return nil
+		}
+		return s.resumePending()
+	})
+}
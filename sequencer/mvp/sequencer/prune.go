@@ -0,0 +1,270 @@
+package sequencer
+
+import (
+	"compress/gzip"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/archive"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/messages"
+)
+
+// Pruning.
+//
+// Archive nodes keep every block. Pruned nodes keep the bodies of the last `keepBlocks`
+// blocks, and only the headers of the blocks before them - enough to follow the hash
+// chain and rebuild the accumulator, but not to serve the blocks. Each header is
+// authenticated by the prevhash of the block after it.
+//
+// Blocks are pruned in segments, oldest first. If cold storage is configured, each
+// segment is first written to a gzipped block archive, which can be restored with
+// `sequencer import`.
+
+type StorageMode uint
+
+const (
+	ArchiveStorage StorageMode = iota
+	PrunedStorage
+)
+
+const (
+	DefaultKeepBlocks = 10000
+	// Number of blocks pruned at a time, and in each cold storage file.
+	pruneSegmentSize = 1000
+	pruneInterval = 10 * time.Second
+)
+
+func ParseStorageMode(mode string) (StorageMode, error) {
+	switch mode {
+	case "archive":
+		return ArchiveStorage, nil
+	case "pruned":
+		return PrunedStorage, nil
+	}
+	return 0, fmt.Errorf("unknown storage mode %q, expected archive or pruned", mode)
+}
+
+func (mode StorageMode) String() (string) {
+	if mode == PrunedStorage {
+		return "pruned"
+	}
+	return "archive"
+}
+
+// Sets the storage mode. In pruned mode, starts pruning the bodies of blocks older
+// than the last `keepBlocks`, exporting them to `coldStorage` first if it's non-empty.
+// Must be called before the node starts.
+func (s *SequencerCore) SetStorageMode(mode StorageMode, keepBlocks int64, coldStorage string) (error) {
+	if mode == PrunedStorage && keepBlocks < 1 {
+		return fmt.Errorf("pruned nodes must keep at least 1 block, got %d", keepBlocks)
+	}
+	if mode == PrunedStorage && coldStorage != "" {
+		err := os.MkdirAll(coldStorage, 0755)
+		if err != nil {
+			return fmt.Errorf("couldn't create cold storage directory: %s", err)
+		}
+	}
+
+	s.storageMode = mode
+	s.keepBlocks = keepBlocks
+	s.coldStorage = coldStorage
+
+	if mode == PrunedStorage {
+		coreLog.Infow("pruning enabled", "keep", keepBlocks, "coldstorage", coldStorage)
+		go s.runPruner()
+	}
+	return nil
+}
+
+func (s *SequencerCore) runPruner() {
+	for {
+		// Prune a segment at a time, so sequencing isn't blocked for long.
+		pruned := true
+		for pruned {
+			err := s.runOnLoop(func() (err error) {
+				pruned, err = s.pruneSegment()
+				return err
+			})
+			if err != nil {
+				coreLog.Errorw("error pruning blocks", "err", err)
+				break
+			}
+		}
+		time.Sleep(pruneInterval)
+	}
+}
+
+// Prunes the oldest segment of blocks outside the last `keepBlocks`. Returns false if
+// there was nothing to prune.
+func (s *SequencerCore) pruneSegment() (bool, error) {
+	from, to, err := s.store.PruneRange(s.LastBlock.Height - s.keepBlocks)
+	if err != nil || from == 0 {
+		return false, err
+	}
+
+	blocks, err := s.getBlocks(uint64(from), uint64(to))
+	if err != nil {
+		return false, err
+	}
+	// The segment stops early at a gap, eg. while backfilling after a fast-sync.
+	for i, block := range blocks {
+		if block.Height != from + int64(i) {
+			blocks = blocks[:i]
+			break
+		}
+	}
+	if len(blocks) == 0 {
+		return false, nil
+	}
+	to = blocks[len(blocks) - 1].Height
+
+	if s.coldStorage != "" {
+		err = s.exportSegment(blocks)
+		if err != nil {
+			return false, fmt.Errorf("error exporting blocks %d-%d to cold storage: %s", from, to, err)
+		}
+	}
+
+	// Headers are stored before the blocks are deleted. If we crash in between, the
+	// segment is pruned again.
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("error writing tx to db: %s", err)
+	}
+	for _, block := range blocks {
+		header := block.Header()
+		buf, err := proto.Marshal(header)
+		if err != nil {
+			tx.Rollback()
+			return false, err
+		}
+		_, err = tx.Exec("INSERT OR REPLACE INTO headers values (?, ?, ?)", header.Height, buf, header.Hash)
+		if err != nil {
+			tx.Rollback()
+			return false, fmt.Errorf("error writing tx to db: %s", err)
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		return false, err
+	}
+
+	err = s.store.Delete(from, to)
+	if err != nil {
+		return false, err
+	}
+
+	s.Metrics.PrunedBlocks.Add(float64(len(blocks)))
+	coreLog.Infow("pruned blocks", "from", from, "to", to)
+	return true, nil
+}
+
+// Writes the blocks to a gzipped archive in the cold storage directory. The file is
+// synced before it's renamed into place, so a segment file is never partially written.
+func (s *SequencerCore) exportSegment(blocks []*messages.Block) (error) {
+	name := fmt.Sprintf("blocks-%012d-%012d.gz", blocks[0].Height, blocks[len(blocks) - 1].Height)
+	path := filepath.Join(s.coldStorage, name)
+
+	file, err := os.Create(path + ".tmp")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	gz := gzip.NewWriter(file)
+	w, err := archive.NewWriter(gz)
+	if err != nil {
+		return err
+	}
+	for _, block := range blocks {
+		err = w.WriteBlock(block)
+		if err != nil {
+			return err
+		}
+	}
+	err = w.Flush()
+	if err != nil {
+		return err
+	}
+	err = gz.Close()
+	if err != nil {
+		return err
+	}
+	err = file.Sync()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(path + ".tmp", path)
+}
+
+// Returned when reading blocks whose bodies have been pruned.
+type PrunedError struct {
+	// The last pruned block in the range that was read.
+	Height int64
+	// The first block after it with a body.
+	Start int64
+}
+
+func (e *PrunedError) Error() (string) {
+	return fmt.Sprintf("block %d is pruned, this node keeps blocks from %d (query an archive node for older blocks)", e.Height, e.Start)
+}
+
+// The JSON-RPC error code.
+func (e *PrunedError) ErrorCode() (int) {
+	return -32001
+}
+
+// Returns a *PrunedError if any block between `from` and `to` is pruned.
+func (s *SequencerCore) checkPruned(from, to uint64) (error) {
+	var pruned sql.NullInt64
+	err := s.db.QueryRow("SELECT MAX(num) FROM headers WHERE num >= ? AND num <= ?", toHeight(from), toHeight(to)).Scan(&pruned)
+	if err != nil {
+		return fmt.Errorf("error fetching from db: %s", err)
+	}
+	if !pruned.Valid {
+		return nil
+	}
+
+	// The end of the run of pruned blocks.
+	var start sql.NullInt64
+	err = s.db.QueryRow(`
+		SELECT MIN(num) + 1 FROM headers h
+		WHERE num >= ? AND NOT EXISTS (SELECT 1 FROM headers WHERE num = h.num + 1)
+	`, pruned.Int64).Scan(&start)
+	if err != nil {
+		return fmt.Errorf("error fetching from db: %s", err)
+	}
+	return &PrunedError{
+		Height: pruned.Int64,
+		Start: start.Int64,
+	}
+}
+
+// Returns the header of the block at `height`, whether or not it's pruned.
+// Returns nil if there's no such block.
+func (s *SequencerCore) getHeader(height int64) (*messages.BlockHeader, error) {
+	var buf []byte
+	err := s.db.QueryRow("SELECT header FROM headers WHERE num = ?", height).Scan(&buf)
+	if err == sql.ErrNoRows {
+		blocks, err := s.getBlocks(uint64(height), uint64(height))
+		if err != nil || len(blocks) == 0 {
+			return nil, err
+		}
+		return blocks[0].Header(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching from db: %s", err)
+	}
+
+	header := &messages.BlockHeader{}
+	err = proto.Unmarshal(buf, header)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding db header: %s", err)
+	}
+	return header, nil
+}
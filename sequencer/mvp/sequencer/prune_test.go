@@ -0,0 +1,141 @@
+package sequencer
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/archive"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/messages"
+	"github.com/stretchr/testify/assert"
+)
+
+func readColdStorage(t *testing.T, path string) ([]*messages.Block) {
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := archive.NewReader(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blocks := []*messages.Block{}
+	for {
+		block, err := r.ReadBlock()
+		if err == io.EOF {
+			return blocks
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		blocks = append(blocks, block)
+	}
+}
+
+func TestPruneSegments(t *testing.T) {
+	seq, err := getMockSequencer(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Error(t, seq.SetStorageMode(PrunedStorage, 0, ""))
+
+	total := 2 * pruneSegmentSize + 100
+	sequenceTestBlocks(t, seq, total)
+	original, err := seq.GetBlocks(1, pruneSegmentSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Set directly rather than with SetStorageMode, so the pruner doesn't run in the background.
+	seq.storageMode = PrunedStorage
+	seq.keepBlocks = 50
+	seq.coldStorage = t.TempDir()
+	prune := func() (bool) {
+		var pruned bool
+		err := seq.runOnLoop(func() (err error) {
+			pruned, err = seq.pruneSegment()
+			return err
+		})
+		assert.NoError(t, err)
+		return pruned
+	}
+
+	// Whole segments outside the last `keepBlocks` are pruned, oldest first.
+	assert.True(t, prune())
+	assert.True(t, prune())
+	assert.False(t, prune())
+
+	_, err = seq.GetBlocks(1, 5)
+	assert.Equal(t, &PrunedError{Height: 5, Start: 2 * pruneSegmentSize + 1}, err)
+	blocks, err := seq.GetBlocks(2 * pruneSegmentSize + 1, 2 * pruneSegmentSize + 1)
+	assert.NoError(t, err)
+	assert.Len(t, blocks, 1)
+
+	// The headers are kept.
+	header, err := seq.getHeader(5)
+	assert.NoError(t, err)
+	assert.Equal(t, original[4].SigHash(), header.Hash)
+
+	// And the bodies are in cold storage.
+	cold := readColdStorage(t, filepath.Join(seq.coldStorage, "blocks-000000000001-000000001000.gz"))
+	assert.Equal(t, len(original), len(cold))
+	for i := range original {
+		assert.True(t, proto.Equal(original[i], cold[i]), "block %d differs", original[i].Height)
+	}
+	_, err = os.Stat(filepath.Join(seq.coldStorage, "blocks-000000001001-000000002000.gz"))
+	assert.NoError(t, err)
+}
+
+func TestVerifyPrunedChain(t *testing.T) {
+	seq, err := getMockSequencer(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sequenceTestBlocks(t, seq, pruneSegmentSize + 10)
+	seq.storageMode = PrunedStorage
+	seq.keepBlocks = 5
+	err = seq.runOnLoop(func() (error) {
+		_, err := seq.pruneSegment()
+		return err
+	})
+	assert.NoError(t, err)
+
+	report, err := seq.VerifyChain()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(pruneSegmentSize), report.Pruned)
+	assert.Equal(t, int64(10), report.Blocks)
+
+	// The operator's signature on pruned headers is checked too.
+	header, err := seq.getHeader(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header.Sig, err = randomTestSigner(t).Sign(header.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf, err := proto.Marshal(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = seq.db.Exec("UPDATE headers SET header = ? WHERE num = 5", buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = seq.VerifyChain()
+	inconsistency, ok := err.(*ChainInconsistency)
+	if !ok {
+		t.Fatalf("expected a *ChainInconsistency, got %v", err)
+	}
+	assert.Equal(t, int64(5), inconsistency.Row)
+	assert.Equal(t, CheckOperatorSig, inconsistency.Check)
+}
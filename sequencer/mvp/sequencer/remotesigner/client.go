@@ -0,0 +1,187 @@
+package remotesigner
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/messages"
+	"google.golang.org/protobuf/proto"
+)
+
+const requestTimeout = 10 * time.Second
+
+// A Signer for the operator key, which signs using a remote signing daemon.
+// The operator key never lives on the sequencing host.
+//
+// It only signs blocks, checkpoints and primary advertisements - the daemon refuses
+// to sign raw digests, as it couldn't apply slashing protection to them.
+type RemoteSigner struct {
+	baseURL string
+	client *http.Client
+	pubkey *ecdsa.PublicKey
+}
+
+// Connects to the signing daemon at `addr`, which is a unix:// socket path or an http(s):// URL.
+func NewRemoteSigner(addr string) (*RemoteSigner, error) {
+	s := &RemoteSigner{
+		baseURL: strings.TrimRight(addr, "/"),
+		client: &http.Client{Timeout: requestTimeout},
+	}
+
+	if strings.HasPrefix(addr, "unix://") {
+		path := strings.TrimPrefix(addr, "unix://")
+		s.baseURL = "http://signer"
+		s.client.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", path)
+			},
+		}
+	}
+
+	pubkeys := []string{}
+	err := s.get(publicKeysPath, &pubkeys)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get pubkey from remote signer: %s", err)
+	}
+	if len(pubkeys) == 0 {
+		return nil, fmt.Errorf("remote signer has no keys")
+	}
+
+	pubkeyBytes, err := hexutil.Decode(pubkeys[0])
+	if err != nil {
+		return nil, fmt.Errorf("remote signer returned invalid pubkey: %s", err)
+	}
+	s.pubkey, err = crypto.UnmarshalPubkey(pubkeyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("remote signer returned invalid pubkey: %s", err)
+	}
+
+	return s, nil
+}
+
+func (s *RemoteSigner) Sign(digestHash []byte) (sig []byte, err error) {
+	return nil, fmt.Errorf("remote signer only signs blocks, checkpoints and advertisements")
+}
+
+func (s *RemoteSigner) SignBlock(block *messages.Block) ([]byte, error) {
+	blockBuf, err := proto.Marshal(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.sign(signRequest{
+		Type: SignBlock,
+		Block: blockBuf,
+	}, block.SigHash(), ErrConflictingBlock)
+}
+
+func (s *RemoteSigner) SignCheckpoint(cp *messages.Checkpoint) ([]byte, error) {
+	cpBuf, err := proto.Marshal(cp)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.sign(signRequest{
+		Type: SignCheckpoint,
+		Checkpoint: cpBuf,
+	}, cp.SigHash(), ErrConflictingCheckpoint)
+}
+
+func (s *RemoteSigner) SignAdvertisement(ad *messages.SequencerPrimaryAdvertisement) ([]byte, error) {
+	adBuf, err := proto.Marshal(ad)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.sign(signRequest{
+		Type: SignAdvertisement,
+		Advertisement: adBuf,
+	}, ad.SigHash(), nil)
+}
+
+// Sends a sign request, and checks the signature over `digestHash` is from the operator.
+// A refusal by slashing protection is returned as `conflictErr`, if set.
+func (s *RemoteSigner) sign(req signRequest, digestHash []byte, conflictErr error) ([]byte, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.client.Post(s.baseURL + signPath + s.String(), "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("remote signer: %s", err)
+	}
+	defer res.Body.Close()
+
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("remote signer: %s", err)
+	}
+
+	switch {
+	case res.StatusCode == http.StatusOK:
+	case res.StatusCode == http.StatusPreconditionFailed && conflictErr != nil:
+		return nil, fmt.Errorf("%w: %s", conflictErr, strings.TrimSpace(string(resBody)))
+	default:
+		return nil, fmt.Errorf("remote signer: %s: %s", res.Status, strings.TrimSpace(string(resBody)))
+	}
+
+	sig, err := hexutil.Decode(strings.TrimSpace(string(resBody)))
+	if err != nil {
+		return nil, fmt.Errorf("remote signer returned invalid signature: %s", err)
+	}
+
+	// Don't trust the signer - check the signature is from the operator.
+	pubkey, err := crypto.SigToPub(digestHash, sig)
+	if err != nil || !pubkey.Equal(s.pubkey) {
+		return nil, fmt.Errorf("remote signer returned signature from the wrong key")
+	}
+
+	return sig, nil
+}
+
+// Checks the remote signer is up.
+func (s *RemoteSigner) Upcheck() (error) {
+	res, err := s.client.Get(s.baseURL + upcheckPath)
+	if err != nil {
+		return fmt.Errorf("remote signer: %s", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("remote signer: %s", res.Status)
+	}
+	return nil
+}
+
+func (s *RemoteSigner) GetPubkey() (*ecdsa.PublicKey) {
+	return s.pubkey
+}
+
+func (s *RemoteSigner) String() (string) {
+	return hexutil.Encode(crypto.FromECDSAPub(s.pubkey))
+}
+
+func (s *RemoteSigner) get(path string, v interface{}) (error) {
+	res, err := s.client.Get(s.baseURL + path)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s", res.Status)
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
@@ -0,0 +1,125 @@
+package remotesigner
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/messages"
+)
+
+// A Signer for a k-of-n operator committee. Each member runs its own signer
+// daemon with slashing protection, and the primary collects co-signatures
+// from them for every block and checkpoint.
+//
+// A member which has signed a block will refuse to sign a different one at the
+// same height. If the primary fails to reach the threshold, it keeps proposing the
+// same block until it does, so those members can sign it again.
+type CommitteeSigner struct {
+	committee *messages.Committee
+	members []*RemoteSigner
+}
+
+// Creates a signer for `committee`, using the members' remote signers.
+// Every signer must be for a member of the committee.
+func NewCommitteeSigner(committee *messages.Committee, members []*RemoteSigner) (*CommitteeSigner, error) {
+	for _, member := range members {
+		if !committee.IsMember(crypto.FromECDSAPub(member.GetPubkey())) {
+			return nil, fmt.Errorf("remote signer %s is not a member of the committee", member)
+		}
+	}
+	if len(members) < committee.Threshold {
+		return nil, fmt.Errorf("need at least %d signers for a %s, got %d", committee.Threshold, committee, len(members))
+	}
+
+	return &CommitteeSigner{
+		committee: committee,
+		members: members,
+	}, nil
+}
+
+type coSignResult struct {
+	sig []byte
+	err error
+}
+
+// Asks every member to sign the block, returning once the threshold is reached.
+func (s *CommitteeSigner) CoSignBlock(block *messages.Block) ([][]byte, error) {
+	return s.coSign([]interface{}{"type", "block", "height", block.Height}, func(member *RemoteSigner) ([]byte, error) {
+		return member.SignBlock(block)
+	})
+}
+
+// Asks every member to sign the checkpoint, returning once the threshold is reached.
+func (s *CommitteeSigner) CoSignCheckpoint(cp *messages.Checkpoint) ([][]byte, error) {
+	return s.coSign([]interface{}{"type", "checkpoint", "height", cp.Height}, func(member *RemoteSigner) ([]byte, error) {
+		return member.SignCheckpoint(cp)
+	})
+}
+
+// Asks every member to sign the advertisement, returning once the threshold is reached.
+func (s *CommitteeSigner) CoSignAdvertisement(ad *messages.SequencerPrimaryAdvertisement) ([][]byte, error) {
+	return s.coSign([]interface{}{"type", "advertisement", "timestamp", ad.Timestamp}, func(member *RemoteSigner) ([]byte, error) {
+		return member.SignAdvertisement(ad)
+	})
+}
+
+// `what` is logged with refusals, as key-value pairs.
+func (s *CommitteeSigner) coSign(what []interface{}, sign func(member *RemoteSigner) ([]byte, error)) ([][]byte, error) {
+	results := make(chan coSignResult, len(s.members))
+	for _, member := range s.members {
+		go func(member *RemoteSigner) {
+			sig, err := sign(member)
+			if err != nil {
+				err = fmt.Errorf("%s: %w", member, err)
+			}
+			results <- coSignResult{sig, err}
+		}(member)
+	}
+
+	sigs := [][]byte{}
+	errs := []string{}
+	for range s.members {
+		res := <-results
+		if res.err != nil {
+			signerLog.Warnw("committee member refused to sign", append(what, "err", res.err)...)
+			errs = append(errs, res.err.Error())
+			continue
+		}
+
+		sigs = append(sigs, res.sig)
+		if len(sigs) == s.committee.Threshold {
+			return sigs, nil
+		}
+	}
+
+	return nil, fmt.Errorf("only %d of %d required committee signatures: %s", len(sigs), s.committee.Threshold, strings.Join(errs, "; "))
+}
+
+func (s *CommitteeSigner) Sign(digestHash []byte) (sig []byte, err error) {
+	return nil, fmt.Errorf("committee signer only signs blocks, checkpoints and advertisements")
+}
+
+// Checks enough members are up to reach the threshold.
+func (s *CommitteeSigner) Upcheck() (error) {
+	up := 0
+	for _, member := range s.members {
+		if member.Upcheck() == nil {
+			up++
+		}
+	}
+	if up < s.committee.Threshold {
+		return fmt.Errorf("only %d of %d required committee signers are up", up, s.committee.Threshold)
+	}
+	return nil
+}
+
+// A committee has no single pubkey.
+func (s *CommitteeSigner) GetPubkey() (*ecdsa.PublicKey) {
+	return nil
+}
+
+func (s *CommitteeSigner) String() (string) {
+	return s.committee.String()
+}
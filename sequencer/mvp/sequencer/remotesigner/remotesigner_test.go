@@ -0,0 +1,191 @@
+package remotesigner
+
+import (
+	"database/sql"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/messages"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/utils"
+	"github.com/stretchr/testify/assert"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func newTestSigner(t *testing.T) (*RemoteSigner, utils.Signer) {
+	return newTestSignerForKey(t, "3fd7f88cb790c6a8b54d4e1aaebba6775f427bb8fa2276e933b7c3440f164caa")
+}
+
+func newTestSignerForKey(t *testing.T, privateKey string) (*RemoteSigner, utils.Signer) {
+	// Each signer gets its own database.
+	db, err := sql.Open("sqlite3", "file:" + privateKey + "?mode=memory")
+	assert.Nil(t, err)
+	protection, err := NewSlashingProtection(db)
+	assert.Nil(t, err)
+
+	operator := utils.NewEthereumECDSASigner(privateKey)
+	server := httptest.NewServer(NewServer(operator, protection).Handler())
+	t.Cleanup(server.Close)
+
+	signer, err := NewRemoteSigner(server.URL)
+	assert.Nil(t, err)
+	return signer, operator
+}
+
+func TestRemoteSignerSignsBlocks(t *testing.T) {
+	signer, operator := newTestSigner(t)
+	assert.True(t, operator.GetPubkey().Equal(signer.GetPubkey()))
+	assert.Nil(t, signer.Upcheck())
+
+	block := &messages.Block{
+		Height: 1,
+		PrevBlockHash: make([]byte, 32),
+		Body: &messages.SequenceTx{Data: []byte("hello")},
+	}
+	signed, err := block.SignWith(signer)
+	assert.Nil(t, err)
+
+	pubkey, err := crypto.SigToPub(signed.SigHash(), signed.Sig)
+	assert.Nil(t, err)
+	assert.True(t, pubkey.Equal(operator.GetPubkey()))
+
+	// Signing the same block again is fine.
+	_, err = block.SignWith(signer)
+	assert.Nil(t, err)
+
+	// Signing a different block at the same height is not.
+	conflicting := &messages.Block{
+		Height: 1,
+		PrevBlockHash: make([]byte, 32),
+		Body: &messages.SequenceTx{Data: []byte("goodbye")},
+	}
+	_, err = conflicting.SignWith(signer)
+	assert.True(t, errors.Is(err, ErrConflictingBlock))
+
+	// Raw digests are never signed.
+	_, err = signer.Sign(block.SigHash())
+	assert.NotNil(t, err)
+}
+
+func TestRemoteSignerSignsCheckpoints(t *testing.T) {
+	signer, operator := newTestSigner(t)
+
+	block := &messages.Block{
+		Height: 1,
+		PrevBlockHash: make([]byte, 32),
+		Body: &messages.SequenceTx{Data: []byte("hello")},
+	}
+	cp := &messages.Checkpoint{
+		Height: 1,
+		TipHash: block.SigHash(),
+		AccumulatorRoot: make([]byte, 32),
+	}
+
+	// Checkpoints are only signed for blocks the signer has signed.
+	_, err := cp.SignWith(signer)
+	assert.True(t, errors.Is(err, ErrConflictingCheckpoint))
+
+	_, err = block.SignWith(signer)
+	assert.Nil(t, err)
+	signed, err := cp.SignWith(signer)
+	assert.Nil(t, err)
+
+	pubkey, err := crypto.SigToPub(signed.SigHash(), signed.Sig)
+	assert.Nil(t, err)
+	assert.True(t, pubkey.Equal(operator.GetPubkey()))
+
+	// A checkpoint signature isn't a valid block signature.
+	pubkey, err = crypto.SigToPub(block.SigHash(), signed.Sig)
+	assert.True(t, err != nil || !pubkey.Equal(operator.GetPubkey()))
+
+	// Signing a different checkpoint at the same height is refused.
+	cp.AccumulatorRoot = crypto.Keccak256([]byte("other"))
+	_, err = cp.SignWith(signer)
+	assert.True(t, errors.Is(err, ErrConflictingCheckpoint))
+}
+
+func TestRemoteSignerSignsAdvertisements(t *testing.T) {
+	signer, operator := newTestSigner(t)
+
+	ad := &messages.SequencerPrimaryAdvertisement{
+		Multiaddress: [][]byte{[]byte("addr")},
+		PeerId: []byte("peer"),
+		Timestamp: 1,
+	}
+	signed, err := ad.SignWith(signer)
+	assert.Nil(t, err)
+
+	pubkey, err := crypto.SigToPub(signed.SigHash(), signed.Sig)
+	assert.Nil(t, err)
+	assert.True(t, pubkey.Equal(operator.GetPubkey()))
+
+	// Advertisements are re-signed as the primary's addresses change.
+	ad.Timestamp = 2
+	_, err = ad.SignWith(signer)
+	assert.Nil(t, err)
+}
+
+func TestCommitteeSigner(t *testing.T) {
+	members := []*RemoteSigner{}
+	committee := &messages.Committee{Threshold: 2}
+	for _, key := range []string{
+		"3fd7f88cb790c6a8b54d4e1aaebba6775f427bb8fa2276e933b7c3440f164caa",
+		"4fd7f88cb790c6a8b54d4e1aaebba6775f427bb8fa2276e933b7c3440f164caa",
+		"5fd7f88cb790c6a8b54d4e1aaebba6775f427bb8fa2276e933b7c3440f164caa",
+	} {
+		member, _ := newTestSignerForKey(t, key)
+		members = append(members, member)
+		committee.Pubkeys = append(committee.Pubkeys, crypto.FromECDSAPub(member.GetPubkey()))
+	}
+	assert.Nil(t, committee.Validate())
+
+	signer, err := NewCommitteeSigner(committee, members)
+	assert.Nil(t, err)
+	assert.Nil(t, signer.Upcheck())
+
+	block := &messages.Block{
+		Height: 1,
+		PrevBlockHash: make([]byte, 32),
+		Body: &messages.SequenceTx{Data: []byte("hello")},
+	}
+	signed, err := block.SignWith(signer)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(signed.Sigs))
+	assert.Nil(t, committee.VerifyBlock(signed))
+
+	// One signature isn't enough, and neither is the same signature twice.
+	signed.Sigs = [][]byte{signed.Sigs[0]}
+	assert.NotNil(t, committee.VerifyBlock(signed))
+	signed.Sigs = [][]byte{signed.Sigs[0], signed.Sigs[0]}
+	assert.NotNil(t, committee.VerifyBlock(signed))
+
+	// A single member can't sign a conflicting block.
+	conflicting := &messages.Block{
+		Height: 1,
+		PrevBlockHash: make([]byte, 32),
+		Body: &messages.SequenceTx{Data: []byte("goodbye")},
+	}
+	rogue := conflicting.Signed(utils.NewEthereumECDSASigner("3fd7f88cb790c6a8b54d4e1aaebba6775f427bb8fa2276e933b7c3440f164caa"))
+	rogue.Sigs = [][]byte{rogue.Sig}
+	assert.NotNil(t, committee.VerifyBlock(rogue))
+
+	// Nor can the committee, as the members which signed the first block refuse.
+	_, err = conflicting.SignWith(signer)
+	assert.NotNil(t, err)
+
+	// Checkpoints are co-signed too.
+	cp := &messages.Checkpoint{
+		Height: 1,
+		TipHash: block.SigHash(),
+		AccumulatorRoot: make([]byte, 32),
+	}
+	signedCp, err := cp.SignWith(signer)
+	assert.Nil(t, err)
+	assert.Nil(t, committee.Verify(signedCp.SigHash(), signedCp.Sigs))
+
+	// A minority threshold isn't allowed.
+	committee.Threshold = 1
+	assert.NotNil(t, committee.Validate())
+}
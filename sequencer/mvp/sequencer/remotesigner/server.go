@@ -0,0 +1,220 @@
+package remotesigner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ipfs/go-log/v2"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/messages"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/utils"
+	"google.golang.org/protobuf/proto"
+)
+
+// A signing daemon for the operator key, with a Web3Signer-style HTTP API.
+//
+//   GET  /upcheck                        - "OK" if the signer is up.
+//   GET  /api/v1/eth1/publicKeys         - JSON array of the operator pubkeys.
+//   POST /api/v1/eth1/sign/{pubkey}      - signs a request, returning the 0x-hex signature.
+//
+// Sign requests are {"type": "BLOCK", "block": "0x<protobuf-encoded block>"},
+// {"type": "CHECKPOINT", "checkpoint": "0x<protobuf-encoded checkpoint>"}, or
+// {"type": "ADVERTISEMENT", "advertisement": "0x<protobuf-encoded advertisement>"}.
+// The signer computes the digest itself, so it always knows the height it's signing at.
+// Conflicting blocks and checkpoints are refused with 412 Precondition Failed.
+
+var signerLog = log.Logger("signer")
+
+const (
+	upcheckPath = "/upcheck"
+	publicKeysPath = "/api/v1/eth1/publicKeys"
+	signPath = "/api/v1/eth1/sign/"
+)
+
+type SignRequestType string
+
+const (
+	SignBlock SignRequestType = "BLOCK"
+	SignCheckpoint SignRequestType = "CHECKPOINT"
+	SignAdvertisement SignRequestType = "ADVERTISEMENT"
+)
+
+type signRequest struct {
+	Type SignRequestType `json:"type"`
+	Block hexutil.Bytes `json:"block,omitempty"`
+	Checkpoint hexutil.Bytes `json:"checkpoint,omitempty"`
+	Advertisement hexutil.Bytes `json:"advertisement,omitempty"`
+}
+
+type Server struct {
+	signer utils.Signer
+	pubkey string
+	protection *SlashingProtection
+}
+
+func NewServer(signer utils.Signer, protection *SlashingProtection) (*Server) {
+	return &Server{
+		signer: signer,
+		pubkey: signer.String(),
+		protection: protection,
+	}
+}
+
+func (s *Server) Handler() (http.Handler) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(upcheckPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	})
+	mux.HandleFunc(publicKeysPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode([]string{s.pubkey})
+	})
+	mux.HandleFunc(signPath, s.handleSign)
+	return mux
+}
+
+func (s *Server) handleSign(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	identifier := strings.TrimPrefix(r.URL.Path, signPath)
+	if !strings.EqualFold(identifier, s.pubkey) {
+		http.Error(w, fmt.Sprintf("unknown key %s", identifier), http.StatusNotFound)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1 << 20))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	req := signRequest{}
+	err = json.Unmarshal(body, &req)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	var (
+		sig []byte
+		height int64
+		hash string
+	)
+	switch req.Type {
+	case SignBlock:
+		block := &messages.Block{}
+		err = proto.Unmarshal(req.Block, block)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid block: %s", err), http.StatusBadRequest)
+			return
+		}
+		height, hash = block.Height, block.PrettyHash()
+		sig, err = s.signBlock(block)
+	case SignCheckpoint:
+		cp := &messages.Checkpoint{}
+		err = proto.Unmarshal(req.Checkpoint, cp)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid checkpoint: %s", err), http.StatusBadRequest)
+			return
+		}
+		height, hash = cp.Height, cp.PrettyHash()
+		sig, err = s.signCheckpoint(cp)
+	case SignAdvertisement:
+		ad := &messages.SequencerPrimaryAdvertisement{}
+		err = proto.Unmarshal(req.Advertisement, ad)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid advertisement: %s", err), http.StatusBadRequest)
+			return
+		}
+		// Advertisements can't conflict, so they aren't recorded.
+		sig, err = s.signer.Sign(ad.SigHash())
+	default:
+		http.Error(w, fmt.Sprintf("unsupported request type: %s", req.Type), http.StatusBadRequest)
+		return
+	}
+
+	if errors.Is(err, ErrConflictingBlock) || errors.Is(err, ErrConflictingCheckpoint) {
+		signerLog.Warnw("refused to sign", "type", req.Type, "height", height, "hash", hash, "err", err)
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+	if err != nil {
+		signerLog.Errorw("error signing", "type", req.Type, "height", height, "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	signerLog.Debugw("signed", "type", req.Type, "height", height, "hash", hash)
+	w.Header().Set("Content-Type", "text/plain")
+	w.Write([]byte(hexutil.Encode(sig)))
+}
+
+func (s *Server) signBlock(block *messages.Block) ([]byte, error) {
+	signingRoot := block.SigHash()
+	pubkey := crypto.FromECDSAPub(s.signer.GetPubkey())
+
+	err := s.protection.CheckAndRecord(pubkey, block.Height, signingRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.signer.Sign(signingRoot)
+}
+
+// Checkpoints are only signed for blocks this signer has signed, so a compromised
+// primary can't get a checkpoint for a chain the operator never signed.
+func (s *Server) signCheckpoint(cp *messages.Checkpoint) ([]byte, error) {
+	signingRoot := cp.SigHash()
+	pubkey := crypto.FromECDSAPub(s.signer.GetPubkey())
+
+	err := s.protection.CheckAndRecordCheckpoint(pubkey, cp.Height, cp.TipHash, signingRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.signer.Sign(signingRoot)
+}
+
+// Serves the signer on `addr`, which is a unix:// socket path or a TCP host:port.
+func (s *Server) ListenAndServe(addr string) (error) {
+	listener, err := Listen(addr)
+	if err != nil {
+		return err
+	}
+	signerLog.Infow("signer listening", "addr", addr, "pubkey", s.pubkey)
+	return http.Serve(listener, s.Handler())
+}
+
+// Listens on `addr`, which is a unix:// socket path or a TCP host:port.
+func Listen(addr string) (net.Listener, error) {
+	if strings.HasPrefix(addr, "unix://") {
+		path := strings.TrimPrefix(addr, "unix://")
+
+		// Remove a stale socket from a previous run.
+		os.Remove(path)
+		listener, err := net.Listen("unix", path)
+		if err != nil {
+			return nil, err
+		}
+
+		// Only the owner can connect.
+		err = os.Chmod(path, 0600)
+		if err != nil {
+			listener.Close()
+			return nil, err
+		}
+		return listener, nil
+	}
+
+	return net.Listen("tcp", strings.TrimPrefix(addr, "http://"))
+}
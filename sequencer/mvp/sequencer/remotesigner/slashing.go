@@ -0,0 +1,134 @@
+package remotesigner
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"sync"
+)
+
+// Slashing protection.
+//
+// The operator is slashed for equivocation - signing two different blocks at the same
+// height. The signer records every block it signs, and refuses to sign a block which
+// conflicts with one it has already signed. Signing the same block again is allowed -
+// the primary saves each block before asking for a signature, and proposes the same
+// block again if it crashes or signing fails before the block is stored. Checkpoints
+// are protected the same way, and only signed for blocks the signer has signed.
+
+var ErrConflictingBlock = fmt.Errorf("refusing to sign conflicting block")
+var ErrConflictingCheckpoint = fmt.Errorf("refusing to sign conflicting checkpoint")
+
+type SlashingProtection struct {
+	mu sync.Mutex
+	db *sql.DB
+}
+
+func NewSlashingProtection(db *sql.DB) (*SlashingProtection, error) {
+	_, err := db.Exec(`
+	CREATE TABLE IF NOT EXISTS signed_blocks (
+		pubkey BLOB,
+		height INTEGER,
+		signing_root BLOB,
+		PRIMARY KEY (pubkey, height)
+	);
+	CREATE TABLE IF NOT EXISTS signed_checkpoints (
+		pubkey BLOB,
+		height INTEGER,
+		signing_root BLOB,
+		PRIMARY KEY (pubkey, height)
+	);
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create slashing protection table: %s", err)
+	}
+
+	return &SlashingProtection{db: db}, nil
+}
+
+// Checks the block with `signingRoot` can be signed at `height`, and records it.
+// Returns ErrConflictingBlock if a different block was already signed at that height.
+func (p *SlashingProtection) CheckAndRecord(pubkey []byte, height int64, signingRoot []byte) (error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	tx, err := p.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	conflict, err := checkAndRecord(tx, "signed_blocks", pubkey, height, signingRoot)
+	if err != nil {
+		return err
+	}
+	if conflict != nil {
+		return fmt.Errorf("%w at height %d: already signed 0x%x", ErrConflictingBlock, height, conflict)
+	}
+	return tx.Commit()
+}
+
+// Checks the checkpoint with `signingRoot` can be signed at `height`, and records it.
+// Returns ErrConflictingCheckpoint if a different checkpoint was already signed at that
+// height, or the block `tipHash` wasn't signed by this signer.
+func (p *SlashingProtection) CheckAndRecordCheckpoint(pubkey []byte, height int64, tipHash []byte, signingRoot []byte) (error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	tx, err := p.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var signed []byte
+	err = tx.QueryRow(
+		"SELECT signing_root FROM signed_blocks WHERE pubkey = ? AND height = ?",
+		pubkey,
+		height,
+	).Scan(&signed)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if !bytes.Equal(signed, tipHash) {
+		return fmt.Errorf("%w at height %d: block 0x%x wasn't signed by this signer", ErrConflictingCheckpoint, height, tipHash)
+	}
+
+	conflict, err := checkAndRecord(tx, "signed_checkpoints", pubkey, height, signingRoot)
+	if err != nil {
+		return err
+	}
+	if conflict != nil {
+		return fmt.Errorf("%w at height %d: already signed 0x%x", ErrConflictingCheckpoint, height, conflict)
+	}
+	return tx.Commit()
+}
+
+// Records `signingRoot` at `height` in `table`. If a different root is already recorded,
+// returns it as `conflict`.
+func checkAndRecord(tx *sql.Tx, table string, pubkey []byte, height int64, signingRoot []byte) (conflict []byte, err error) {
+	var signed []byte
+	err = tx.QueryRow(
+		"SELECT signing_root FROM " + table + " WHERE pubkey = ? AND height = ?",
+		pubkey,
+		height,
+	).Scan(&signed)
+
+	switch {
+	case err == sql.ErrNoRows:
+		_, err = tx.Exec(
+			"INSERT INTO " + table + " (pubkey, height, signing_root) VALUES (?, ?, ?)",
+			pubkey,
+			height,
+			signingRoot,
+		)
+		return nil, err
+	case err != nil:
+		return nil, err
+	}
+
+	if !bytes.Equal(signed, signingRoot) {
+		return signed, nil
+	}
+	return nil, nil
+}
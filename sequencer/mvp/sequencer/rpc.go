@@ -1,18 +1,35 @@
 package sequencer
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"runtime"
+	"strings"
 
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/golang/protobuf/proto"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/messages"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
 )
 
 type RPCNode struct {
 	addr string
+	server *rpc.Server
 	httpServer http.Server
+	serveMux *http.ServeMux
+	wsHandler http.Handler
+
+	// The admin_ methods are served on a separate, loopback-only listener. Disabled if nil.
+	adminServer *http.Server
+
+	// gRPC is served on a separate port. Disabled if empty.
+	grpcAddr string
+	grpcServer *grpc.Server
 }
 
 type SequencerService struct {
@@ -29,11 +46,11 @@ func (s *SequencerService) Get(from, to uint64) ([]byte, error) {
 			const size = 64 << 10
 			buf := make([]byte, size)
 			buf = buf[:runtime.Stack(buf, false)]
-			fmt.Println("RPC method " + " crashed: " + fmt.Sprintf("%v\n%s", err, buf))
+			rpcLog.Errorw("RPC method crashed", "err", err, "stack", string(buf))
 			// errRes = errors.New("method handler crashed")
 		}
 	}()
-	fmt.Printf("rpc: get(%d, %d)\n", from, to)
+	rpcLog.Debugw("rpc: get", "from", from, "to", to)
 
 	reply, err := s.seq.Get(from, to)
 	if err != nil {
@@ -42,7 +59,7 @@ func (s *SequencerService) Get(from, to uint64) ([]byte, error) {
 
 	buf, err := proto.Marshal(reply)
 	if err != nil {
-		fmt.Println(err)
+		rpcLog.Errorw("error encoding reply", "err", err)
 		return nil, err
 	}
 
@@ -50,7 +67,7 @@ func (s *SequencerService) Get(from, to uint64) ([]byte, error) {
 }
 
 func (s *SequencerService) Info() ([]byte, error) {
-	fmt.Printf("rpc: info()")
+	rpcLog.Debug("rpc: info")
 	reply, err := s.seq.Info()
 	if err != nil {
 		return nil, err
@@ -64,14 +81,142 @@ func (s *SequencerService) Info() ([]byte, error) {
 	return buf, nil
 }
 
-func NewRPCNode(addr string, seq *SequencerCore) (*RPCNode) {
+// Returns the checkpoint at `height`, or the latest checkpoint if it's 0.
+func (s *SequencerService) Checkpoint(height int64) ([]byte, error) {
+	rpcLog.Debugw("rpc: checkpoint", "height", height)
+	cp, err := s.seq.GetCheckpoint(height)
+	if err != nil {
+		return nil, err
+	}
+	if cp == nil {
+		return nil, fmt.Errorf("checkpoint %d not found", height)
+	}
+
+	return proto.Marshal(cp)
+}
+
+// Subscribes to signed blocks from `fromHeight` onwards, backfilling history first.
+// Only available over WebSocket, as `sequencer_subscribe("newBlocks", fromHeight)`.
+func (s *SequencerService) NewBlocks(ctx context.Context, fromHeight int64) (*rpc.Subscription, error) {
+	return s.subscribeBlocks(ctx, fromHeight, func(block *messages.Block) (interface{}, error) {
+		return proto.Marshal(block)
+	})
+}
+
+func (s *SequencerService) subscribeBlocks(ctx context.Context, fromHeight int64, encode func(*messages.Block) (interface{}, error)) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+	sub := s.seq.SubscribeBlocks(fromHeight)
+	rpcLog.Debugw("rpc: subscribe blocks", "from", fromHeight)
+
+	go func() {
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case block := <-sub.Blocks():
+				data, err := encode(block)
+				if err != nil {
+					rpcLog.Errorw("error encoding block", "err", err)
+					return
+				}
+
+				err = notifier.Notify(rpcSub.ID, data)
+				if err != nil {
+					return
+				}
+			case err, ok := <-sub.Err():
+				if ok {
+					rpcLog.Warnw("error in blocks subscription", "err", err)
+				}
+				return
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+//
+// JSON variants.
+// These return protojson-encoded messages, with hex bytes and decimal heights.
+//
+
+func (s *SequencerService) AppendJSON(tx json.RawMessage) (int64, error) {
+	msg := &messages.SequenceTx{}
+	err := messages.UnmarshalJSON(tx, msg)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.seq.SequenceMessage(msg)
+}
+
+func (s *SequencerService) GetJSON(from, to uint64) (json.RawMessage, error) {
+	rpcLog.Debugw("rpc: getJSON", "from", from, "to", to)
+
+	reply, err := s.seq.Get(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return messages.MarshalJSON(reply)
+}
+
+func (s *SequencerService) InfoJSON() (json.RawMessage, error) {
+	rpcLog.Debug("rpc: infoJSON")
+
+	reply, err := s.seq.Info()
+	if err != nil {
+		return nil, err
+	}
+
+	return messages.MarshalJSON(reply)
+}
+
+func (s *SequencerService) CheckpointJSON(height int64) (json.RawMessage, error) {
+	rpcLog.Debugw("rpc: checkpointJSON", "height", height)
+	cp, err := s.seq.GetCheckpoint(height)
+	if err != nil {
+		return nil, err
+	}
+	if cp == nil {
+		return nil, fmt.Errorf("checkpoint %d not found", height)
+	}
+
+	return messages.MarshalJSON(cp)
+}
+
+// JSON variant of NewBlocks, as `sequencer_subscribe("newBlocksJSON", fromHeight)`.
+func (s *SequencerService) NewBlocksJSON(ctx context.Context, fromHeight int64) (*rpc.Subscription, error) {
+	return s.subscribeBlocks(ctx, fromHeight, func(block *messages.Block) (interface{}, error) {
+		return messages.MarshalJSON(block)
+	})
+}
+
+func isWebsocket(r *http.Request) bool {
+	return strings.ToLower(r.Header.Get("Upgrade")) == "websocket" &&
+		strings.Contains(strings.ToLower(r.Header.Get("Connection")), "upgrade")
+}
+
+func NewRPCNode(addr string, grpcAddr string, seq *SequencerCore) (*RPCNode) {
 	// JSON-RPC server.
 	rpc := rpc.NewServer()
 	rpc.RegisterName("sequencer", &SequencerService{seq})
 
-	// HTTP frontend.
+	// HTTP and WebSocket frontends, on the same port.
 	serveMux := http.NewServeMux()
-	serveMux.HandleFunc("/", rpc.ServeHTTP)
+
+	// Prometheus metrics.
+	serveMux.Handle("/metrics", promhttp.HandlerFor(seq.Metrics.Registry, promhttp.HandlerOpts{}))
 
 	httpServer := &http.Server{
 		Addr:           addr,
@@ -81,14 +226,75 @@ func NewRPCNode(addr string, seq *SequencerCore) (*RPCNode) {
 		MaxHeaderBytes: 1 << 20,
 	}
 
-	return &RPCNode{
+	node := &RPCNode{
 		addr: addr,
+		server: rpc,
 		httpServer: *httpServer,
+		serveMux: serveMux,
+		// Browsers can only connect from localhost, until SetWSOrigins is called.
+		wsHandler: rpc.WebsocketHandler(nil),
+		grpcAddr: grpcAddr,
 	}
+	serveMux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if isWebsocket(r) {
+			node.wsHandler.ServeHTTP(w, r)
+			return
+		}
+		rpc.ServeHTTP(w, r)
+	})
+
+	if grpcAddr != "" {
+		node.grpcServer = newGRPCServer(seq)
+	}
+
+	return node
+}
+
+// Allows WebSocket connections from web pages on `origins`, or any page with "*".
+// Must be called before the node starts.
+func (n *RPCNode) SetWSOrigins(origins []string) {
+	n.wsHandler = n.server.WebsocketHandler(origins)
+}
+
+// Serves the admin_ methods over HTTP on 127.0.0.1:`port`. They manage the node, so
+// they're kept off the public port. Must be called before the node starts.
+func (n *RPCNode) ServeAdmin(port string, peers *PeerManager) {
+	admin := rpc.NewServer()
+	admin.RegisterName("admin", &AdminService{peers})
+	n.adminServer = &http.Server{
+		Addr: fmt.Sprintf("127.0.0.1:%s", port),
+		Handler: admin,
+		MaxHeaderBytes: 1 << 20,
+	}
+}
+
+// Serves /healthz and /readyz.
+func (n *RPCNode) RegisterHealthChecks(health *HealthChecker) {
+	health.register(n.serveMux)
 }
 
 func (n *RPCNode) Start() {
+	// Start gRPC server.
+	if n.grpcServer != nil {
+		listener, err := net.Listen("tcp", n.grpcAddr)
+		if err != nil {
+			log.Fatal(fmt.Errorf("couldn't listen for gRPC on %s: %s", n.grpcAddr, err))
+		}
+
+		rpcLog.Infow("gRPC listening", "addr", n.grpcAddr)
+		go func() {
+			log.Fatal(n.grpcServer.Serve(listener))
+		}()
+	}
+
+	if n.adminServer != nil {
+		rpcLog.Infow("admin RPC listening", "http", "http://" + n.adminServer.Addr)
+		go func() {
+			log.Fatal(n.adminServer.ListenAndServe())
+		}()
+	}
+
 	// Start RPC server.
-	fmt.Println("RPC listening on http://" + n.addr)
+	rpcLog.Infow("RPC listening", "http", "http://" + n.addr, "ws", "ws://" + n.addr)
 	log.Fatal(n.httpServer.ListenAndServe())
 }
\ No newline at end of file
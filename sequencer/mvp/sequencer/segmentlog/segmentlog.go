@@ -0,0 +1,794 @@
+package segmentlog
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// An append-only log of records, numbered by consecutive offsets and stored in
+// segment files, like a Kafka partition.
+//
+//   <dir>/<base offset>.log    - records, each [length uint32][crc32c uint32][data]
+//   <dir>/<base offset>.index  - sparse index, [offset - base uint32][position uint32]
+//                                for a record every `IndexInterval` bytes
+//
+// Records are appended to the last ("active") segment, which rolls over to a new
+// segment when it reaches `SegmentSize`. A segment is synced before the next one is
+// created, so only the active segment can have a torn tail after a crash - it's
+// scanned when the log is opened, and truncated after the last valid record.
+//
+// Segments are contiguous runs of offsets. There can be gaps between them, eg. when
+// segments are inserted before the start of the log, or deleted from the front.
+
+var ErrNotFound = fmt.Errorf("offset not found")
+
+const (
+	headerSize = 8
+	indexEntrySize = 8
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+type Options struct {
+	// Max size of a segment file, in bytes. Up to 4GB.
+	SegmentSize int64
+	// Bytes of records between index entries.
+	IndexInterval int64
+	// Appends are synced to disk at most this often, unless Sync is called first.
+	// Appends which haven't been synced are lost if the machine (not the process)
+	// crashes, so call Sync before acting on them. 0 syncs every append.
+	SyncInterval time.Duration
+	// Opens the log for reading only, eg. while another process is appending to it.
+	// The active segment isn't recovered - records after a torn tail are ignored.
+	ReadOnly bool
+}
+
+func DefaultOptions() (Options) {
+	return Options{
+		SegmentSize: 64 << 20,
+		IndexInterval: 4 << 10,
+		SyncInterval: 10 * time.Millisecond,
+	}
+}
+
+type Log struct {
+	dir string
+	opts Options
+
+	mu sync.RWMutex
+	// Ordered by base offset. The last is the active segment.
+	segments []*segment
+	// Set when the active segment has writes which haven't been synced.
+	dirty bool
+	// Set when a sync fails. What's on disk is unknown after a failed fsync, so every
+	// later append and sync fails with it.
+	err error
+
+	quit chan struct{}
+	done chan struct{}
+}
+
+type segment struct {
+	base int64
+	// The offset after the last record.
+	next int64
+	size int64
+	file *os.File
+	index []indexEntry
+	indexFile *os.File
+}
+
+type indexEntry struct {
+	offset int64
+	position int64
+}
+
+// Opens the log in `dir`, creating it if it doesn't exist, and recovers the active
+// segment after a crash.
+func Open(dir string, opts Options) (*Log, error) {
+	if opts.SegmentSize <= headerSize || (1 << 32) < opts.SegmentSize {
+		return nil, fmt.Errorf("invalid segment size %d", opts.SegmentSize)
+	}
+	if opts.IndexInterval <= 0 {
+		return nil, fmt.Errorf("invalid index interval %d", opts.IndexInterval)
+	}
+
+	if opts.ReadOnly {
+		_, err := os.Stat(dir)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		err := os.MkdirAll(dir, 0755)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	l := &Log{
+		dir: dir,
+		opts: opts,
+		quit: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	bases, err := l.listSegments()
+	if err != nil {
+		return nil, err
+	}
+	for i, base := range bases {
+		active := i == len(bases) - 1
+		seg, err := l.openSegment(base, active)
+		if err != nil {
+			l.closeSegments()
+			return nil, err
+		}
+		l.segments = append(l.segments, seg)
+	}
+
+	go l.runSync()
+	return l, nil
+}
+
+// Returns the base offsets of the segment files in the directory, in order. Removes
+// files left behind by an interrupted write.
+func (l *Log) listSegments() ([]int64, error) {
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	logs := map[int64]bool{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasSuffix(name, ".tmp") {
+			if !l.opts.ReadOnly {
+				os.Remove(filepath.Join(l.dir, name))
+			}
+			continue
+		}
+		if strings.HasSuffix(name, ".log") {
+			base, err := strconv.ParseInt(strings.TrimSuffix(name, ".log"), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("unexpected file in log directory: %s", name)
+			}
+			logs[base] = true
+		}
+	}
+	// An index without its log is from a segment which was being deleted.
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasSuffix(name, ".index") {
+			base, err := strconv.ParseInt(strings.TrimSuffix(name, ".index"), 10, 64)
+			if err == nil && !logs[base] && !l.opts.ReadOnly {
+				os.Remove(filepath.Join(l.dir, name))
+			}
+		}
+	}
+
+	bases := []int64{}
+	for base := range logs {
+		bases = append(bases, base)
+	}
+	sort.Slice(bases, func(i, j int) bool { return bases[i] < bases[j] })
+	return bases, nil
+}
+
+func (l *Log) segmentPath(base int64, ext string) (string) {
+	return filepath.Join(l.dir, fmt.Sprintf("%020d%s", base, ext))
+}
+
+// Opens a segment. The active segment is scanned in full and its torn tail, if any,
+// is truncated. Other segments are trusted up to their last index entry, and scanned
+// from there to find the end.
+func (l *Log) openSegment(base int64, active bool) (*segment, error) {
+	flag, indexFlag := os.O_RDWR, os.O_RDWR|os.O_CREATE
+	if l.opts.ReadOnly {
+		flag, indexFlag = os.O_RDONLY, os.O_RDONLY
+	}
+
+	file, err := os.OpenFile(l.segmentPath(base, ".log"), flag, 0644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	// A missing index is rebuilt.
+	indexFile, err := os.OpenFile(l.segmentPath(base, ".index"), indexFlag, 0644)
+	if err != nil && !(l.opts.ReadOnly && os.IsNotExist(err)) {
+		file.Close()
+		return nil, err
+	}
+
+	seg := &segment{
+		base: base,
+		next: base,
+		size: info.Size(),
+		file: file,
+		indexFile: indexFile,
+	}
+
+	index := []indexEntry{}
+	if !active {
+		index, err = seg.readIndex()
+		if err != nil {
+			seg.close()
+			return nil, err
+		}
+	}
+
+	// Scan from the last index entry, indexing the records after it.
+	start := indexEntry{offset: base, position: 0}
+	if 0 < len(index) {
+		start = index[len(index) - 1]
+	}
+	seg.index = index
+
+	end, err := seg.scan(start, l.opts.IndexInterval)
+	if err != nil {
+		seg.close()
+		return nil, err
+	}
+	if end != info.Size() && !(active && l.opts.ReadOnly) {
+		if !active {
+			seg.close()
+			return nil, fmt.Errorf("segment %d is corrupt at position %d", base, end)
+		}
+		err = file.Truncate(end)
+		if err == nil {
+			err = file.Sync()
+		}
+		if err != nil {
+			seg.close()
+			return nil, fmt.Errorf("couldn't truncate torn segment %d: %s", base, err)
+		}
+	}
+
+	// The index is rebuilt for the active segment, or if it was missing.
+	if (active || len(index) == 0) && !l.opts.ReadOnly {
+		err = seg.writeIndex()
+		if err != nil {
+			seg.close()
+			return nil, err
+		}
+	}
+	return seg, nil
+}
+
+// Reads and validates the segment's index file. Returns an empty index if it's invalid,
+// so it's rebuilt from the segment.
+func (seg *segment) readIndex() ([]indexEntry, error) {
+	if seg.indexFile == nil {
+		return []indexEntry{}, nil
+	}
+	buf, err := io.ReadAll(io.NewSectionReader(seg.indexFile, 0, 1 << 62))
+	if err != nil {
+		return nil, err
+	}
+
+	index := []indexEntry{}
+	for i := 0; i + indexEntrySize <= len(buf); i += indexEntrySize {
+		entry := indexEntry{
+			offset: seg.base + int64(binary.BigEndian.Uint32(buf[i:])),
+			position: int64(binary.BigEndian.Uint32(buf[i + 4:])),
+		}
+		valid := entry.position < seg.size
+		if 0 < len(index) {
+			prev := index[len(index) - 1]
+			valid = valid && prev.offset < entry.offset && prev.position < entry.position
+		} else {
+			valid = valid && entry.offset == seg.base && entry.position == 0
+		}
+		if !valid {
+			return []indexEntry{}, nil
+		}
+		index = append(index, entry)
+	}
+	return index, nil
+}
+
+// Rewrites the index file from the in-memory index.
+func (seg *segment) writeIndex() (error) {
+	buf := make([]byte, 0, len(seg.index) * indexEntrySize)
+	for _, entry := range seg.index {
+		buf = seg.appendIndexEntry(buf, entry)
+	}
+	err := seg.indexFile.Truncate(0)
+	if err != nil {
+		return err
+	}
+	_, err = seg.indexFile.WriteAt(buf, 0)
+	return err
+}
+
+func (seg *segment) appendIndexEntry(buf []byte, entry indexEntry) ([]byte) {
+	buf = appendUint32(buf, uint32(entry.offset - seg.base))
+	return appendUint32(buf, uint32(entry.position))
+}
+
+func appendUint32(buf []byte, v uint32) ([]byte) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], v)
+	return append(buf, b[:]...)
+}
+
+// Scans the records from `start`, indexing them, until the end of the file or the first
+// invalid record. Returns the position after the last valid record.
+func (seg *segment) scan(start indexEntry, indexInterval int64) (int64, error) {
+	seg.next, seg.size = start.offset, start.position
+	lastIndexed := int64(-1)
+	if 0 < len(seg.index) {
+		lastIndexed = seg.index[len(seg.index) - 1].position
+	}
+
+	for {
+		data, err := seg.readRecord(seg.size)
+		if err == io.EOF || err == errCorrupt {
+			return seg.size, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		if lastIndexed == -1 || indexInterval <= seg.size - lastIndexed {
+			seg.index = append(seg.index, indexEntry{offset: seg.next, position: seg.size})
+			lastIndexed = seg.size
+		}
+		seg.next++
+		seg.size += headerSize + int64(len(data))
+	}
+}
+
+var errCorrupt = fmt.Errorf("corrupt record")
+
+// Reads the record at `position`. Returns io.EOF at the end of the file, and errCorrupt
+// for a torn or corrupt record.
+func (seg *segment) readRecord(position int64) ([]byte, error) {
+	var header [headerSize]byte
+	n, err := seg.file.ReadAt(header[:], position)
+	if n == 0 && err == io.EOF {
+		return nil, io.EOF
+	}
+	if n < headerSize {
+		return nil, errCorrupt
+	}
+
+	length := int64(binary.BigEndian.Uint32(header[0:]))
+	checksum := binary.BigEndian.Uint32(header[4:])
+	if (1 << 32) < position + headerSize + length {
+		return nil, errCorrupt
+	}
+
+	data := make([]byte, length)
+	n, err = seg.file.ReadAt(data, position + headerSize)
+	if int64(n) < length {
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		return nil, errCorrupt
+	}
+	if crc32.Checksum(data, crcTable) != checksum {
+		return nil, errCorrupt
+	}
+	return data, nil
+}
+
+// Returns the position of the record at `offset`, which must be in the segment.
+func (seg *segment) position(offset int64) (int64, error) {
+	// The last index entry at or before the offset.
+	i := sort.Search(len(seg.index), func(i int) bool { return offset < seg.index[i].offset }) - 1
+	entry := seg.index[i]
+
+	position := entry.position
+	for off := entry.offset; off < offset; off++ {
+		var header [headerSize]byte
+		_, err := seg.file.ReadAt(header[:], position)
+		if err != nil {
+			return 0, err
+		}
+		position += headerSize + int64(binary.BigEndian.Uint32(header[0:]))
+	}
+	return position, nil
+}
+
+func (seg *segment) close() {
+	seg.file.Close()
+	if seg.indexFile != nil {
+		seg.indexFile.Close()
+	}
+}
+
+func (l *Log) active() (*segment) {
+	if len(l.segments) == 0 {
+		return nil
+	}
+	return l.segments[len(l.segments) - 1]
+}
+
+// Appends a record at `offset`, which must follow the last record. The first record
+// in an empty log can have any offset.
+func (l *Log) Append(offset int64, data []byte) (error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.opts.ReadOnly {
+		return fmt.Errorf("log is read-only")
+	}
+	if l.err != nil {
+		return l.err
+	}
+
+	seg := l.active()
+	if seg != nil && offset != seg.next {
+		return fmt.Errorf("expected offset %d, got %d", seg.next, offset)
+	}
+
+	recordSize := headerSize + int64(len(data))
+	if l.opts.SegmentSize < recordSize {
+		return fmt.Errorf("record of %d bytes is larger than the segment size", len(data))
+	}
+	if seg == nil || l.opts.SegmentSize < seg.size + recordSize {
+		var err error
+		seg, err = l.roll(offset)
+		if err != nil {
+			return err
+		}
+	}
+
+	record := make([]byte, recordSize)
+	binary.BigEndian.PutUint32(record[0:], uint32(len(data)))
+	binary.BigEndian.PutUint32(record[4:], crc32.Checksum(data, crcTable))
+	copy(record[headerSize:], data)
+
+	_, err := seg.file.WriteAt(record, seg.size)
+	if err != nil {
+		// Don't leave a partial record behind.
+		seg.file.Truncate(seg.size)
+		return err
+	}
+
+	last := int64(-1)
+	if 0 < len(seg.index) {
+		last = seg.index[len(seg.index) - 1].position
+	}
+	if last == -1 || l.opts.IndexInterval <= seg.size - last {
+		entry := indexEntry{offset: offset, position: seg.size}
+		seg.index = append(seg.index, entry)
+		_, err = seg.indexFile.WriteAt(seg.appendIndexEntry(nil, entry), int64(len(seg.index) - 1) * indexEntrySize)
+		if err != nil {
+			return err
+		}
+	}
+	seg.next++
+	seg.size += recordSize
+
+	l.dirty = true
+	if l.opts.SyncInterval == 0 {
+		return l.sync()
+	}
+	return nil
+}
+
+// Syncs the active segment, and starts a new one at `base`.
+func (l *Log) roll(base int64) (*segment, error) {
+	if prev := l.active(); prev != nil {
+		if prev.next == prev.base {
+			// Reuse the empty segment.
+			return prev, nil
+		}
+		err := l.syncSegment(prev)
+		if err != nil {
+			return nil, l.fail(err)
+		}
+	}
+
+	seg, err := l.createSegment(base)
+	if err != nil {
+		return nil, err
+	}
+	l.segments = append(l.segments, seg)
+	return seg, nil
+}
+
+func (l *Log) createSegment(base int64) (*segment, error) {
+	file, err := os.OpenFile(l.segmentPath(base, ".log"), os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return nil, err
+	}
+	indexFile, err := os.OpenFile(l.segmentPath(base, ".index"), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	err = syncDir(l.dir)
+	if err != nil {
+		file.Close()
+		indexFile.Close()
+		return nil, err
+	}
+	return &segment{
+		base: base,
+		next: base,
+		file: file,
+		indexFile: indexFile,
+	}, nil
+}
+
+func (l *Log) syncSegment(seg *segment) (error) {
+	err := seg.file.Sync()
+	if err != nil {
+		return err
+	}
+	return seg.indexFile.Sync()
+}
+
+func syncDir(dir string) (error) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// Syncs appends to disk.
+func (l *Log) Sync() (error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.sync()
+}
+
+func (l *Log) sync() (error) {
+	if l.err != nil {
+		return l.err
+	}
+	if !l.dirty {
+		return nil
+	}
+	err := l.syncSegment(l.active())
+	if err != nil {
+		return l.fail(err)
+	}
+	l.dirty = false
+	return nil
+}
+
+func (l *Log) fail(err error) (error) {
+	l.err = fmt.Errorf("error syncing log: %s", err)
+	return l.err
+}
+
+// Syncs batches of appends every SyncInterval. Errors are returned by the next append.
+func (l *Log) runSync() {
+	defer close(l.done)
+	if l.opts.SyncInterval == 0 {
+		<-l.quit
+		return
+	}
+
+	ticker := time.NewTicker(l.opts.SyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			l.Sync()
+		case <-l.quit:
+			return
+		}
+	}
+}
+
+// Writes a complete segment of `records`, starting at `base`, before the first segment
+// in the log. Used to fill in history before the start of the log.
+func (l *Log) Prepend(base int64, records [][]byte) (error) {
+	if len(records) == 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.opts.ReadOnly {
+		return fmt.Errorf("log is read-only")
+	}
+	if 0 < len(l.segments) && l.segments[0].base < base + int64(len(records)) {
+		return fmt.Errorf("records %d-%d overlap the log, which starts at %d", base, base + int64(len(records)) - 1, l.segments[0].base)
+	}
+
+	seg := &segment{base: base, next: base}
+	buf := []byte{}
+	indexBuf := []byte{}
+	lastIndexed := int64(-1)
+	for _, data := range records {
+		if lastIndexed == -1 || l.opts.IndexInterval <= seg.size - lastIndexed {
+			entry := indexEntry{offset: seg.next, position: seg.size}
+			seg.index = append(seg.index, entry)
+			indexBuf = seg.appendIndexEntry(indexBuf, entry)
+			lastIndexed = seg.size
+		}
+		buf = appendUint32(buf, uint32(len(data)))
+		buf = appendUint32(buf, crc32.Checksum(data, crcTable))
+		buf = append(buf, data...)
+		seg.next++
+		seg.size += headerSize + int64(len(data))
+	}
+	if (1 << 32) < seg.size {
+		return fmt.Errorf("segment of %d bytes is too large", seg.size)
+	}
+
+	// Write both files in full before they're renamed into place.
+	for _, f := range []struct{ ext string; buf []byte }{{".index", indexBuf}, {".log", buf}} {
+		path := l.segmentPath(base, f.ext)
+		err := writeFileSync(path + ".tmp", f.buf)
+		if err == nil {
+			err = os.Rename(path + ".tmp", path)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	err := syncDir(l.dir)
+	if err != nil {
+		return err
+	}
+
+	var file, indexFile *os.File
+	file, err = os.OpenFile(l.segmentPath(base, ".log"), os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	indexFile, err = os.OpenFile(l.segmentPath(base, ".index"), os.O_RDWR, 0644)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	seg.file, seg.indexFile = file, indexFile
+
+	l.segments = append([]*segment{seg}, l.segments...)
+	return nil
+}
+
+func writeFileSync(path string, buf []byte) (error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(buf)
+	if err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+// Deletes the segments which end at or before `offset`. The active segment is never
+// deleted.
+func (l *Log) DeleteThrough(offset int64) (error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.opts.ReadOnly {
+		return fmt.Errorf("log is read-only")
+	}
+
+	for 1 < len(l.segments) && l.segments[0].next - 1 <= offset {
+		seg := l.segments[0]
+		seg.close()
+		// The log goes first, so an interrupted delete leaves a stray index, not a segment without one.
+		err := os.Remove(l.segmentPath(seg.base, ".log"))
+		if err != nil {
+			return err
+		}
+		os.Remove(l.segmentPath(seg.base, ".index"))
+		l.segments = l.segments[1:]
+	}
+	return nil
+}
+
+// A contiguous run of offsets in the log.
+type Span struct {
+	From int64
+	// The offset after the last record.
+	Next int64
+}
+
+// Returns the spans of offsets in each segment, in order. Empty segments are skipped.
+func (l *Log) Segments() ([]Span) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	spans := []Span{}
+	for _, seg := range l.segments {
+		if seg.base < seg.next {
+			spans = append(spans, Span{From: seg.base, Next: seg.next})
+		}
+	}
+	return spans
+}
+
+// Calls `fn` for each record with an offset between `from` and `to`, in order. Offsets
+// in gaps between segments are skipped.
+func (l *Log) Scan(from, to int64, fn func(offset int64, data []byte) (error)) (error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	for _, seg := range l.segments {
+		if seg.next <= from || to < seg.base || seg.base == seg.next {
+			continue
+		}
+
+		offset := from
+		if offset < seg.base {
+			offset = seg.base
+		}
+		position, err := seg.position(offset)
+		if err != nil {
+			return err
+		}
+		for ; offset < seg.next && offset <= to; offset++ {
+			data, err := seg.readRecord(position)
+			if err == errCorrupt || err == io.EOF {
+				return fmt.Errorf("segment %d is corrupt at offset %d", seg.base, offset)
+			}
+			if err != nil {
+				return err
+			}
+			err = fn(offset, data)
+			if err != nil {
+				return err
+			}
+			position += headerSize + int64(len(data))
+		}
+	}
+	return nil
+}
+
+// Returns the record at `offset`, or ErrNotFound.
+func (l *Log) Read(offset int64) ([]byte, error) {
+	var record []byte
+	err := l.Scan(offset, offset, func(_ int64, data []byte) (error) {
+		record = data
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, ErrNotFound
+	}
+	return record, nil
+}
+
+// Syncs and closes the log.
+func (l *Log) Close() (error) {
+	close(l.quit)
+	<-l.done
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	err := l.sync()
+	l.closeSegments()
+	if l.err == nil {
+		l.err = fmt.Errorf("log is closed")
+	}
+	return err
+}
+
+func (l *Log) closeSegments() {
+	for _, seg := range l.segments {
+		seg.close()
+	}
+	l.segments = nil
+}
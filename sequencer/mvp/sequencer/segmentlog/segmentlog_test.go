@@ -0,0 +1,181 @@
+package segmentlog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func record(i int64) ([]byte) {
+	return []byte(fmt.Sprintf("record %d", i))
+}
+
+func testOptions() (Options) {
+	opts := DefaultOptions()
+	opts.SegmentSize = 200
+	opts.IndexInterval = 40
+	return opts
+}
+
+func readAll(t *testing.T, l *Log, from, to int64) ([]int64) {
+	offsets := []int64{}
+	err := l.Scan(from, to, func(offset int64, data []byte) (error) {
+		assert.Equal(t, record(offset), data)
+		offsets = append(offsets, offset)
+		return nil
+	})
+	assert.Nil(t, err)
+	return offsets
+}
+
+func TestAppendAndRead(t *testing.T) {
+	dir := t.TempDir()
+	l, err := Open(dir, testOptions())
+	assert.Nil(t, err)
+
+	for i := int64(5); i < 105; i++ {
+		assert.Nil(t, l.Append(i, record(i)))
+	}
+	assert.NotNil(t, l.Append(200, record(200)))
+
+	// Rolled over into many segments.
+	segments := l.Segments()
+	assert.Less(t, 5, len(segments))
+	assert.Equal(t, int64(5), segments[0].From)
+	assert.Equal(t, int64(105), segments[len(segments) - 1].Next)
+
+	data, err := l.Read(50)
+	assert.Nil(t, err)
+	assert.Equal(t, record(50), data)
+	_, err = l.Read(4)
+	assert.Equal(t, ErrNotFound, err)
+	assert.Equal(t, 91, len(readAll(t, l, 10, 100)))
+	assert.Nil(t, l.Close())
+
+	// Reopening restores the log, and appends continue from the end.
+	l, err = Open(dir, testOptions())
+	assert.Nil(t, err)
+	assert.Nil(t, l.Append(105, record(105)))
+	assert.Equal(t, 101, len(readAll(t, l, 0, 1000)))
+
+	// Segments are deleted from the front, but never the active one.
+	assert.Nil(t, l.DeleteThrough(50))
+	first := l.Segments()[0].From
+	assert.True(t, first <= 51 && 40 < first)
+	_, err = l.Read(first - 1)
+	assert.Equal(t, ErrNotFound, err)
+	assert.Nil(t, l.DeleteThrough(1000))
+	assert.Equal(t, 1, len(l.Segments()))
+	assert.Nil(t, l.Close())
+}
+
+func TestPrepend(t *testing.T) {
+	dir := t.TempDir()
+	l, err := Open(dir, testOptions())
+	assert.Nil(t, err)
+
+	for i := int64(100); i < 110; i++ {
+		assert.Nil(t, l.Append(i, record(i)))
+	}
+
+	records := [][]byte{}
+	for i := int64(80); i < 90; i++ {
+		records = append(records, record(i))
+	}
+	assert.Nil(t, l.Prepend(80, records))
+	assert.NotNil(t, l.Prepend(75, records))
+
+	// There's a gap between the prepended segment and the rest.
+	offsets := readAll(t, l, 0, 1000)
+	assert.Equal(t, 20, len(offsets))
+	assert.Equal(t, int64(89), offsets[9])
+	assert.Equal(t, int64(100), offsets[10])
+	assert.Nil(t, l.Close())
+
+	l, err = Open(dir, testOptions())
+	assert.Nil(t, err)
+	assert.Equal(t, 20, len(readAll(t, l, 0, 1000)))
+	assert.Nil(t, l.Append(110, record(110)))
+	assert.Nil(t, l.Close())
+}
+
+func TestTornTailRecovery(t *testing.T) {
+	dir := t.TempDir()
+	opts := testOptions()
+	opts.SegmentSize = 1 << 20
+	l, err := Open(dir, opts)
+	assert.Nil(t, err)
+	for i := int64(1); i <= 20; i++ {
+		assert.Nil(t, l.Append(i, record(i)))
+	}
+	assert.Nil(t, l.Close())
+
+	path := filepath.Join(dir, fmt.Sprintf("%020d.log", 1))
+	info, err := os.Stat(path)
+	assert.Nil(t, err)
+
+	// A write torn partway through the last record.
+	assert.Nil(t, os.Truncate(path, info.Size() - 3))
+	l, err = Open(dir, opts)
+	assert.Nil(t, err)
+	assert.Equal(t, 19, len(readAll(t, l, 0, 100)))
+	assert.Nil(t, l.Append(20, record(20)))
+	assert.Nil(t, l.Close())
+
+	// A corrupt record, and garbage after it.
+	file, err := os.OpenFile(path, os.O_RDWR, 0644)
+	assert.Nil(t, err)
+	info, _ = file.Stat()
+	_, err = file.WriteAt([]byte("X"), info.Size() - 1)
+	assert.Nil(t, err)
+	_, err = file.WriteAt([]byte("garbage"), info.Size())
+	assert.Nil(t, err)
+	file.Close()
+
+	l, err = Open(dir, opts)
+	assert.Nil(t, err)
+	assert.Equal(t, 19, len(readAll(t, l, 0, 100)))
+	assert.Nil(t, l.Close())
+}
+
+func TestReadOnly(t *testing.T) {
+	dir := t.TempDir()
+	l, err := Open(dir, testOptions())
+	assert.Nil(t, err)
+	for i := int64(1); i <= 30; i++ {
+		assert.Nil(t, l.Append(i, record(i)))
+	}
+	assert.Nil(t, l.Sync())
+
+	// Readers can open the log while it's being appended to.
+	r, err := Open(dir, Options{SegmentSize: 200, IndexInterval: 40, ReadOnly: true})
+	assert.Nil(t, err)
+	assert.Equal(t, 30, len(readAll(t, r, 0, 100)))
+	assert.NotNil(t, r.Append(31, record(31)))
+	assert.Nil(t, r.Close())
+
+	assert.Nil(t, l.Append(31, record(31)))
+	assert.Nil(t, l.Close())
+}
+
+func TestSyncErrorIsSticky(t *testing.T) {
+	dir := t.TempDir()
+	opts := testOptions()
+	opts.SyncInterval = time.Hour
+	l, err := Open(dir, opts)
+	assert.Nil(t, err)
+	assert.Nil(t, l.Append(1, record(1)))
+
+	// Make the fsync fail.
+	l.active().file.Close()
+	assert.NotNil(t, l.Sync())
+
+	// Nothing is appended after a failed sync.
+	assert.NotNil(t, l.Append(2, record(2)))
+	assert.NotNil(t, l.Sync())
+	assert.NotNil(t, l.Close())
+}
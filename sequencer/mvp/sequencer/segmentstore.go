@@ -0,0 +1,223 @@
+package sequencer
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/accumulator"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/messages"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/segmentlog"
+)
+
+// Stores blocks in a segment log, at offsets equal to their height. Each record is the
+// block hash followed by the encoded block, so the accumulator can be rebuilt without
+// decoding blocks.
+//
+// Appends skip the database entirely, which is much faster than a transaction per block.
+// Pruning deletes whole segments, once every block in a segment is old enough.
+type segmentBlockStore struct {
+	log *segmentlog.Log
+}
+
+const hashSize = 32
+
+func newSegmentBlockStore(dir string, opts segmentlog.Options) (*segmentBlockStore, error) {
+	log, err := segmentlog.Open(dir, opts)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't open block log: %s", err)
+	}
+	return &segmentBlockStore{log: log}, nil
+}
+
+// Stores blocks in the segment log in `dir`, instead of the database. The database
+// must not have any blocks yet. Must be called before the node starts, and before
+// handovers are configured, since they're detected from the stored blocks.
+func (s *SequencerCore) UseSegmentStore(dir string, opts segmentlog.Options) (error) {
+	return s.runOnLoop(func() (error) {
+		count, err := s.store.Count()
+		if err != nil {
+			return err
+		}
+		if count != 0 {
+			return fmt.Errorf("the database already has %d blocks, export them and import them into a new home to use the segments engine", count)
+		}
+
+		store, err := newSegmentBlockStore(dir, opts)
+		if err != nil {
+			return err
+		}
+		s.store.Close()
+		s.store = store
+
+		s.LastBlock = genesisBlock()
+		s.acc = accumulator.New()
+		coreLog.Infow("storing blocks in segment log", "dir", dir)
+		return s.restoreTip()
+	})
+}
+
+func encodeStoredBlock(block *messages.Block) ([]byte, error) {
+	buf, err := proto.Marshal(block)
+	if err != nil {
+		return nil, err
+	}
+	return append(block.SigHash(), buf...), nil
+}
+
+func decodeStoredBlock(data []byte) (*messages.Block, error) {
+	if len(data) < hashSize {
+		return nil, fmt.Errorf("error decoding stored block: record is too short")
+	}
+	block := &messages.Block{}
+	err := proto.Unmarshal(data[hashSize:], block)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding stored block: %s", err)
+	}
+	return block, nil
+}
+
+func (st *segmentBlockStore) Append(block *messages.Block) (error) {
+	data, err := encodeStoredBlock(block)
+	if err != nil {
+		return err
+	}
+	err = st.log.Append(block.Height, data)
+	if err != nil {
+		return fmt.Errorf("error writing block to log: %s", err)
+	}
+	return nil
+}
+
+func (st *segmentBlockStore) Prepend(blocks []*messages.Block) (error) {
+	records := [][]byte{}
+	for i, block := range blocks {
+		if block.Height != blocks[0].Height + int64(i) {
+			return fmt.Errorf("expected block %d, got block %d", blocks[0].Height + int64(i), block.Height)
+		}
+		data, err := encodeStoredBlock(block)
+		if err != nil {
+			return err
+		}
+		records = append(records, data)
+	}
+	err := st.log.Prepend(blocks[0].Height, records)
+	if err != nil {
+		return fmt.Errorf("error writing blocks to log: %s", err)
+	}
+	return nil
+}
+
+func (st *segmentBlockStore) Blocks(from, to int64) ([]*messages.Block, error) {
+	blocks := []*messages.Block{}
+	err := st.log.Scan(from, to, func(_ int64, data []byte) (error) {
+		block, err := decodeStoredBlock(data)
+		if err != nil {
+			return err
+		}
+		blocks = append(blocks, block)
+		return nil
+	})
+	return blocks, err
+}
+
+func (st *segmentBlockStore) Txs(from, to int64) ([]*messages.SequenceTx, error) {
+	blocks, err := st.Blocks(from, to)
+	if err != nil {
+		return nil, err
+	}
+	txs := []*messages.SequenceTx{}
+	for _, block := range blocks {
+		txs = append(txs, block.Body)
+	}
+	return txs, nil
+}
+
+func (st *segmentBlockStore) Last() (*messages.Block, error) {
+	segments := st.log.Segments()
+	if len(segments) == 0 {
+		return nil, nil
+	}
+	data, err := st.log.Read(segments[len(segments) - 1].Next - 1)
+	if err != nil {
+		return nil, err
+	}
+	return decodeStoredBlock(data)
+}
+
+func (st *segmentBlockStore) First() (int64, error) {
+	segments := st.log.Segments()
+	if len(segments) == 0 {
+		return 0, nil
+	}
+	return segments[0].From, nil
+}
+
+func (st *segmentBlockStore) Count() (int64, error) {
+	count := int64(0)
+	for _, span := range st.log.Segments() {
+		count += span.Next - span.From
+	}
+	return count, nil
+}
+
+func (st *segmentBlockStore) Hashes(from, to int64, fn func(height int64, hash []byte) (error)) (error) {
+	return st.log.Scan(from, to, func(offset int64, data []byte) (error) {
+		if len(data) < hashSize {
+			return fmt.Errorf("error decoding stored block %d: record is too short", offset)
+		}
+		return fn(offset, data[:hashSize])
+	})
+}
+
+func (st *segmentBlockStore) Scan(fn func(stored *storedBlock) (error)) (error) {
+	return st.log.Scan(0, 1 << 62, func(offset int64, data []byte) (error) {
+		stored := &storedBlock{Height: offset, Block: data}
+		if hashSize <= len(data) {
+			stored.Hash, stored.Block = data[:hashSize], data[hashSize:]
+		}
+		return fn(stored)
+	})
+}
+
+// Scans every block, so it's slow for long chains. Only used when configuring handovers.
+func (st *segmentBlockStore) HasHash(hash []byte) (bool, error) {
+	err := st.Hashes(0, 1 << 62, func(_ int64, stored []byte) (error) {
+		if bytes.Equal(stored, hash) {
+			return errFound
+		}
+		return nil
+	})
+	if err == errFound {
+		return true, nil
+	}
+	return false, err
+}
+
+var errFound = fmt.Errorf("found")
+
+// Blocks are pruned a segment at a time. The active segment isn't pruned.
+func (st *segmentBlockStore) PruneRange(limit int64) (int64, int64, error) {
+	segments := st.log.Segments()
+	if len(segments) < 2 || limit < segments[0].Next - 1 {
+		return 0, 0, nil
+	}
+	return segments[0].From, segments[0].Next - 1, nil
+}
+
+func (st *segmentBlockStore) Delete(from, to int64) (error) {
+	return st.log.DeleteThrough(to)
+}
+
+// Segments only hold blocks.
+func (st *segmentBlockStore) CheckOrphans() (error) {
+	return nil
+}
+
+func (st *segmentBlockStore) Sync() (error) {
+	return st.log.Sync()
+}
+
+func (st *segmentBlockStore) Close() (error) {
+	return st.log.Close()
+}
@@ -0,0 +1,379 @@
+package sequencer
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/messages"
+)
+
+// Block storage.
+//
+// Blocks are kept in a blockStore - by default the sequence and blocks tables in the
+// database, or a segment log (see segmentstore.go). Checkpoints and the headers of
+// pruned blocks are kept in the database either way.
+
+type StorageEngine uint
+
+const (
+	SQLiteEngine StorageEngine = iota
+	SegmentsEngine
+)
+
+func ParseStorageEngine(engine string) (StorageEngine, error) {
+	switch engine {
+	case "sqlite":
+		return SQLiteEngine, nil
+	case "segments":
+		return SegmentsEngine, nil
+	}
+	return 0, fmt.Errorf("unknown storage engine %q, expected sqlite or segments", engine)
+}
+
+func (engine StorageEngine) String() (string) {
+	if engine == SegmentsEngine {
+		return "segments"
+	}
+	return "sqlite"
+}
+
+type blockStore interface {
+	// Appends the block after the last block, or the first block of an empty store.
+	Append(block *messages.Block) (error)
+	// Inserts blocks which end before the first block, when backfilling history.
+	Prepend(blocks []*messages.Block) (error)
+	// Returns the stored blocks between `from` and `to`, in height order.
+	Blocks(from, to int64) ([]*messages.Block, error)
+	// Returns the txs of the stored blocks between `from` and `to`, in height order.
+	Txs(from, to int64) ([]*messages.SequenceTx, error)
+	// Returns the last block, or nil if the store is empty.
+	Last() (*messages.Block, error)
+	// Returns the height of the first block, or 0 if the store is empty.
+	First() (int64, error)
+	Count() (int64, error)
+	// Calls `fn` with the hash of each stored block between `from` and `to`, in height order.
+	Hashes(from, to int64, fn func(height int64, hash []byte) (error)) (error)
+	// Calls `fn` for each stored block, in height order.
+	Scan(fn func(stored *storedBlock) (error)) (error)
+	HasHash(hash []byte) (bool, error)
+	// Returns the oldest range of blocks which can be deleted together, ending at or
+	// before `limit`. Returns 0, 0 if there's none.
+	PruneRange(limit int64) (from int64, to int64, err error)
+	// Deletes the blocks between `from` and `to`, which must be a PruneRange.
+	Delete(from, to int64) (error)
+	// Returns a *ChainInconsistency if the store has data which doesn't belong to a block.
+	CheckOrphans() (error)
+	// Flushes appended blocks to disk.
+	Sync() (error)
+	Close() (error)
+}
+
+// A block as stored, for verifying storage.
+type storedBlock struct {
+	Height int64
+	// The encoded block.
+	Block []byte
+	// The hash the block is stored under.
+	Hash []byte
+	// The encoded tx, for stores which keep txs separately from blocks. Nil otherwise.
+	Sequence []byte
+	SequenceMissing bool
+}
+
+// Stores blocks in the sequence and blocks tables. Rows are numbered by block height,
+// so the sequence number of a tx is its block height.
+type sqlBlockStore struct {
+	db *sql.DB
+}
+
+func newSQLBlockStore(db *sql.DB) (*sqlBlockStore) {
+	return &sqlBlockStore{db: db}
+}
+
+func (st *sqlBlockStore) Append(block *messages.Block) (error) {
+	return st.insert([]*messages.Block{block})
+}
+
+func (st *sqlBlockStore) Prepend(blocks []*messages.Block) (error) {
+	return st.insert(blocks)
+}
+
+func (st *sqlBlockStore) insert(blocks []*messages.Block) (error) {
+	tx, err := st.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error writing tx to db: %s", err)
+	}
+	for _, block := range blocks {
+		err = insertBlock(tx, block)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Inserts the block and its tx into storage.
+func insertBlock(tx *sql.Tx, block *messages.Block) (error) {
+	sequenceBuf, err := proto.Marshal(block.Body)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		"INSERT INTO sequence values (?, ?, ?)",
+		block.Height,
+		sequenceBuf,
+		nil,
+	)
+	if err != nil {
+		return fmt.Errorf("error writing tx to db: %s", err)
+	}
+
+	blockBuf, err := proto.Marshal(block)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		"INSERT INTO blocks values (?, ?, ?)",
+		block.Height,
+		blockBuf,
+		block.SigHash(),
+	)
+	if err != nil {
+		return fmt.Errorf("error writing tx to db: %s", err)
+	}
+
+	return nil
+}
+
+func (st *sqlBlockStore) Blocks(from, to int64) ([]*messages.Block, error) {
+	blocks := []*messages.Block{}
+
+	res, err := st.db.Query(
+		`SELECT block FROM blocks WHERE num >= ? AND num <= ? ORDER BY num`,
+		from,
+		to,
+	)
+	if err != nil {
+		return blocks, fmt.Errorf("error fetching from db: %s", err)
+	}
+	defer res.Close()
+
+	for res.Next() {
+		var buf []byte
+		err := res.Scan(&buf)
+		if err != nil {
+			return blocks, fmt.Errorf("error fetching from db: %s", err)
+		}
+
+		block := &messages.Block{}
+		err = proto.Unmarshal(buf, block)
+		if err != nil {
+			return blocks, fmt.Errorf("error decoding db block: %s", err)
+		}
+
+		blocks = append(blocks, block)
+	}
+
+	return blocks, res.Err()
+}
+
+func (st *sqlBlockStore) Txs(from, to int64) ([]*messages.SequenceTx, error) {
+	txs := []*messages.SequenceTx{}
+
+	res, err := st.db.Query(
+		`SELECT msg FROM sequence WHERE num >= ? AND num <= ? ORDER BY num`,
+		from,
+		to,
+	)
+	if err != nil {
+		return txs, fmt.Errorf("error fetching from db: %s", err)
+	}
+	defer res.Close()
+
+	for res.Next() {
+		var buf []byte
+		err := res.Scan(&buf)
+		if err != nil {
+			return txs, fmt.Errorf("error fetching from db: %s", err)
+		}
+
+		tx := &messages.SequenceTx{}
+		err = proto.Unmarshal(buf, tx)
+		if err != nil {
+			return txs, fmt.Errorf("error decoding db tx: %s", err)
+		}
+
+		txs = append(txs, tx)
+	}
+
+	return txs, res.Err()
+}
+
+func (st *sqlBlockStore) Last() (*messages.Block, error) {
+	var buf []byte
+	err := st.db.QueryRow("SELECT block FROM blocks ORDER BY num DESC LIMIT 1").Scan(&buf)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	block := &messages.Block{}
+	err = proto.Unmarshal(buf, block)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding db block: %s", err)
+	}
+	return block, nil
+}
+
+func (st *sqlBlockStore) First() (int64, error) {
+	var first sql.NullInt64
+	err := st.db.QueryRow("SELECT MIN(num) FROM blocks").Scan(&first)
+	if err != nil {
+		return 0, fmt.Errorf("error fetching from db: %s", err)
+	}
+	return first.Int64, nil
+}
+
+func (st *sqlBlockStore) Count() (int64, error) {
+	var count int64
+	err := st.db.QueryRow("SELECT COUNT(*) FROM sequence").Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("error fetching from db: %s", err)
+	}
+	return count, nil
+}
+
+func (st *sqlBlockStore) Hashes(from, to int64, fn func(height int64, hash []byte) (error)) (error) {
+	res, err := st.db.Query("SELECT num, hash FROM blocks WHERE num >= ? AND num <= ? ORDER BY num", from, to)
+	if err != nil {
+		return fmt.Errorf("error fetching from db: %s", err)
+	}
+	defer res.Close()
+
+	for res.Next() {
+		var (
+			num int64
+			hash []byte
+		)
+		err := res.Scan(&num, &hash)
+		if err != nil {
+			return fmt.Errorf("error fetching from db: %s", err)
+		}
+		err = fn(num, hash)
+		if err != nil {
+			return err
+		}
+	}
+	if err = res.Err(); err != nil {
+		return fmt.Errorf("error fetching from db: %s", err)
+	}
+	return nil
+}
+
+func (st *sqlBlockStore) Scan(fn func(stored *storedBlock) (error)) (error) {
+	res, err := st.db.Query(`
+		SELECT blocks.num, blocks.block, blocks.hash, sequence.num, sequence.msg
+		FROM blocks LEFT JOIN sequence ON sequence.num = blocks.num
+		ORDER BY blocks.num
+	`)
+	if err != nil {
+		return fmt.Errorf("error fetching from db: %s", err)
+	}
+	defer res.Close()
+
+	for res.Next() {
+		var (
+			stored storedBlock
+			sequenceNum sql.NullInt64
+		)
+		err := res.Scan(&stored.Height, &stored.Block, &stored.Hash, &sequenceNum, &stored.Sequence)
+		if err != nil {
+			return fmt.Errorf("error fetching from db: %s", err)
+		}
+		stored.SequenceMissing = !sequenceNum.Valid
+
+		err = fn(&stored)
+		if err != nil {
+			return err
+		}
+	}
+	if err = res.Err(); err != nil {
+		return fmt.Errorf("error fetching from db: %s", err)
+	}
+	return nil
+}
+
+func (st *sqlBlockStore) HasHash(hash []byte) (bool, error) {
+	var num int64
+	err := st.db.QueryRow("SELECT num FROM blocks WHERE hash = ?", hash).Scan(&num)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error fetching from db: %s", err)
+	}
+	return true, nil
+}
+
+// Blocks are pruned in segments of pruneSegmentSize.
+func (st *sqlBlockStore) PruneRange(limit int64) (int64, int64, error) {
+	from, err := st.First()
+	if err != nil || from == 0 {
+		return 0, 0, err
+	}
+
+	to := from + pruneSegmentSize - 1
+	if limit < to {
+		return 0, 0, nil
+	}
+	return from, to, nil
+}
+
+func (st *sqlBlockStore) Delete(from, to int64) (error) {
+	tx, err := st.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error writing tx to db: %s", err)
+	}
+	_, err = tx.Exec("DELETE FROM sequence WHERE num >= ? AND num <= ?", from, to)
+	if err == nil {
+		_, err = tx.Exec("DELETE FROM blocks WHERE num >= ? AND num <= ?", from, to)
+	}
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("error writing tx to db: %s", err)
+	}
+	return tx.Commit()
+}
+
+// Every sequence row should belong to a block.
+func (st *sqlBlockStore) CheckOrphans() (error) {
+	var orphan int64
+	err := st.db.QueryRow("SELECT num FROM sequence WHERE num NOT IN (SELECT num FROM blocks) ORDER BY num LIMIT 1").Scan(&orphan)
+	if err == nil {
+		return &ChainInconsistency{
+			Row: orphan,
+			Check: CheckSequence,
+			Err: fmt.Errorf("sequence row %d has no block", orphan),
+		}
+	}
+	if err != sql.ErrNoRows {
+		return fmt.Errorf("error fetching from db: %s", err)
+	}
+	return nil
+}
+
+// The database is closed by the core.
+// Each append is its own transaction, so it's on disk already.
+func (st *sqlBlockStore) Sync() (error) {
+	return nil
+}
+
+func (st *sqlBlockStore) Close() (error) {
+	return nil
+}
@@ -0,0 +1,228 @@
+package sequencer
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/messages"
+)
+
+// Block subscriptions.
+//
+// Subscribers receive blocks in height order, starting from a requested height.
+// Each subscriber pulls blocks at its own pace - recent blocks are served from
+// an in-memory window, and anything older is replayed from the database.
+// The core never blocks on a subscriber, so a slow consumer only slows itself.
+
+// Number of recent blocks kept in memory for subscribers.
+const recentBlocksWindow = 1024
+
+// Maximum number of blocks read from the database at a time during replay.
+const replayBatchSize = 1000
+
+type blockFeed struct {
+	mu sync.RWMutex
+	tip int64
+	recent []*messages.Block
+	// Closed and replaced whenever the tip changes.
+	tipChanged chan struct{}
+}
+
+func newBlockFeed() (*blockFeed) {
+	return &blockFeed{
+		tip: 0,
+		recent: make([]*messages.Block, recentBlocksWindow),
+		tipChanged: make(chan struct{}),
+	}
+}
+
+// Publishes a new tip block, waking up subscribers.
+func (f *blockFeed) publish(block *messages.Block) {
+	f.mu.Lock()
+	f.tip = block.Height
+	f.recent[block.Height % recentBlocksWindow] = block
+	close(f.tipChanged)
+	f.tipChanged = make(chan struct{})
+	f.mu.Unlock()
+}
+
+// Returns the current tip height, and a channel which is closed when it changes.
+func (f *blockFeed) current() (int64, <-chan struct{}) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.tip, f.tipChanged
+}
+
+// Returns the block at `height` if it's in the recent window.
+func (f *blockFeed) recentBlock(height int64) (*messages.Block) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	block := f.recent[height % recentBlocksWindow]
+	if block == nil || block.Height != height {
+		return nil
+	}
+	return block
+}
+
+// The oldest height in the recent window.
+func (f *blockFeed) oldestRecent() (int64) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.tip - recentBlocksWindow + 1
+}
+
+// A stream of blocks, delivered in height order.
+// It satisfies go-ethereum's event.Subscription interface.
+type BlockSubscription struct {
+	seq *SequencerCore
+	blocks chan *messages.Block
+	err chan error
+	quit chan struct{}
+	unsubscribeOnce sync.Once
+
+	mu sync.Mutex
+	next int64
+	slow bool
+}
+
+// Subscribes to blocks from `fromHeight` onwards. Blocks below the current tip are
+// replayed from the database first.
+func (s *SequencerCore) SubscribeBlocks(fromHeight int64) (*BlockSubscription) {
+	// Genesis is implicit, and isn't stored.
+	if fromHeight < 1 {
+		fromHeight = 1
+	}
+
+	sub := &BlockSubscription{
+		seq: s,
+		blocks: make(chan *messages.Block),
+		err: make(chan error, 1),
+		quit: make(chan struct{}),
+		next: fromHeight,
+	}
+	go sub.loop()
+	return sub
+}
+
+// The channel blocks are delivered on.
+func (sub *BlockSubscription) Blocks() (<-chan *messages.Block) {
+	return sub.blocks
+}
+
+// Receives an error if the subscription failed. Closed on unsubscribe.
+func (sub *BlockSubscription) Err() (<-chan error) {
+	return sub.err
+}
+
+func (sub *BlockSubscription) Unsubscribe() {
+	sub.unsubscribeOnce.Do(func() {
+		close(sub.quit)
+	})
+}
+
+// The number of blocks the subscriber is behind the tip.
+func (sub *BlockSubscription) Lag() (int64) {
+	tip, _ := sub.seq.feed.current()
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+
+	lag := tip - sub.next + 1
+	if lag < 0 {
+		return 0
+	}
+	return lag
+}
+
+// Whether the subscriber has fallen behind the recent window, and is being
+// served from the database.
+func (sub *BlockSubscription) Slow() (bool) {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	return sub.slow
+}
+
+func (sub *BlockSubscription) loop() {
+	feed := sub.seq.feed
+	defer close(sub.err)
+
+	for {
+		tip, tipChanged := feed.current()
+		next := sub.nextHeight()
+
+		// Caught up - wait for a new block.
+		if tip < next {
+			select {
+			case <-tipChanged:
+				continue
+			case <-sub.quit:
+				return
+			}
+		}
+
+		sub.setSlow(next < feed.oldestRecent())
+
+		var blocks []*messages.Block
+		if block := feed.recentBlock(next); block != nil {
+			blocks = []*messages.Block{block}
+		} else {
+			// Replay from the database.
+			to := next + replayBatchSize - 1
+			if tip < to {
+				to = tip
+			}
+
+			var err error
+			blocks, err = sub.seq.GetBlocks(uint64(next), uint64(to))
+			if err != nil {
+				sub.err <- err
+				return
+			}
+			if len(blocks) == 0 {
+				sub.err <- fmt.Errorf("block %d missing from database", next)
+				return
+			}
+		}
+
+		for _, block := range blocks {
+			if block.Height != next {
+				sub.err <- fmt.Errorf("expected block %d, got block %d", next, block.Height)
+				return
+			}
+
+			select {
+			case sub.blocks <- block:
+			case <-sub.quit:
+				return
+			}
+
+			next++
+			sub.setNextHeight(next)
+		}
+	}
+}
+
+func (sub *BlockSubscription) nextHeight() (int64) {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	return sub.next
+}
+
+func (sub *BlockSubscription) setNextHeight(height int64) {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	sub.next = height
+}
+
+func (sub *BlockSubscription) setSlow(slow bool) {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+
+	if slow != sub.slow {
+		if slow {
+			coreLog.Warnw("block subscriber is slow, replaying from database", "height", sub.next)
+		} else {
+			coreLog.Infow("block subscriber caught up", "height", sub.next)
+		}
+	}
+	sub.slow = slow
+}
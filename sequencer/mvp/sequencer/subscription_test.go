@@ -0,0 +1,103 @@
+package sequencer
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/messages"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// Sequences `count` txs, and waits for their blocks.
+func sequenceTestBlocks(t *testing.T, seq *SequencerCore, count int) {
+	signer := utils.NewEthereumECDSASigner("3977045d27df7e401ecf1596fd3ae86b59f666944f81ba8dbf547c2269902f6b")
+	from, _ := seq.feed.current()
+	for i := 0; i < count; i++ {
+		msg := messages.ConstructSequenceMessage(fmt.Sprintf("0x%08x", from + int64(i)), time.Minute)
+		msg.SetFrom(signer.GetPubkey())
+		msg = msg.Signed(signer)
+		_, err := seq.Sequence(msg.ToHex())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for {
+		tip, tipChanged := seq.feed.current()
+		if from + int64(count) <= tip {
+			return
+		}
+		select {
+		case <-tipChanged:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for block %d", from + int64(count))
+		}
+	}
+}
+
+func nextBlock(t *testing.T, sub *BlockSubscription) (*messages.Block) {
+	select {
+	case block := <-sub.Blocks():
+		return block
+	case err := <-sub.Err():
+		t.Fatalf("subscription failed: %s", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a block")
+	}
+	return nil
+}
+
+func TestSubscriptionReplay(t *testing.T) {
+	seq, err := getMockSequencer(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// More than fit in the recent window, so the oldest are replayed from the database.
+	total := recentBlocksWindow + replayBatchSize / 2
+	sequenceTestBlocks(t, seq, total)
+	assert.Equal(t, int64(total) - recentBlocksWindow + 1, seq.feed.oldestRecent())
+
+	sub := seq.SubscribeBlocks(0)
+	defer sub.Unsubscribe()
+	assert.Equal(t, int64(total), sub.Lag())
+
+	block := nextBlock(t, sub)
+	assert.Equal(t, int64(1), block.Height)
+	assert.True(t, sub.Slow())
+	for height := int64(2); height <= int64(total); height++ {
+		block = nextBlock(t, sub)
+		assert.Equal(t, height, block.Height)
+	}
+	assert.False(t, sub.Slow())
+
+	// Then new blocks, as they're published.
+	sequenceTestBlocks(t, seq, 2)
+	assert.Equal(t, int64(total + 1), nextBlock(t, sub).Height)
+	assert.Equal(t, int64(total + 2), nextBlock(t, sub).Height)
+}
+
+func TestSubscriptionFromRecentWindow(t *testing.T) {
+	seq, err := getMockSequencer(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sequenceTestBlocks(t, seq, 10)
+
+	// Recent blocks are served from memory, even if the database loses them.
+	_, err = seq.db.Exec("DELETE FROM blocks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub := seq.SubscribeBlocks(5)
+	for height := int64(5); height <= 10; height++ {
+		assert.Equal(t, height, nextBlock(t, sub).Height)
+	}
+	assert.False(t, sub.Slow())
+
+	sub.Unsubscribe()
+	_, ok := <-sub.Err()
+	assert.False(t, ok, "Err should be closed on unsubscribe")
+}
@@ -0,0 +1,633 @@
+package sequencer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/golang/protobuf/proto"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/accumulator"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/messages"
+	libp2pHost "github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/protocol"
+	"github.com/libp2p/go-msgio"
+)
+
+// Block sync.
+//
+// Gossip only carries new blocks, so replicas fetch the blocks they've missed from
+// peers over a request-response protocol. Each request is a stream, with one
+// SyncRequest and one SyncResponse.
+//
+// A replica syncs forward from its tip. A new replica can instead fast-sync from a
+// trusted checkpoint (<height>:<tip hash>) - it fetches the checkpoint and its block,
+// syncs forward from there, and backfills the blocks before it in the background.
+// Backfilled blocks are authenticated by the hash chain back from the checkpoint.
+
+const syncProtocolId = protocol.ID("/goliath/sync/1.0.0")
+
+const (
+	// Max number of blocks in a response.
+	syncBatchSize = 1000
+	// Responses stop growing past this size, so a batch of big blocks isn't too big to send.
+	syncResponseSoftLimit = 4 << 20
+	// Fits the largest block.
+	syncMaxMessageSize = 64 << 20
+	syncRequestTimeout = 30 * time.Second
+	// How often a replica checks its peers for new blocks and checkpoints.
+	syncInterval = 5 * time.Second
+)
+
+// Serves sync requests from peers.
+func (s *SequencerCore) handleSyncStream(stream network.Stream) {
+	defer stream.Close()
+	stream.SetDeadline(time.Now().Add(syncRequestTimeout))
+
+	req := &messages.SyncRequest{}
+	err := readSyncMsg(stream, req)
+	if err != nil {
+		p2pLog.Debugw("error reading sync request", "peer", stream.Conn().RemotePeer(), "err", err)
+		stream.Reset()
+		return
+	}
+
+	res, err := s.serveSyncRequest(req)
+	if err != nil {
+		res = &messages.SyncResponse{Error: err.Error()}
+	}
+	res.TipHeight, _ = s.feed.current()
+
+	err = writeSyncMsg(stream, res)
+	if err != nil {
+		p2pLog.Debugw("error writing sync response", "peer", stream.Conn().RemotePeer(), "err", err)
+		stream.Reset()
+	}
+}
+
+func (s *SequencerCore) serveSyncRequest(req *messages.SyncRequest) (*messages.SyncResponse, error) {
+	switch r := req.Request.(type) {
+	case *messages.SyncRequest_GetBlocks:
+		from, to := r.GetBlocks.FromHeight, r.GetBlocks.ToHeight
+		if from < 1 || to < from {
+			return nil, fmt.Errorf("invalid range %d-%d", from, to)
+		}
+		if syncBatchSize <= to - from {
+			to = from + syncBatchSize - 1
+		}
+
+		blocks, err := s.GetBlocks(uint64(from), uint64(to))
+		if err != nil {
+			return nil, err
+		}
+
+		res := &messages.SyncResponse{}
+		size := 0
+		for i, block := range blocks {
+			// Only return contiguous blocks - history may have a gap before a checkpoint.
+			if block.Height != from + int64(i) {
+				break
+			}
+			size += proto.Size(block)
+			if syncResponseSoftLimit < size && 0 < i {
+				break
+			}
+			res.Blocks = append(res.Blocks, block)
+		}
+		return res, nil
+
+	case *messages.SyncRequest_GetCheckpoint:
+		cp, err := s.GetCheckpoint(r.GetCheckpoint.Height)
+		if err != nil {
+			return nil, err
+		}
+		return &messages.SyncResponse{Checkpoint: cp}, nil
+	}
+
+	return nil, fmt.Errorf("unknown request")
+}
+
+func readSyncMsg(stream network.Stream, msg proto.Message) (error) {
+	buf, err := msgio.NewVarintReaderSize(stream, syncMaxMessageSize).ReadMsg()
+	if err != nil {
+		return err
+	}
+	return proto.Unmarshal(buf, msg)
+}
+
+func writeSyncMsg(stream network.Stream, msg proto.Message) (error) {
+	buf, err := proto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return msgio.NewVarintWriter(stream).WriteMsg(buf)
+}
+
+//
+// Core.
+// These run on the core loop, as they write to the database.
+//
+
+type syncWork struct {
+	fn func() (error)
+	done chan error
+}
+
+// Runs `fn` on the core loop, serially with block processing.
+func (s *SequencerCore) runOnLoop(fn func() (error)) (error) {
+	work := &syncWork{
+		fn: fn,
+		done: make(chan error, 1),
+	}
+	s.syncWork <- work
+	return <-work.done
+}
+
+// The height of the first block in the database, which is 1 unless the node synced
+// from a checkpoint and hasn't finished backfilling. Includes pruned blocks.
+func (s *SequencerCore) HistoryStart() (int64, error) {
+	start, err := s.store.First()
+	if err != nil {
+		return 0, err
+	}
+
+	var pruned *int64
+	err = s.db.QueryRow("SELECT MIN(num) FROM headers").Scan(&pruned)
+	if err != nil {
+		return 0, fmt.Errorf("error fetching from db: %s", err)
+	}
+	if pruned != nil && (start == 0 || *pruned < start) {
+		start = *pruned
+	}
+
+	if start == 0 {
+		return 1, nil
+	}
+	return start, nil
+}
+
+// Starts an empty chain from a checkpoint, and the block at its height.
+func (s *SequencerCore) StartFromCheckpoint(cp *messages.Checkpoint, tip *messages.Block) (error) {
+	return s.runOnLoop(func() (error) {
+		if s.LastBlock.Height != 0 {
+			return fmt.Errorf("the database already has blocks up to %d", s.LastBlock.Height)
+		}
+
+		operator, err := s.verifyCheckpoint(cp, tip)
+		if err != nil {
+			return err
+		}
+		if tip.Body == nil {
+			return fmt.Errorf("block body is empty")
+		}
+		err = s.verifySequenceMessage(tip.Body)
+		if err != nil {
+			return err
+		}
+		acc, err := accumulator.FromPeaks(cp.Height, cp.AccumulatorPeaks)
+		if err != nil {
+			return err
+		}
+
+		// The checkpoint is stored first, so the tip's accumulator can always be restored.
+		err = s.storeCheckpoint(cp)
+		if err != nil {
+			return err
+		}
+		err = s.store.Append(tip)
+		if err != nil {
+			return err
+		}
+
+		s.setOperator(operator)
+		s.LastBlock = tip
+		s.acc = acc
+		s.applyHandover(tip)
+		s.observeHeight(tip.Height)
+		s.Metrics.Blocks.Inc()
+		s.Metrics.ChainHeight.Set(float64(tip.Height))
+		s.feed.publish(tip)
+		coreLog.Infow("synced from checkpoint", "height", cp.Height, "hash", cp.PrettyHash(), "operator", s.operatorChangeHistory[s.operator])
+
+		// Blocks may have arrived while we were syncing.
+		for height := range s.unprocessedBlockAtHeight {
+			if height <= tip.Height {
+				delete(s.unprocessedBlockAtHeight, height)
+			}
+		}
+		return s.checkOutOfOrderBlocks()
+	})
+}
+
+// Stores a checkpoint from a peer, after checking it against our chain.
+func (s *SequencerCore) AddCheckpoint(cp *messages.Checkpoint) (error) {
+	return s.runOnLoop(func() (error) {
+		return s.addCheckpoint(cp)
+	})
+}
+
+// Inserts blocks from before the start of the history, after syncing from a checkpoint.
+// `blocks` must be in height order, and end at the block before the history start.
+func (s *SequencerCore) Backfill(blocks []*messages.Block) (error) {
+	return s.runOnLoop(func() (error) {
+		return s.backfill(blocks)
+	})
+}
+
+func (s *SequencerCore) backfill(blocks []*messages.Block) (error) {
+	start, err := s.HistoryStart()
+	if err != nil {
+		return err
+	}
+	if len(blocks) == 0 || blocks[len(blocks) - 1].Height != start - 1 {
+		return fmt.Errorf("backfill must end at block %d", start - 1)
+	}
+
+	next, err := s.getHeader(start)
+	if err != nil {
+		return err
+	}
+	if next == nil {
+		return fmt.Errorf("block %d is missing from the database", start)
+	}
+
+	// Walk back along the hash chain.
+	expectedHash := next.PrevBlockHash
+	for i := len(blocks) - 1; 0 <= i; i-- {
+		block := blocks[i]
+		if block.Height != start - int64(len(blocks) - i) {
+			return fmt.Errorf("expected block %d, got block %d", start - int64(len(blocks) - i), block.Height)
+		}
+		if !bytes.Equal(block.SigHash(), expectedHash) {
+			return fmt.Errorf("block %d has hash %s, expected %s", block.Height, block.PrettyHash(), hexutil.Encode(expectedHash))
+		}
+		if block.Body == nil {
+			return fmt.Errorf("block %d body is empty", block.Height)
+		}
+		err = s.verifySequenceMessage(block.Body)
+		if err != nil {
+			return fmt.Errorf("block %d: %s", block.Height, err)
+		}
+		expectedHash = block.PrevBlockHash
+	}
+	if blocks[0].Height == 1 && !bytes.Equal(expectedHash, genesisBlock().SigHash()) {
+		return fmt.Errorf("block 1 prevhash is not the genesis block")
+	}
+
+	err = s.store.Prepend(blocks)
+	if err != nil {
+		return err
+	}
+	coreLog.Debugw("backfilled blocks", "from", blocks[0].Height, "to", blocks[len(blocks) - 1].Height)
+
+	if blocks[0].Height == 1 {
+		return s.verifyBackfill()
+	}
+	return nil
+}
+
+// Checks the backfilled history matches the earliest checkpoint's accumulator.
+func (s *SequencerCore) verifyBackfill() (error) {
+	var height int64
+	err := s.db.QueryRow("SELECT MIN(height) FROM checkpoints").Scan(&height)
+	if err != nil {
+		return fmt.Errorf("error fetching from db: %s", err)
+	}
+	cp, err := s.GetCheckpoint(height)
+	if err != nil || cp == nil {
+		return fmt.Errorf("checkpoint %d is missing from the database", height)
+	}
+
+	acc := accumulator.New()
+	err = s.replayAccumulator(acc, cp.Height)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(acc.Root(), cp.AccumulatorRoot) {
+		return fmt.Errorf("backfilled history has accumulator root %s, but checkpoint %d has %s", hexutil.Encode(acc.Root()), cp.Height, hexutil.Encode(cp.AccumulatorRoot))
+	}
+
+	coreLog.Infow("backfilled history matches checkpoint", "height", cp.Height, "root", hexutil.Encode(cp.AccumulatorRoot))
+	return nil
+}
+
+//
+// Syncer.
+//
+
+// A checkpoint trusted by the node operator, to fast-sync from.
+type TrustedCheckpoint struct {
+	Height int64
+	Hash []byte
+}
+
+// Parses a checkpoint in the format <height>:<tip hash>.
+func ParseTrustedCheckpoint(str string) (*TrustedCheckpoint, error) {
+	parts := strings.Split(str, ":")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("checkpoint must be <height>:<tip hash>, got %q", str)
+	}
+
+	height, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || height < 1 {
+		return nil, fmt.Errorf("invalid checkpoint height %q", parts[0])
+	}
+	hash, err := hexutil.Decode(parts[1])
+	if err != nil || len(hash) != 32 {
+		return nil, fmt.Errorf("invalid checkpoint hash %q", parts[1])
+	}
+
+	return &TrustedCheckpoint{Height: height, Hash: hash}, nil
+}
+
+func (cp *TrustedCheckpoint) String() (string) {
+	return fmt.Sprintf("%d:%s", cp.Height, hexutil.Encode(cp.Hash))
+}
+
+// Syncs a replica's chain from its peers.
+type Syncer struct {
+	seq *SequencerCore
+	host libp2pHost.Host
+	peers *PeerManager
+	trusted *TrustedCheckpoint
+}
+
+func NewSyncer(seq *SequencerCore, host libp2pHost.Host) (*Syncer) {
+	host.SetStreamHandler(syncProtocolId, seq.handleSyncStream)
+	return &Syncer{
+		seq: seq,
+		host: host,
+	}
+}
+
+// Fast-syncs from `cp` if the database is empty, instead of syncing from genesis.
+// Must be called before the node starts.
+func (s *Syncer) SetTrustedCheckpoint(cp *TrustedCheckpoint) {
+	s.trusted = cp
+}
+
+func (s *Syncer) Run(ctx context.Context) {
+	if s.trusted != nil {
+		// The chain is at least this high, so we aren't ready until we've synced.
+		s.seq.observeHeight(s.trusted.Height)
+
+		for {
+			tip, _ := s.seq.feed.current()
+			if tip != 0 {
+				break
+			}
+
+			err := s.fastSync(ctx)
+			if err == nil {
+				break
+			}
+			p2pLog.Warnw("error syncing from checkpoint", "checkpoint", s.trusted, "err", err)
+			if !sleep(ctx, syncInterval) {
+				return
+			}
+		}
+	}
+
+	go s.backfill(ctx)
+
+	for {
+		err := s.syncForward(ctx)
+		if err != nil {
+			p2pLog.Debugw("error syncing blocks", "err", err)
+		}
+
+		err = s.syncCheckpoint(ctx)
+		if err != nil {
+			p2pLog.Debugw("error syncing checkpoint", "err", err)
+		}
+
+		if !sleep(ctx, syncInterval) {
+			return
+		}
+	}
+}
+
+func (s *Syncer) fastSync(ctx context.Context) (error) {
+	res, err := s.request(ctx, &messages.SyncRequest{
+		Request: &messages.SyncRequest_GetCheckpoint{
+			GetCheckpoint: &messages.GetCheckpointRequest{Height: s.trusted.Height},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	cp := res.Checkpoint
+	if cp == nil {
+		return fmt.Errorf("no peer has checkpoint %d", s.trusted.Height)
+	}
+	if cp.Height != s.trusted.Height || !bytes.Equal(cp.TipHash, s.trusted.Hash) {
+		return fmt.Errorf("peer returned checkpoint %d:%s", cp.Height, cp.PrettyHash())
+	}
+
+	blocks, err := s.getBlocks(ctx, cp.Height, cp.Height)
+	if err != nil {
+		return err
+	}
+	if len(blocks) == 0 {
+		return fmt.Errorf("no peer has block %d", cp.Height)
+	}
+
+	return s.seq.StartFromCheckpoint(cp, blocks[0])
+}
+
+// Fetches the blocks after the tip.
+func (s *Syncer) syncForward(ctx context.Context) (error) {
+	tip, _ := s.seq.feed.current()
+	next := tip + 1
+
+	for {
+		blocks, err := s.getBlocks(ctx, next, next + syncBatchSize - 1)
+		if err != nil {
+			return err
+		}
+		if len(blocks) == 0 {
+			return nil
+		}
+
+		for _, block := range blocks {
+			s.seq.ProcessBlock(block)
+		}
+		next = blocks[len(blocks) - 1].Height + 1
+		p2pLog.Debugw("synced blocks", "from", blocks[0].Height, "to", next - 1)
+	}
+}
+
+// Fetches the latest checkpoint, so we can serve it to other new replicas.
+func (s *Syncer) syncCheckpoint(ctx context.Context) (error) {
+	res, err := s.request(ctx, &messages.SyncRequest{
+		Request: &messages.SyncRequest_GetCheckpoint{
+			GetCheckpoint: &messages.GetCheckpointRequest{Height: 0},
+		},
+	})
+	if err != nil || res.Checkpoint == nil {
+		return err
+	}
+
+	latest, err := s.seq.GetCheckpoint(0)
+	if err != nil {
+		return err
+	}
+	tip, _ := s.seq.feed.current()
+	if (latest != nil && res.Checkpoint.Height <= latest.Height) || tip < res.Checkpoint.Height {
+		return nil
+	}
+
+	return s.seq.AddCheckpoint(res.Checkpoint)
+}
+
+// Fetches the history before the checkpoint we synced from, newest first.
+func (s *Syncer) backfill(ctx context.Context) {
+	batch := int64(syncBatchSize)
+
+	for {
+		start, err := s.seq.HistoryStart()
+		if err != nil {
+			// The database may be busy with new blocks.
+			p2pLog.Debugw("error backfilling history", "err", err)
+			if !sleep(ctx, time.Second) {
+				return
+			}
+			continue
+		}
+		if start <= 1 {
+			return
+		}
+
+		from := start - batch
+		if from < 1 {
+			from = 1
+		}
+		blocks, err := s.getBlocks(ctx, from, start - 1)
+		if err == nil && len(blocks) == 0 {
+			err = fmt.Errorf("no peer has blocks %d-%d", from, start - 1)
+		}
+		if err == nil && blocks[len(blocks) - 1].Height != start - 1 {
+			// The response was cut short, so ask for fewer blocks.
+			batch = int64(len(blocks))
+			continue
+		}
+		if err == nil {
+			err = s.seq.Backfill(blocks)
+		}
+		if err != nil {
+			p2pLog.Warnw("error backfilling history", "from", from, "to", start - 1, "err", err)
+			if !sleep(ctx, syncInterval) {
+				return
+			}
+			continue
+		}
+
+		if from == 1 {
+			p2pLog.Info("backfill complete")
+		}
+	}
+}
+
+func (s *Syncer) getBlocks(ctx context.Context, from int64, to int64) ([]*messages.Block, error) {
+	res, err := s.request(ctx, &messages.SyncRequest{
+		Request: &messages.SyncRequest_GetBlocks{
+			GetBlocks: &messages.GetBlocksRequest{FromHeight: from, ToHeight: to},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, block := range res.Blocks {
+		if block.Height != from + int64(i) || to < block.Height {
+			return nil, fmt.Errorf("peer returned block %d, expected %d", block.Height, from + int64(i))
+		}
+	}
+	return res.Blocks, nil
+}
+
+// Sends the request to each peer in turn, best first, returning the first non-empty response.
+func (s *Syncer) request(ctx context.Context, req *messages.SyncRequest) (*messages.SyncResponse, error) {
+	peers := s.peers.Sort(s.host.Network().Peers())
+	if len(peers) == 0 {
+		return nil, fmt.Errorf("no peers")
+	}
+
+	var (
+		res *messages.SyncResponse
+		err error
+	)
+	for _, peer := range peers {
+		res, err = s.requestPeer(ctx, peer, req)
+		if err != nil {
+			p2pLog.Debugw("sync request failed", "peer", peer, "err", err)
+			continue
+		}
+		if 0 < len(res.Blocks) || res.Checkpoint != nil {
+			return res, nil
+		}
+	}
+	if res != nil {
+		return res, nil
+	}
+	return nil, err
+}
+
+func (s *Syncer) requestPeer(ctx context.Context, peer peer.ID, req *messages.SyncRequest) (*messages.SyncResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, syncRequestTimeout)
+	defer cancel()
+
+	start := time.Now()
+	res, err := s.roundTrip(ctx, peer, req)
+	if err != nil {
+		s.peers.SyncFailed(peer)
+		return nil, err
+	}
+	s.peers.SyncResponded(peer, time.Since(start))
+	s.seq.observePeerHeight(peer, res.TipHeight)
+
+	// An error response is still a response.
+	if res.Error != "" {
+		return nil, fmt.Errorf("%s", res.Error)
+	}
+	return res, nil
+}
+
+func (s *Syncer) roundTrip(ctx context.Context, peer peer.ID, req *messages.SyncRequest) (*messages.SyncResponse, error) {
+	stream, err := s.host.NewStream(ctx, peer, syncProtocolId)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+	stream.SetDeadline(time.Now().Add(syncRequestTimeout))
+
+	err = writeSyncMsg(stream, req)
+	if err != nil {
+		stream.Reset()
+		return nil, err
+	}
+	stream.CloseWrite()
+
+	res := &messages.SyncResponse{}
+	err = readSyncMsg(stream, res)
+	if err != nil {
+		stream.Reset()
+		return nil, err
+	}
+	return res, nil
+}
+
+// Sleeps for `d`, returning false if the context was cancelled.
+func sleep(ctx context.Context, d time.Duration) (bool) {
+	select {
+	case <-time.After(d):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,147 @@
+package sequencer
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/messages"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestP2PNode(t *testing.T) (*P2PNode) {
+	node, err := NewP2PNode("/ip4/127.0.0.1/tcp/0", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		node.Close()
+	})
+	return node
+}
+
+func connectTestNodes(t *testing.T, a *P2PNode, b *P2PNode) {
+	err := a.Host.Connect(context.Background(), peer.AddrInfo{ID: b.Host.ID(), Addrs: b.Host.Addrs()})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestReplica(t *testing.T) (*SequencerCore) {
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s-replica-%d?mode=memory&cache=shared", t.Name(), time.Now().UnixNano()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	seq := NewSequencerCore(db, "")
+	t.Cleanup(seq.Close)
+	return seq
+}
+
+func waitForTip(t *testing.T, seq *SequencerCore, height int64) {
+	for {
+		tip, tipChanged := seq.feed.current()
+		if height <= tip {
+			return
+		}
+		select {
+		case <-tipChanged:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for block %d, tip is %d", height, tip)
+		}
+	}
+}
+
+func TestFastSyncAndBackfill(t *testing.T) {
+	primary, err := getMockSequencer(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	primary.SetCheckpointInterval(10)
+	sequenceTestBlocks(t, primary, 25)
+
+	replica := newTestReplica(t)
+	primaryNode, replicaNode := newTestP2PNode(t), newTestP2PNode(t)
+	NewSyncer(primary, primaryNode.Host)
+	syncer := NewSyncer(replica, replicaNode.Host)
+	connectTestNodes(t, replicaNode, primaryNode)
+
+	cp, err := primary.GetCheckpoint(20)
+	if err != nil || cp == nil {
+		t.Fatalf("no checkpoint at 20: %v", err)
+	}
+	ctx := context.Background()
+
+	// A checkpoint we don't trust is refused.
+	syncer.SetTrustedCheckpoint(&TrustedCheckpoint{Height: 20, Hash: []byte{1, 2, 3}})
+	assert.Error(t, syncer.fastSync(ctx))
+
+	// Starts at the checkpoint.
+	syncer.SetTrustedCheckpoint(&TrustedCheckpoint{Height: 20, Hash: cp.TipHash})
+	err = syncer.fastSync(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tip, _ := replica.feed.current()
+	assert.Equal(t, int64(20), tip)
+	start, err := replica.HistoryStart()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(20), start)
+
+	// Then syncs forward from it, and backfills the history before it.
+	assert.NoError(t, syncer.syncForward(ctx))
+	waitForTip(t, replica, 25)
+	syncer.backfill(ctx)
+	start, err = replica.HistoryStart()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), start)
+
+	ours, err := primary.GetBlocks(1, 25)
+	assert.NoError(t, err)
+	theirs, err := replica.GetBlocks(1, 25)
+	assert.NoError(t, err)
+	assert.Equal(t, len(ours), len(theirs))
+	for i := range ours {
+		assert.True(t, proto.Equal(ours[i], theirs[i]), "block %d differs", ours[i].Height)
+	}
+}
+
+func TestBackfillChecksHashChain(t *testing.T) {
+	primary, err := getMockSequencer(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	primary.SetCheckpointInterval(10)
+	sequenceTestBlocks(t, primary, 10)
+	cp, err := primary.GetCheckpoint(10)
+	if err != nil || cp == nil {
+		t.Fatalf("no checkpoint at 10: %v", err)
+	}
+	blocks, err := primary.GetBlocks(1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replica := newTestReplica(t)
+	err = replica.StartFromCheckpoint(cp, blocks[9])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Blocks which don't hash to the next block's prevhash are refused.
+	forged := proto.Clone(blocks[8]).(*messages.Block)
+	forged.Body.Data = []byte{0xff}
+	err = replica.Backfill(append(append([]*messages.Block{}, blocks[:8]...), forged))
+	assert.Error(t, err)
+	err = replica.Backfill(blocks[3:8])
+	assert.EqualError(t, err, "backfill must end at block 9")
+
+	assert.NoError(t, replica.Backfill(blocks[4:9]))
+	assert.NoError(t, replica.Backfill(blocks[:4]))
+	start, err := replica.HistoryStart()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), start)
+}
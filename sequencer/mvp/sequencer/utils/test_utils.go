@@ -21,11 +21,10 @@ type EthereumECDSASigner struct {
 }
 
 func NewEthereumECDSASignerFromKey(privateKey *ecdsa.PrivateKey) (*EthereumECDSASigner) {
-	s := &EthereumECDSASigner{
+	return &EthereumECDSASigner{
 		privateKey: privateKey,
+		publicKey: &privateKey.PublicKey,
 	}
-	s.publicKey = s.getPubkey()
-	return s
 }
 
 func NewEthereumECDSASigner(privateKeyHex string) (*EthereumECDSASigner) {
@@ -34,11 +33,7 @@ func NewEthereumECDSASigner(privateKeyHex string) (*EthereumECDSASigner) {
 		panic(err)
 	}
 
-	s := &EthereumECDSASigner{
-		privateKey: privateKey,
-	}
-	s.publicKey = s.getPubkey()
-	return s
+	return NewEthereumECDSASignerFromKey(privateKey)
 }
 
 func (s *EthereumECDSASigner) Sign(digestHash []byte) (sig []byte, err error) {
@@ -52,18 +47,3 @@ func (s *EthereumECDSASigner) GetPubkey() (*ecdsa.PublicKey) {
 func (s *EthereumECDSASigner) String() (string) {
 	return hexutil.Encode(crypto.FromECDSAPub(s.publicKey))
 }
-
-func (s *EthereumECDSASigner) getPubkey() (*ecdsa.PublicKey) {
-	// TODO probably not the best way to get the pubkey.
-	badData, _ := hexutil.Decode("0xaaaa")
-	digest := crypto.Keccak256Hash(badData).Bytes()
-	sig, err := s.Sign(digest)
-	if err != nil {
-		panic(err)
-	}
-	pub, err := crypto.SigToPub(digest, sig)
-	if err != nil {
-		panic(err)
-	}
-	return pub
-}
\ No newline at end of file
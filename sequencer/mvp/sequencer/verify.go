@@ -0,0 +1,283 @@
+package sequencer
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/golang/protobuf/proto"
+	"github.com/liamzebedee/goliath-blockchain/sequencer/mvp/sequencer/messages"
+)
+
+// Offline chain verification, for auditing a node's database after incidents.
+
+// The checks run on each block, in order.
+const (
+	CheckDecode = "decode"
+	CheckHeight = "height"
+	CheckHash = "hash"
+	CheckPrevHash = "prevhash"
+	CheckOperatorSig = "operator signature"
+	CheckSequence = "sequence"
+	CheckTx = "tx"
+)
+
+// The first inconsistency found in the chain.
+type ChainInconsistency struct {
+	// Row number in the blocks table, which is the expected block height.
+	Row int64
+	// The check which failed.
+	Check string
+	Err error
+	// The block, if it could be decoded.
+	Block *messages.Block
+}
+
+func (e *ChainInconsistency) Error() (string) {
+	return fmt.Sprintf("block %d: %s: %s", e.Row, e.Check, e.Err)
+}
+
+type ChainReport struct {
+	// Number of blocks verified.
+	Blocks int64
+	// Number of pruned blocks verified, of which only the header is kept.
+	Pruned int64
+	Tip *messages.Block
+	// Number of operator handovers in the chain.
+	Handovers int
+}
+
+// Walks the chain from genesis, checking for every block:
+//
+//  - it decodes, and its height follows the previous block's.
+//  - it's stored under its hash, and its prevhash links to the previous block.
+//  - it's signed by the operator, honoring handovers.
+//  - the row in the sequence table matches the block body.
+//  - the tx is well-formed and signed by its sender.
+//
+// Pruned blocks only have headers, which are checked against the hash chain and
+// the operator's signature over the header hash. The body and tx checks are skipped.
+//
+// Returns a *ChainInconsistency for the first inconsistency found, and a report
+// of the blocks verified before it.
+func (s *SequencerCore) VerifyChain() (*ChainReport, error) {
+	v := &chainVerifier{
+		s: s,
+		report: &ChainReport{
+			Tip: genesisBlock(),
+		},
+	}
+	v.prevHeight, v.prevHash = v.report.Tip.Height, v.report.Tip.SigHash()
+
+	// Walk the stored blocks, merging in the headers of pruned blocks.
+	res, err := s.db.Query("SELECT num, header, hash FROM headers ORDER BY num")
+	if err != nil {
+		return v.report, fmt.Errorf("error fetching from db: %s", err)
+	}
+	defer res.Close()
+
+	// The next header, read ahead of the blocks.
+	var (
+		pending bool
+		num int64
+		headerBuf []byte
+		hash []byte
+	)
+	// Verifies the headers up to `height`. A header at the height of a stored block
+	// fails on the block.
+	headersThrough := func(height int64) (error) {
+		for {
+			if !pending {
+				if !res.Next() {
+					break
+				}
+				err := res.Scan(&num, &headerBuf, &hash)
+				if err != nil {
+					return fmt.Errorf("error fetching from db: %s", err)
+				}
+				pending = true
+			}
+			if height < num {
+				return nil
+			}
+			err := v.verifyHeader(num, headerBuf, hash)
+			if err != nil {
+				return err
+			}
+			pending = false
+		}
+		if err := res.Err(); err != nil {
+			return fmt.Errorf("error fetching from db: %s", err)
+		}
+		return nil
+	}
+
+	err = s.store.Scan(func(stored *storedBlock) (error) {
+		err := headersThrough(stored.Height)
+		if err != nil {
+			return err
+		}
+		return v.verifyBlock(stored)
+	})
+	if err == nil {
+		err = headersThrough(math.MaxInt64)
+	}
+	if err != nil {
+		return v.report, err
+	}
+
+	err = s.store.CheckOrphans()
+	if err != nil {
+		return v.report, err
+	}
+
+	return v.report, nil
+}
+
+type chainVerifier struct {
+	s *SequencerCore
+	report *ChainReport
+	// Index of the operator for the next block.
+	operator int
+	prevHeight int64
+	prevHash []byte
+}
+
+// Checks the next row is at the next height.
+func (v *chainVerifier) checkHeight(num int64) (error) {
+	expectedHeight := v.prevHeight + 1
+	if num < expectedHeight {
+		return &ChainInconsistency{
+			Row: num,
+			Check: CheckHeight,
+			Err: fmt.Errorf("row %d is both pruned and not pruned", num),
+		}
+	}
+	if num != expectedHeight {
+		return &ChainInconsistency{
+			Row: expectedHeight,
+			Check: CheckHeight,
+			Err: fmt.Errorf("row %d is missing, next row is %d", expectedHeight, num),
+		}
+	}
+	return nil
+}
+
+func (v *chainVerifier) verifyHeader(num int64, headerBuf []byte, hash []byte) (error) {
+	fail := func(check string, format string, args ...interface{}) (error) {
+		return &ChainInconsistency{
+			Row: num,
+			Check: check,
+			Err: fmt.Errorf(format, args...),
+		}
+	}
+
+	err := v.checkHeight(num)
+	if err != nil {
+		return err
+	}
+
+	header := &messages.BlockHeader{}
+	err = proto.Unmarshal(headerBuf, header)
+	if err != nil {
+		return fail(CheckDecode, "couldn't decode header: %s", err)
+	}
+	if header.Height != num {
+		return fail(CheckHeight, "header has height %d, expected %d", header.Height, num)
+	}
+	if !bytes.Equal(hash, header.Hash) {
+		return fail(CheckHash, "stored under hash %s, but header hash is %s", hexutil.Encode(hash), hexutil.Encode(header.Hash))
+	}
+	if !bytes.Equal(header.PrevBlockHash, v.prevHash) {
+		return fail(CheckPrevHash, "prevhash is %s, but previous block hash is %s", hexutil.Encode(header.PrevBlockHash), hexutil.Encode(v.prevHash))
+	}
+
+	operator := v.s.operatorChangeHistory[v.operator]
+	err = verifyOperatorDigest(operator, header.Hash, header.Sig, header.Sigs)
+	if err != nil {
+		return fail(CheckOperatorSig, "%s (operator %s)", err, operator)
+	}
+
+	v.report.Pruned++
+	v.next(header.Height, header.Hash)
+	return nil
+}
+
+func (v *chainVerifier) verifyBlock(stored *storedBlock) (error) {
+	num := stored.Height
+	fail := func(check string, block *messages.Block, format string, args ...interface{}) (error) {
+		return &ChainInconsistency{
+			Row: num,
+			Check: check,
+			Err: fmt.Errorf(format, args...),
+			Block: block,
+		}
+	}
+
+	err := v.checkHeight(num)
+	if err != nil {
+		return err
+	}
+
+	block := &messages.Block{}
+	err = proto.Unmarshal(stored.Block, block)
+	if err != nil {
+		return fail(CheckDecode, nil, "couldn't decode block: %s", err)
+	}
+	if block.Height != num {
+		return fail(CheckHeight, block, "block has height %d, expected %d", block.Height, num)
+	}
+
+	if !bytes.Equal(stored.Hash, block.SigHash()) {
+		return fail(CheckHash, block, "stored under hash %s, but block hash is %s", hexutil.Encode(stored.Hash), block.PrettyHash())
+	}
+
+	if !bytes.Equal(block.PrevBlockHash, v.prevHash) {
+		return fail(CheckPrevHash, block, "prevhash is %s, but previous block hash is %s", hexutil.Encode(block.PrevBlockHash), hexutil.Encode(v.prevHash))
+	}
+
+	operator := v.s.operatorChangeHistory[v.operator]
+	err = verifyOperatorSig(operator, block)
+	if err != nil {
+		return fail(CheckOperatorSig, block, "%s (operator %s)", err, operator)
+	}
+
+	if block.Body == nil {
+		return fail(CheckSequence, block, "block body is empty")
+	}
+	// Only the SQL store keeps txs separately.
+	if stored.SequenceMissing {
+		return fail(CheckSequence, block, "sequence row %d is missing", num)
+	}
+	if stored.Sequence != nil {
+		sequenceTx := &messages.SequenceTx{}
+		err = proto.Unmarshal(stored.Sequence, sequenceTx)
+		if err != nil {
+			return fail(CheckSequence, block, "couldn't decode sequence row: %s", err)
+		}
+		if !proto.Equal(sequenceTx, block.Body) {
+			return fail(CheckSequence, block, "sequence row %s doesn't match block body %s", hexutil.Encode(sequenceTx.SigHash()), hexutil.Encode(block.Body.SigHash()))
+		}
+	}
+
+	err = v.s.verifySequenceMessage(block.Body)
+	if err != nil {
+		return fail(CheckTx, block, "%s", err)
+	}
+
+	// Block is valid.
+	v.report.Blocks++
+	v.report.Tip = block
+	v.next(block.Height, block.SigHash())
+	return nil
+}
+
+// Moves past a verified block, advancing to the next operator after a handover.
+func (v *chainVerifier) next(height int64, hash []byte) {
+	v.prevHeight, v.prevHash = height, hash
+	if v.operator + 1 < len(v.s.operatorChangeHistory) && bytes.Equal(v.s.operatorChangeHistory[v.operator + 1].BlockHash, hash) {
+		v.operator++
+		v.report.Handovers++
+	}
+}
@@ -0,0 +1,147 @@
+package sequencer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// Connectivity watchdog, for replicas.
+//
+// A replica with no peers silently falls behind, so at startup it waits for
+// `minPeers` peers, and fails if it doesn't have them within `connectTimeout`.
+// After that, it redials the bootstrap peers whenever they're disconnected, backing
+// off exponentially for each one, and logs a heartbeat with the number of peers.
+
+const (
+	DefaultMinPeers = 1
+	DefaultConnectTimeout = 10 * time.Second
+
+	watchdogInterval = time.Second
+	heartbeatInterval = 30 * time.Second
+	reconnectMinBackoff = time.Second
+	reconnectMaxBackoff = time.Minute
+	reconnectDialTimeout = 5 * time.Second
+)
+
+type Watchdog struct {
+	p2p *P2PNode
+	metrics *Metrics
+	minPeers int
+	// 0 to wait forever.
+	connectTimeout time.Duration
+
+	// When each bootstrap peer can next be redialled, and the backoff after that.
+	nextDial map[peer.ID]time.Time
+	backoff map[peer.ID]time.Duration
+}
+
+func NewWatchdog(p2p *P2PNode) (*Watchdog) {
+	return &Watchdog{
+		p2p: p2p,
+		metrics: p2p.metrics,
+		minPeers: DefaultMinPeers,
+		connectTimeout: DefaultConnectTimeout,
+		nextDial: make(map[peer.ID]time.Time),
+		backoff: make(map[peer.ID]time.Duration),
+	}
+}
+
+// Sets the minimum number of peers, and how long to wait for them at startup.
+// Must be called before the node starts.
+func (w *Watchdog) SetMinPeers(minPeers int, connectTimeout time.Duration) {
+	w.minPeers = minPeers
+	w.connectTimeout = connectTimeout
+}
+
+func (w *Watchdog) peers() (int) {
+	return len(w.p2p.Host.Network().Peers())
+}
+
+// Waits for the minimum number of peers, then keeps the node connected. Returns an
+// error if the peers don't connect in time.
+func (w *Watchdog) Run(ctx context.Context) (error) {
+	// Dials are cut short at the deadline, so they can't hold up the check.
+	startCtx := ctx
+	if 0 < w.connectTimeout {
+		var cancel context.CancelFunc
+		startCtx, cancel = context.WithTimeout(ctx, w.connectTimeout)
+		defer cancel()
+	}
+
+	p2pLog.Infow("waiting for peers", "min", w.minPeers, "timeout", w.connectTimeout)
+	for w.peers() < w.minPeers {
+		if startCtx.Err() != nil && ctx.Err() == nil {
+			return fmt.Errorf("connected to %d peers after %s, need %d", w.peers(), w.connectTimeout, w.minPeers)
+		}
+		w.reconnect(startCtx)
+		if !sleep(startCtx, watchdogInterval) && ctx.Err() != nil {
+			return nil
+		}
+	}
+	p2pLog.Infow("connected to the network", "peers", w.peers())
+
+	lastHeartbeat := time.Now()
+	for sleep(ctx, watchdogInterval) {
+		w.reconnect(ctx)
+		connected := w.minPeers <= w.peers()
+		if w.metrics != nil {
+			if connected {
+				w.metrics.Connected.Set(1)
+			} else {
+				w.metrics.Connected.Set(0)
+			}
+		}
+
+		if heartbeatInterval <= time.Since(lastHeartbeat) {
+			lastHeartbeat = time.Now()
+			if connected {
+				p2pLog.Infow("heartbeat", "peers", w.peers())
+			} else {
+				p2pLog.Warnw("heartbeat - too few peers", "peers", w.peers(), "min", w.minPeers)
+			}
+		}
+	}
+	return nil
+}
+
+// Redials the disconnected bootstrap peers which are due.
+func (w *Watchdog) reconnect(ctx context.Context) {
+	host := w.p2p.Host
+	for _, info := range w.p2p.bootstrapPeers {
+		id := info.ID
+		if id == host.ID() || host.Network().Connectedness(id) == network.Connected {
+			delete(w.backoff, id)
+			continue
+		}
+		if time.Now().Before(w.nextDial[id]) {
+			continue
+		}
+
+		if w.metrics != nil {
+			w.metrics.BootstrapReconnects.Inc()
+		}
+		dialCtx, cancel := context.WithTimeout(ctx, reconnectDialTimeout)
+		err := host.Connect(dialCtx, info)
+		cancel()
+		if err == nil {
+			p2pLog.Infow("reconnected to bootstrap peer", "peer", id)
+			delete(w.backoff, id)
+			continue
+		}
+
+		backoff := w.backoff[id]
+		if backoff == 0 {
+			backoff = reconnectMinBackoff
+		}
+		p2pLog.Debugw("error reconnecting to bootstrap peer", "peer", id, "retry", backoff, "err", err)
+		w.nextDial[id] = time.Now().Add(backoff)
+		w.backoff[id] = backoff * 2
+		if reconnectMaxBackoff < w.backoff[id] {
+			w.backoff[id] = reconnectMaxBackoff
+		}
+	}
+}
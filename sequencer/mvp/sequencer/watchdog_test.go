@@ -0,0 +1,124 @@
+package sequencer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchdogConnectTimeout(t *testing.T) {
+	w := NewWatchdog(newTestP2PNode(t))
+	w.SetMinPeers(1, 100 * time.Millisecond)
+	assert.Error(t, w.Run(context.Background()))
+}
+
+func TestWatchdogReconnect(t *testing.T) {
+	boot := newTestP2PNode(t)
+	addrs, err := peer.AddrInfoToP2pAddrs(&peer.AddrInfo{ID: boot.Host.ID(), Addrs: boot.Host.Addrs()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	node, err := NewP2PNode("/ip4/127.0.0.1/tcp/0", nil, addrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		node.Close()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- NewWatchdog(node).Run(ctx)
+	}()
+
+	connected := func() (bool) {
+		return node.Host.Network().Connectedness(boot.Host.ID()) == network.Connected
+	}
+	assert.Eventually(t, connected, 5 * time.Second, 10 * time.Millisecond)
+	node.Host.Network().ClosePeer(boot.Host.ID())
+	assert.Eventually(t, connected, 5 * time.Second, 10 * time.Millisecond)
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("watchdog didn't stop")
+	}
+}
+
+func TestWatchdogBackoff(t *testing.T) {
+	// A bootstrap peer which isn't there.
+	addrs, err := peer.AddrInfoToP2pAddrs(&peer.AddrInfo{ID: randomPeerID(t), Addrs: newTestP2PNode(t).Host.Addrs()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	node, err := NewP2PNode("/ip4/127.0.0.1/tcp/0", nil, addrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		node.Close()
+	})
+	w := NewWatchdog(node)
+	id := node.bootstrapPeers[0].ID
+
+	w.reconnect(context.Background())
+	assert.Equal(t, 2 * reconnectMinBackoff, w.backoff[id])
+	assert.True(t, time.Now().Before(w.nextDial[id]))
+
+	// It isn't redialled until it's due.
+	w.reconnect(context.Background())
+	assert.Equal(t, 2 * reconnectMinBackoff, w.backoff[id])
+
+	for i := 0; i < 10; i++ {
+		w.nextDial[id] = time.Time{}
+		w.reconnect(context.Background())
+	}
+	assert.Equal(t, reconnectMaxBackoff, w.backoff[id])
+}
+
+func TestWatchdogDialTimeout(t *testing.T) {
+	// A bootstrap peer which accepts connections, but never answers.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+	addr, err := ma.NewMultiaddr(fmt.Sprintf("/ip4/127.0.0.1/tcp/%d", listener.Addr().(*net.TCPAddr).Port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	addrs, err := peer.AddrInfoToP2pAddrs(&peer.AddrInfo{ID: randomPeerID(t), Addrs: []ma.Multiaddr{addr}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	node, err := NewP2PNode("/ip4/127.0.0.1/tcp/0", nil, addrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		node.Close()
+	})
+
+	start := time.Now()
+	NewWatchdog(node).reconnect(context.Background())
+	assert.Less(t, int64(time.Since(start)), int64(reconnectDialTimeout + time.Second))
+}